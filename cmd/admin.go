@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"golang.org/x/term"
+	"icecreamshop/internal/admin"
+	"icecreamshop/internal/config"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	userUsage   = "usage: user create --email EMAIL --name NAME --last-name LAST_NAME [--admin] | user promote --email EMAIL [--staff] | user reset-password --email EMAIL (passwords are asked for)"
+	flavorUsage = "usage: flavor import FILE (a JSON list of flavors, - reads it from the standard input)"
+	priceUsage  = "usage: price set --weight GRAMS --price AMOUNT [--currency CODE] [--max-flavors N] (the amount is in minor units, like cents)"
+	orderUsage  = "usage: order list [--status STATUS[,STATUS]] [--user EMAIL]"
+)
+
+// runUser runs the user commands: create, promote and reset-password.
+// Passwords have no flag, so they are not visible in the process list: they are read from the standard input.
+func runUser(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+	ctx := context.Background()
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user")
+
+	switch args[0] {
+	case "create":
+		name := flags.String("name", "", "first name of the user")
+		lastName := flags.String("last-name", "", "last name of the user")
+		asAdmin := flags.Bool("admin", false, "makes the user an admin")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		return withDBStorage(cfg, func(store storage.Storage) error {
+			user, err := admin.CreateUser(ctx, store, types.User{Email: *email, Name: *name, LastName: *lastName, Password: password}, *asAdmin)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created user %d (%s) with permissions %v\n", user.ID, user.Email, user.Permissions)
+			return nil
+		})
+	case "promote":
+		asStaff := flags.Bool("staff", false, "makes the user part of the staff instead of an admin")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		role := admin.AdminRole
+		if *asStaff {
+			role = admin.StaffRole
+		}
+		return withDBStorage(cfg, func(store storage.Storage) error {
+			if err := admin.PromoteUser(ctx, store, *email, role); err != nil {
+				return err
+			}
+			fmt.Printf("%s is now %s\n", *email, role)
+			return nil
+		})
+	case "reset-password":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		return withDBStorage(cfg, func(store storage.Storage) error {
+			if err := admin.ResetPassword(ctx, store, *email, password); err != nil {
+				return err
+			}
+			fmt.Printf("the password of %s was replaced\n", *email)
+			return nil
+		})
+	default:
+		return errors.New(userUsage)
+	}
+}
+
+// runFlavor runs the flavor commands: import.
+func runFlavor(cfg config.Config, args []string) error {
+	if len(args) != 2 || args[0] != "import" {
+		return errors.New(flavorUsage)
+	}
+	var reader io.Reader = os.Stdin
+	if args[1] != "-" {
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+	return withDBStorage(cfg, func(store storage.Storage) error {
+		result, err := admin.ImportFlavors(context.Background(), store, reader)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("added %d flavors %v and updated %d flavors %v\n", len(result.Added), result.Added, len(result.Updated), result.Updated)
+		return nil
+	})
+}
+
+// runPrice runs the price commands: set.
+func runPrice(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "set" {
+		return errors.New(priceUsage)
+	}
+	flags := flag.NewFlagSet("price set", flag.ContinueOnError)
+	weight := flags.Uint("weight", 0, "weight of the tub size in grams")
+	amount := flags.Int64("price", 0, "price in minor units of the currency, like cents")
+	currency := flags.String("currency", cfg.Store.DefaultCurrency(), "ISO 4217 code of the currency of the price")
+	maxFlavors := flags.Uint("max-flavors", 4, "max amount of flavors of the tub size")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	price := types.IceCreamTubPrice{
+		Weight:     *weight,
+		Price:      types.NewMoney(*amount, strings.ToUpper(*currency)),
+		MaxFlavors: *maxFlavors,
+	}
+	return withDBStorage(cfg, func(store storage.Storage) error {
+		added, err := admin.SetPrice(context.Background(), store, cfg.Store, price)
+		if err != nil {
+			return err
+		}
+		action := "updated"
+		if added {
+			action = "added"
+		}
+		fmt.Printf("%s the %dg tub at %s with up to %d flavors\n", action, price.Weight, price.Price, price.MaxFlavors)
+		return nil
+	})
+}
+
+// runOrder runs the order commands: list.
+func runOrder(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return errors.New(orderUsage)
+	}
+	flags := flag.NewFlagSet("order list", flag.ContinueOnError)
+	statuses := flags.String("status", "", "comma separated statuses of the orders")
+	email := flags.String("user", "", "email of the user who made the orders")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	filter := admin.OrderFilter{UserEmail: *email}
+	if *statuses != "" {
+		for _, status := range strings.Split(*statuses, ",") {
+			filter.Statuses = append(filter.Statuses, strings.TrimSpace(status))
+		}
+	}
+	return withDBStorage(cfg, func(store storage.Storage) error {
+		orders, err := admin.ListOrders(context.Background(), store, filter)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tUSER\tSTATUS\tPAYMENT\tTUBS\tTOTAL\tADDRESS")
+		for _, order := range orders {
+			fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t%d\t%s\t%s\n",
+				order.ID, order.UserID, order.Status, order.PaymentState, len(order.IceCreamTubs), order.TotalCost, order.Address)
+		}
+		return writer.Flush()
+	})
+}
+
+// readPassword reads a password from the terminal without echoing it.
+// When the standard input is not a terminal, like in scripts, it reads its first line.
+func readPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, "password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
@@ -1,17 +1,59 @@
 package main
 
-import "icecreamshop/internal/types"
+import (
+	"icecreamshop/internal/config"
+	"icecreamshop/internal/types"
+)
 
-var flavorDDL types.Flavor = types.Flavor{ID: "ddl", Name: "Dulce de leche", Type: "Dulce de leches"}
-var flavorMRC types.Flavor = types.Flavor{ID: "mrc", Name: "Chocolate marroc", Type: "Chocolates"}
-var flavorTRM types.Flavor = types.Flavor{ID: "trm", Name: "Tramontana", Type: "Cremas"}
-var flavorFRT types.Flavor = types.Flavor{ID: "frt", Name: "Frutilla al agua", Type: "Al agua"}
+var flavorDDL types.Flavor = types.Flavor{
+	ID:         "ddl",
+	Name:       "Dulce de leche",
+	CategoryID: "dulce-de-leches",
+	Stock:      10000,
+	Allergens:  []string{"lactose"},
+}
+var flavorMRC types.Flavor = types.Flavor{
+	ID:         "mrc",
+	Name:       "Chocolate marroc",
+	CategoryID: "chocolates",
+	Stock:      10000,
+	Allergens:  []string{"lactose", "nuts"},
+}
+var flavorTRM types.Flavor = types.Flavor{
+	ID:         "trm",
+	Name:       "Tramontana",
+	CategoryID: "cremas",
+	Stock:      10000,
+	Allergens:  []string{"lactose", "gluten"},
+}
+var flavorFRT types.Flavor = types.Flavor{
+	ID:         "frt",
+	Name:       "Frutilla al agua",
+	CategoryID: "al-agua",
+	Stock:      10000,
+	Diets:      []string{"vegan", "lactose-free", "gluten-free"},
+}
+
+func initialCategories() []types.FlavorCategory {
+	return []types.FlavorCategory{
+		{ID: "dulce-de-leches", Name: "Dulce de leches", SortOrder: 1},
+		{ID: "chocolates", Name: "Chocolates", SortOrder: 2},
+		{ID: "cremas", Name: "Cremas", SortOrder: 3},
+		{ID: "al-agua", Name: "Al agua", SortOrder: 4},
+	}
+}
 
 func initialFlavors() []types.Flavor {
 	return []types.Flavor{flavorDDL, flavorMRC, flavorTRM, flavorFRT}
 }
 
-func initialUsers() []types.User {
+// initialUsers has an admin with a known password, so it is only added in the development mode
+// and to the memory store of the testing mode, which is lost when the server stops.
+// The production mode starts without users, and its admins are created with the user create command.
+func initialUsers(mode string) []types.User {
+	if mode == config.Production {
+		return []types.User{}
+	}
 	return []types.User{
 		{
 			ID:          1,
@@ -25,10 +67,11 @@ func initialUsers() []types.User {
 	}
 }
 
-func initialPrices() map[uint]uint {
-	return map[uint]uint{
-		250:  3,
-		500:  5,
-		1000: 10,
+// initialPrices are measured in minor units of the store currency, like cents.
+func initialPrices(currency string) []types.IceCreamTubPrice {
+	return []types.IceCreamTubPrice{
+		{Weight: 250, Price: types.NewMoney(300, currency), MaxFlavors: 3},
+		{Weight: 500, Price: types.NewMoney(500, currency), MaxFlavors: 3},
+		{Weight: 1000, Price: types.NewMoney(1000, currency), MaxFlavors: 4},
 	}
 }
@@ -1,38 +1,137 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"icecreamshop/internal/api"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/storage"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
+const usage = `usage: icecreamshop [flags] [command]
+
+commands:
+  serve                  starts the server, the default command
+  migrate                applies, reverts or lists the migrations of the database
+  seed                   adds the missing initial data to the database
+  user create            signs up a user, optionally as an admin
+  user promote           makes a user an admin or part of the staff
+  user reset-password    replaces the password of a user
+  flavor import          adds or replaces the flavors of a JSON file
+  price set              adds a tub size or replaces its price
+  order list             lists the orders, optionally by status or user
+
+The settings are read from the env, the .env file included, then from the config file and then from the flags,
+each overriding the ones before.
+
+flags:`
+
 func main() {
 	err := loadEnv()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = validateEnvironments()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage()
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	if command == "help" {
+		printUsage()
+		return
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch command {
+	case "serve":
+		err = serve(cfg)
+	case "migrate":
+		err = runMigrate(cfg, args)
+	case "seed":
+		err = runSeed(cfg, args)
+	case "user":
+		err = runUser(cfg, args)
+	case "flavor":
+		err = runFlavor(cfg, args)
+	case "price":
+		err = runPrice(cfg, args)
+	case "order":
+		err = runOrder(cfg, args)
+	default:
+		printUsage()
+		os.Exit(2)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	users := initialUsers()
-	prices := initialPrices()
-	flavors := initialFlavors()
+func printUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	config.PrintFlags(os.Stderr)
+}
 
-	api_env := os.Getenv("API_ENV")
+// serve starts the server with the storage of the mode, until it fails or the process is interrupted or terminated.
+// Then the requests in progress are answered before the storage is closed.
+func serve(cfg config.Config) error {
 	var db storage.Storage
-	if api_env == "development" || api_env == "production" {
-		db = storage.NewDBStorage(flavors, users, prices)
-	} else if api_env == "testing" {
-		db = storage.NewMemoryStorage(flavors, users, prices)
+	if cfg.Mode == config.Testing {
+		db = storage.NewMemoryStorage(cfg.Store, initialCategories(), initialFlavors(), initialUsers(cfg.Mode), initialPrices(cfg.Store.DefaultCurrency()))
 	} else {
-		log.Fatal("Invalid API_ENV")
+		db = newDBStorage(cfg)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scheme := "http"
+	if cfg.Server.TLS() {
+		scheme = "https"
+	}
+	log.Printf("Server running in %v mode on %v://%v\n", cfg.Mode, scheme, cfg.Server.Address())
+	sv := api.NewServer(db, cfg)
+	err := sv.Start(ctx)
+	if err == nil {
+		log.Println("Server stopped")
 	}
+	return err
+}
 
-	log.Printf("Server running in %v mode\n", api_env)
-	sv := api.NewServer(db)
-	log.Fatal(sv.Start())
+// newDBStorage opens the database of the driver of the config: postgres, the default, or sqlite.
+// Pending migrations are applied before the server starts, while the initial data is only added by the seed command.
+func newDBStorage(cfg config.Config) *storage.DbStorage {
+	if cfg.Database.Driver == config.SQLiteDriver {
+		return storage.NewSQLiteStorage(cfg)
+	}
+	return storage.NewDBStorage(cfg)
+}
+
+// withDBStorage runs an admin command on the database of the server, which is closed afterwards.
+// The memory storage of the testing mode is not shared with the server, so commands cannot use it.
+func withDBStorage(cfg config.Config, command func(store storage.Storage) error) error {
+	if cfg.Mode == config.Testing {
+		return errors.New("admin commands need a database, set the mode to development or production")
+	}
+	store := newDBStorage(cfg)
+	defer store.Close()
+	return command(store)
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"icecreamshop/internal/config"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/storage/migrations"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const (
+	migrateUsage = "usage: migrate up | migrate down [steps] | migrate status"
+	seedUsage    = "usage: seed"
+)
+
+// runMigrate runs the migrate command on the database of the config, the test database in the testing mode.
+//   - up applies every pending migration.
+//   - down reverts the last applied migration, or the last steps ones.
+//   - status lists every migration and when it was applied.
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	switch args[0] {
+	case "up":
+		applied, err := storage.Migrate(db, cfg.Store.DefaultCurrency())
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("the database is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New("the steps of migrate down must be a positive number")
+			}
+		}
+		reverted, err := migrations.Down(db, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("there are no applied migrations")
+		}
+		return err
+	case "status":
+		statuses, err := migrations.Statuses(db)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(writer, "%04d\t%s\t%s\n", status.Version, status.Name, state)
+		}
+		return writer.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// runSeed adds the initial categories, flavors and prices that are missing to the database of the config,
+// after applying its pending migrations. The admin with the default password is only added in the development mode.
+// Rows that already exist are left as they are, so it can run more than once.
+func runSeed(cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return errors.New(seedUsage)
+	}
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	if _, err := storage.Migrate(db, cfg.Store.DefaultCurrency()); err != nil {
+		return err
+	}
+	categories, flavors, users, prices := initialCategories(), initialFlavors(), initialUsers(cfg.Mode), initialPrices(cfg.Store.DefaultCurrency())
+	if err := storage.Seed(db, categories, flavors, users, prices); err != nil {
+		return err
+	}
+	fmt.Printf("the initial data is in the database: %d categories, %d flavors, %d users and %d prices\n", len(categories), len(flavors), len(users), len(prices))
+	return nil
+}
+
+// openDB connects to the database of the config, without migrating nor seeding it.
+func openDB(cfg config.Config) (*gorm.DB, error) {
+	if err := cfg.ValidateDatabase(); err != nil {
+		return nil, err
+	}
+	if cfg.Database.Driver == config.SQLiteDriver {
+		return storage.OpenSQLite(cfg.Database.SQLitePath)
+	}
+	return storage.OpenPostgres(cfg.PostgresDatabase())
+}
+
+func closeDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		_ = sqlDB.Close()
+	}
+}
@@ -0,0 +1,173 @@
+// Package admin has the operations of the admin commands of the binary.
+// They work on any storage, with the same validations as the api, so the shop can be operated from a shell.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"io"
+	"slices"
+)
+
+// Roles that users can be promoted to.
+const (
+	AdminRole = "admin"
+	StaffRole = "staff"
+)
+
+// CreateUser signs up a new user, and makes them an admin when asAdmin is true.
+func CreateUser(ctx context.Context, store storage.Storage, user types.User, asAdmin bool) (types.User, error) {
+	if err := user.Validate(); err != nil {
+		return types.User{}, err
+	}
+	err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+		if err := tx.SignUpUser(ctx, &user); err != nil {
+			return err
+		}
+		if asAdmin {
+			return tx.PromoteUserToAdmin(ctx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return types.User{}, err
+	}
+	return store.GetUserByID(ctx, user.ID)
+}
+
+// PromoteUser gives a role to the user with an email.
+func PromoteUser(ctx context.Context, store storage.Storage, email string, role string) error {
+	user, err := store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	switch role {
+	case AdminRole:
+		return store.PromoteUserToAdmin(ctx, user.ID)
+	case StaffRole:
+		return store.PromoteUserToStaff(ctx, user.ID)
+	default:
+		return fmt.Errorf("the role must be %s or %s", AdminRole, StaffRole)
+	}
+}
+
+// ResetPassword replaces the password of the user with an email.
+func ResetPassword(ctx context.Context, store storage.Storage, email string, password string) error {
+	if len(password) < 8 {
+		return errors.New(messageErrors.PasswordIsTooShort)
+	}
+	user, err := store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	return store.UpdateUserPassword(ctx, user.ID, password)
+}
+
+// ImportResult has the ids of the flavors added and updated by an import.
+type ImportResult struct {
+	Added   []string
+	Updated []string
+}
+
+// ImportFlavors reads a JSON list of flavors, like the ones of the api, adding the new ones and replacing the existing ones,
+// including their stock. Either every flavor is imported or, when one of them is invalid, none of them.
+func ImportFlavors(ctx context.Context, store storage.Storage, reader io.Reader) (ImportResult, error) {
+	var flavors []types.Flavor
+	if err := json.NewDecoder(reader).Decode(&flavors); err != nil {
+		return ImportResult{}, errors.New(messageErrors.InvalidJsonFormat)
+	}
+	for i := range flavors {
+		if err := flavors[i].Validate(); err != nil {
+			return ImportResult{}, fmt.Errorf("flavor %d (%s): %w", i+1, flavors[i].ID, err)
+		}
+	}
+
+	result := ImportResult{Added: []string{}, Updated: []string{}}
+	err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+		for i, flavor := range flavors {
+			if _, err := tx.GetFlavorByID(ctx, flavor.ID); err != nil {
+				if err := tx.AddFlavor(ctx, flavor); err != nil {
+					return fmt.Errorf("flavor %d (%s): %w", i+1, flavor.ID, err)
+				}
+				result.Added = append(result.Added, flavor.ID)
+				continue
+			}
+			if _, err := tx.UpdateFlavor(ctx, flavor.ID, flavor); err != nil {
+				return fmt.Errorf("flavor %d (%s): %w", i+1, flavor.ID, err)
+			}
+			if _, err := tx.UpdateFlavorStock(ctx, flavor.ID, flavor.Stock); err != nil {
+				return fmt.Errorf("flavor %d (%s): %w", i+1, flavor.ID, err)
+			}
+			result.Updated = append(result.Updated, flavor.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return result, nil
+}
+
+// SetPrice adds a tub size, or replaces its price and max amount of flavors when it is already on sale.
+// Prices without currency are in the one of the store settings. It returns whether the tub size was added.
+func SetPrice(ctx context.Context, store storage.Storage, settings types.StoreSettings, price types.IceCreamTubPrice) (bool, error) {
+	if err := price.Validate(settings.DefaultCurrency()); err != nil {
+		return false, err
+	}
+	added := false
+	err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+		if _, err := tx.GetPriceByWeight(ctx, price.Weight); err != nil {
+			added = true
+			return tx.AddPrice(ctx, price)
+		}
+		_, err := tx.UpdatePrice(ctx, price.Weight, price)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return added, nil
+}
+
+// OrderFilter selects the orders to list. Empty fields select every order.
+type OrderFilter struct {
+	Statuses  []string
+	UserEmail string
+}
+
+// ListOrders obtains the orders selected by a filter, sorted by id.
+func ListOrders(ctx context.Context, store storage.Storage, filter OrderFilter) ([]types.Order, error) {
+	for _, status := range filter.Statuses {
+		if !types.IsValidOrderStatus(status) {
+			return nil, errors.New(messageErrors.InvalidOrderStatus)
+		}
+	}
+
+	var orders []types.Order
+	if filter.UserEmail != "" {
+		if _, err := store.GetUserByEmail(ctx, filter.UserEmail); err != nil {
+			return nil, err
+		}
+		orders = store.GetAllOrdersByUserEmail(ctx, filter.UserEmail)
+	} else if len(filter.Statuses) > 0 {
+		orders = store.GetOrdersByStatus(ctx, filter.Statuses...)
+	} else {
+		orders = store.GetAllOrders(ctx)
+	}
+
+	selected := []types.Order{}
+	for _, order := range orders {
+		if len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, order.Status) {
+			selected = append(selected, order)
+		}
+	}
+	slices.SortFunc(selected, func(a, b types.Order) int {
+		return int(a.ID) - int(b.ID)
+	})
+	return selected, nil
+}
@@ -19,7 +19,7 @@ func newHandler(store storage.Storage) *handler {
 
 // GetAllDeliveryDrivers handles the GET request to obtain all delivery drivers.
 func (h *handler) GetAllDeliveryDrivers(c *gin.Context) {
-	deliveryDrivers := h.Store.GetDeliveryDrivers()
+	deliveryDrivers := h.Store.GetDeliveryDrivers(c.Request.Context())
 	c.JSON(http.StatusOK, deliveryDrivers)
 }
 
@@ -37,7 +37,7 @@ func (h *handler) AddDeliveryDriver(c *gin.Context) {
 		return
 	}
 
-	err := h.Store.AddDeliveryDriver(&deliveryDriver)
+	err := h.Store.AddDeliveryDriver(c.Request.Context(), &deliveryDriver)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -54,7 +54,7 @@ func (h *handler) GetDeliveryDriverByID(c *gin.Context) {
 		return
 	}
 
-	deliveryDriver, err := h.Store.GetDeliveryDriverByID(id)
+	deliveryDriver, err := h.Store.GetDeliveryDriverByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.DeliveryDriverNotFound})
 		return
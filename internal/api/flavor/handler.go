@@ -5,9 +5,16 @@ import (
 	"icecreamshop/internal/messageErrors"
 	"icecreamshop/internal/storage"
 	"icecreamshop/internal/types"
+	"icecreamshop/internal/utils"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
+// defaultLowStockThreshold is used when no threshold is given to query low stock flavors. Measured in grams.
+const defaultLowStockThreshold uint = 2000
+
 type handler struct {
 	Store storage.Storage
 }
@@ -17,21 +24,45 @@ func newHandler(storage storage.Storage) *handler {
 }
 
 // GetFlavors handles the GET request to obtain all flavor.
+// Flavors that are not available today are hidden, unless an admin asks for them with "include=unavailable".
+// Flavors can be filtered by allergens and diets, like "excludeAllergens=nuts,gluten&diet=vegan".
 func (handler *handler) GetFlavors(c *gin.Context) {
-	kind := c.Query("type")
-	if kind != "" {
-		flavors := handler.Store.GetFlavorsByType(kind)
-		c.JSON(http.StatusOK, flavors)
-		return
+	excludedAllergens := queryList(c, "excludeAllergens")
+	for _, allergen := range excludedAllergens {
+		if !slices.Contains(types.Allergens, allergen) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidAllergen})
+			return
+		}
+	}
+	diets := queryList(c, "diet")
+	for _, diet := range diets {
+		if !slices.Contains(types.Diets, diet) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidDiet})
+			return
+		}
+	}
+
+	var flavors []types.Flavor
+	categoryID := c.Query("type")
+	if categoryID != "" {
+		flavors = handler.Store.GetFlavorsByType(c.Request.Context(), categoryID)
+	} else {
+		flavors = handler.Store.GetFlavors(c.Request.Context())
+	}
+
+	isAdmin, _ := c.Get("is-admin")
+	if c.Query("include") != "unavailable" || isAdmin != true {
+		flavors = availableFlavors(flavors, time.Now())
 	}
-	flavors := handler.Store.GetFlavors()
+	flavors = flavorsForDiets(flavors, excludedAllergens, diets)
+
 	c.JSON(http.StatusOK, flavors)
 }
 
 // GetFlavorByID handles the GET request to obtain a flavor by ID.
 func (handler *handler) GetFlavorByID(c *gin.Context) {
 	id := c.Param("id")
-	flavor, err := handler.Store.GetFlavorByID(id)
+	flavor, err := handler.Store.GetFlavorByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.FlavorNotFound})
 		return
@@ -52,7 +83,7 @@ func (handler *handler) AddFlavor(c *gin.Context) {
 		return
 	}
 
-	err := handler.Store.AddFlavor(flavor)
+	err := handler.Store.AddFlavor(c.Request.Context(), flavor)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -60,3 +91,163 @@ func (handler *handler) AddFlavor(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, flavor)
 }
+
+// UpdateFlavorStock handles the PUT request to set the stock of a flavor in grams (only admins).
+func (handler *handler) UpdateFlavorStock(c *gin.Context) {
+	id := c.Param("id")
+
+	var body struct {
+		Stock *uint `json:"stock"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Stock == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	flavor, err := handler.Store.UpdateFlavorStock(c.Request.Context(), id, *body.Stock)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, flavor)
+}
+
+// GetLowStockFlavors handles the GET request to obtain all flavors whose stock is lower than a threshold (only admins).
+// Threshold can be set with the query param "threshold", measured in grams.
+func (handler *handler) GetLowStockFlavors(c *gin.Context) {
+	threshold := defaultLowStockThreshold
+	if query := c.Query("threshold"); query != "" {
+		value, err := utils.StringToUint(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		threshold = value
+	}
+
+	flavors := handler.Store.GetLowStockFlavors(c.Request.Context(), threshold)
+	c.JSON(http.StatusOK, flavors)
+}
+
+// GetOutOfStockFlavors handles the GET request to obtain all flavors with no stock left (only admins).
+func (handler *handler) GetOutOfStockFlavors(c *gin.Context) {
+	flavors := handler.Store.GetLowStockFlavors(c.Request.Context(), 1)
+	c.JSON(http.StatusOK, flavors)
+}
+
+// UpdateFlavor handles the PUT request to replace the name, category, availability, allergens and diets of a flavor (only admins).
+func (handler *handler) UpdateFlavor(c *gin.Context) {
+	id := c.Param("id")
+
+	var flavor types.Flavor
+	if err := c.ShouldBindJSON(&flavor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	flavor.ID = id
+	if err := flavor.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedFlavor, err := handler.Store.UpdateFlavor(c.Request.Context(), id, flavor)
+	if err != nil {
+		if err.Error() == messageErrors.FlavorNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedFlavor)
+}
+
+// PatchFlavor handles the PATCH request to update some fields of a flavor (only admins).
+// Fields not included in the JSON body keep their current value.
+func (handler *handler) PatchFlavor(c *gin.Context) {
+	id := c.Param("id")
+
+	flavor, err := handler.Store.GetFlavorByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&flavor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	flavor.ID = id
+	if err := flavor.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedFlavor, err := handler.Store.UpdateFlavor(c.Request.Context(), id, flavor)
+	if err != nil {
+		if err.Error() == messageErrors.FlavorNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedFlavor)
+}
+
+// RetireFlavor handles the DELETE request to take a flavor off the menu (only admins).
+// The flavor is not deleted, so old orders can still reference it.
+func (handler *handler) RetireFlavor(c *gin.Context) {
+	id := c.Param("id")
+
+	err := handler.Store.RetireFlavor(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == messageErrors.FlavorNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// availableFlavors filters the flavors that can be sold at a given moment.
+func availableFlavors(flavors []types.Flavor, moment time.Time) []types.Flavor {
+	available := []types.Flavor{}
+	for _, flavor := range flavors {
+		if flavor.IsAvailableAt(moment) {
+			available = append(available, flavor)
+		}
+	}
+	return available
+}
+
+// flavorsForDiets filters the flavors without the excluded allergens and suitable for all the diets.
+func flavorsForDiets(flavors []types.Flavor, excludedAllergens []string, diets []string) []types.Flavor {
+	filtered := []types.Flavor{}
+	for _, flavor := range flavors {
+		if !flavor.ContainsAnyAllergen(excludedAllergens) && flavor.IsSuitableForDiets(diets) {
+			filtered = append(filtered, flavor)
+		}
+	}
+	return filtered
+}
+
+// queryList obtains a comma separated list from a query param, in lower case.
+func queryList(c *gin.Context, key string) []string {
+	var list []string
+	for _, value := range strings.Split(c.Query(key), ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -11,8 +11,14 @@ func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *mid
 
 	flavorsGroup := router.Group("/flavors")
 	{
-		flavorsGroup.GET("", handler.GetFlavors)
+		flavorsGroup.GET("", middleware.IdentifyUser, handler.GetFlavors)
+		flavorsGroup.GET("/low-stock", middleware.AuthenticateAdmin, handler.GetLowStockFlavors)
+		flavorsGroup.GET("/out-of-stock", middleware.AuthenticateAdmin, handler.GetOutOfStockFlavors)
 		flavorsGroup.GET("/:id", handler.GetFlavorByID)
 		flavorsGroup.POST("", middleware.AuthenticateAdmin, handler.AddFlavor)
+		flavorsGroup.PUT("/:id", middleware.AuthenticateAdmin, handler.UpdateFlavor)
+		flavorsGroup.PATCH("/:id", middleware.AuthenticateAdmin, handler.PatchFlavor)
+		flavorsGroup.DELETE("/:id", middleware.AuthenticateAdmin, handler.RetireFlavor)
+		flavorsGroup.PUT("/:id/stock", middleware.AuthenticateAdmin, handler.UpdateFlavorStock)
 	}
 }
@@ -0,0 +1,98 @@
+package flavorCategory
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"net/http"
+)
+
+type handler struct {
+	Store storage.Storage
+}
+
+func newHandler(storage storage.Storage) *handler {
+	return &handler{storage}
+}
+
+// GetFlavorCategories handles the GET request to obtain all flavor categories in menu order.
+func (handler *handler) GetFlavorCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, handler.Store.GetFlavorCategories(c.Request.Context()))
+}
+
+// GetFlavorCategoryByID handles the GET request to obtain a flavor category by ID.
+func (handler *handler) GetFlavorCategoryByID(c *gin.Context) {
+	id := c.Param("id")
+	category, err := handler.Store.GetFlavorCategoryByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+// AddFlavorCategory handles the POST request to add a flavor category (only admins).
+func (handler *handler) AddFlavorCategory(c *gin.Context) {
+	var category types.FlavorCategory
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	if err := category.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.Store.AddFlavorCategory(c.Request.Context(), category)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateFlavorCategory handles the PUT request to rename or reorder a flavor category (only admins).
+func (handler *handler) UpdateFlavorCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	var category types.FlavorCategory
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	category.ID = id
+	if err := category.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedCategory, err := handler.Store.UpdateFlavorCategory(c.Request.Context(), id, category)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedCategory)
+}
+
+// DeleteFlavorCategory handles the DELETE request to delete a flavor category (only admins).
+// Categories that still have flavors cannot be deleted.
+func (handler *handler) DeleteFlavorCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	err := handler.Store.DeleteFlavorCategory(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == messageErrors.FlavorCategoryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,20 @@
+package flavorCategory
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/middleware"
+	"icecreamshop/internal/storage"
+)
+
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
+	handler := newHandler(storage)
+
+	categoriesGroup := router.Group("/flavor-categories")
+	{
+		categoriesGroup.GET("", handler.GetFlavorCategories)
+		categoriesGroup.GET("/:id", handler.GetFlavorCategoryByID)
+		categoriesGroup.POST("", middleware.AuthenticateAdmin, handler.AddFlavorCategory)
+		categoriesGroup.PUT("/:id", middleware.AuthenticateAdmin, handler.UpdateFlavorCategory)
+		categoriesGroup.DELETE("/:id", middleware.AuthenticateAdmin, handler.DeleteFlavorCategory)
+	}
+}
@@ -0,0 +1,64 @@
+package kitchen
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"icecreamshop/internal/utils"
+	"net/http"
+	"slices"
+)
+
+type handler struct {
+	Store storage.Storage
+}
+
+func newHandler(store storage.Storage) *handler {
+	return &handler{store}
+}
+
+// GetPendingOrders handles the GET request to obtain the orders the kitchen has to prepare or hand over (only staff).
+func (h *handler) GetPendingOrders(c *gin.Context) {
+	orders := h.Store.GetOrdersByStatus(c.Request.Context(), types.OrderPlaced, types.OrderPreparing, types.OrderReady)
+	c.JSON(http.StatusOK, orders)
+}
+
+// UpdateOrderStatus handles the PUT request to start preparing an order or mark it as ready (only staff).
+func (h *handler) UpdateOrderStatus(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := struct {
+		Status string `json:"status"`
+	}{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	if !slices.Contains(types.StaffOrderStatuses, request.Status) {
+		c.JSON(http.StatusForbidden, gin.H{"error": messageErrors.OrderStatusNotAllowedForRole})
+		return
+	}
+
+	order, err := h.Store.UpdateOrderStatus(c.Request.Context(), orderID, request.Status, userID.(uint))
+	if err != nil {
+		switch err.Error() {
+		case messageErrors.OrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case messageErrors.IllegalOrderStatusTransition:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,17 @@
+package kitchen
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/middleware"
+	"icecreamshop/internal/storage"
+)
+
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
+	handler := newHandler(storage)
+
+	kitchenGroup := router.Group("/kitchen/orders", middleware.AuthenticateStaff)
+	{
+		kitchenGroup.GET("", handler.GetPendingOrders)
+		kitchenGroup.PUT("/:id/status", handler.UpdateOrderStatus)
+	}
+}
@@ -10,11 +10,12 @@ import (
 )
 
 type handler struct {
-	Store storage.Storage
+	Store  storage.Storage
+	Tokens auth.Tokens
 }
 
-func newHandler(store storage.Storage) *handler {
-	return &handler{store}
+func newHandler(store storage.Storage, tokens auth.Tokens) *handler {
+	return &handler{store, tokens}
 }
 
 // SignUpUser handles the POST request to sign up a new user.
@@ -36,7 +37,7 @@ func (h *handler) SignUpUser(c *gin.Context) {
 		return
 	}
 
-	err := h.Store.SignUpUser(&user)
+	err := h.Store.SignUpUser(c.Request.Context(), &user)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -56,13 +57,13 @@ func (h *handler) LogInUser(c *gin.Context) {
 		return
 	}
 
-	err := h.Store.LogInUser(body.Email, body.Password)
+	err := h.Store.LogInUser(c.Request.Context(), body.Email, body.Password)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidEmailOrPassword})
 		return
 	}
 
-	tokenString := auth.GenerateTokenFromUserEmail(body.Email)
+	tokenString := h.Tokens.GenerateTokenFromUserEmail(body.Email)
 	c.SetSameSite(http.SameSiteLaxMode)
 	c.SetCookie("Authorization", tokenString, 60*60*24, "", "", false, true)
 
@@ -73,14 +74,14 @@ func (h *handler) LogInUser(c *gin.Context) {
 // It also checks if the user is a delivery driver to return extra information if needed.
 func (h *handler) GetMyAccount(c *gin.Context) {
 	userEmail, _ := c.Get("user-email")
-	user, err := h.Store.GetUserByEmail(userEmail.(string))
+	user, err := h.Store.GetUserByEmail(c.Request.Context(), userEmail.(string))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 	for _, permission := range user.Permissions {
 		if permission == "repartidor" {
-			deliveryDriver, _ := h.Store.GetDeliveryDriverByID(user.ID)
+			deliveryDriver, _ := h.Store.GetDeliveryDriverByID(c.Request.Context(), user.ID)
 			response := struct {
 				types.User
 				DeliveryDriver types.DeliveryDriver `json:"deliveryDriver,omitempty"` // will be included only if not nil
@@ -99,7 +100,7 @@ func (h *handler) GetMyAccount(c *gin.Context) {
 // Automatically logs out the user.
 func (h *handler) DeleteMyAccount(c *gin.Context) {
 	userID, _ := c.Get("user-id")
-	h.Store.DeleteUserByID(userID.(uint))
+	h.Store.DeleteUserByID(c.Request.Context(), userID.(uint))
 	c.SetCookie("Authorization", "", 0, "", "", false, true)
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -119,7 +120,7 @@ func (h *handler) UpdateMyAccount(c *gin.Context) {
 		return
 	}
 
-	user, err := h.Store.UpdateUser(userUpdated)
+	user, err := h.Store.UpdateUser(c.Request.Context(), userUpdated)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -143,7 +144,7 @@ func (h *handler) UpdateDeliveryDriverData(c *gin.Context) {
 		return
 	}
 
-	err := h.Store.UpdateDeliveryDriverByID(userID.(uint), &updatedDeliveryDriver)
+	err := h.Store.UpdateDeliveryDriverByID(c.Request.Context(), userID.(uint), &updatedDeliveryDriver)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -156,7 +157,7 @@ func (h *handler) UpdateDeliveryDriverData(c *gin.Context) {
 func (h *handler) DeleteDeliveryDriver(c *gin.Context) {
 	userID, _ := c.Get("user-id")
 
-	err := h.Store.DeleteDeliveryDriverByID(userID.(uint))
+	err := h.Store.DeleteDeliveryDriverByID(c.Request.Context(), userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
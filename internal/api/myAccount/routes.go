@@ -2,12 +2,13 @@ package myAccount
 
 import (
 	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/auth"
 	"icecreamshop/internal/middleware"
 	"icecreamshop/internal/storage"
 )
 
-func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
-	handler := newHandler(storage)
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware, tokens auth.Tokens) {
+	handler := newHandler(storage, tokens)
 
 	router.POST("/signup", handler.SignUpUser)
 	router.POST("/login", middleware.CheckIfNotLoggedIn, handler.LogInUser)
@@ -0,0 +1,76 @@
+package myDeliveries
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"icecreamshop/internal/utils"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type handler struct {
+	Store storage.Storage
+}
+
+func newHandler(store storage.Storage) *handler {
+	return &handler{store}
+}
+
+// GetAssignedOrders handles the GET request to obtain the orders assigned to the current delivery driver.
+func (h *handler) GetAssignedOrders(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+	orders := h.Store.GetOrdersByDeliveryDriverID(c.Request.Context(), userID.(uint))
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetAssignableOrders handles the GET request to obtain the orders without delivery driver that can be delivered now.
+// Scheduled orders are only listed shortly before their delivery window.
+func (h *handler) GetAssignableOrders(c *gin.Context) {
+	orders := h.Store.GetAssignableOrders(c.Request.Context(), time.Now())
+	c.JSON(http.StatusOK, orders)
+}
+
+// UpdateOrderStatus handles the PUT request to pick up or deliver an order. The order must be assigned to the current delivery driver.
+func (h *handler) UpdateOrderStatus(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.Store.GetOrderByID(c.Request.Context(), orderID)
+	if err != nil || order.DeliveryDriverID != userID.(uint) {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	request := struct {
+		Status string `json:"status"`
+	}{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	if !slices.Contains(types.DeliveryOrderStatuses, request.Status) {
+		c.JSON(http.StatusForbidden, gin.H{"error": messageErrors.OrderStatusNotAllowedForRole})
+		return
+	}
+
+	order, err = h.Store.UpdateOrderStatus(c.Request.Context(), orderID, request.Status, userID.(uint))
+	if err != nil {
+		if err.Error() == messageErrors.IllegalOrderStatusTransition {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,18 @@
+package myDeliveries
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/middleware"
+	"icecreamshop/internal/storage"
+)
+
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
+	handler := newHandler(storage)
+
+	myDeliveriesGroup := router.Group("/my-deliveries", middleware.AuthenticateDeliveryDriver)
+	{
+		myDeliveriesGroup.GET("", handler.GetAssignedOrders)
+		myDeliveriesGroup.GET("/assignable", handler.GetAssignableOrders)
+		myDeliveriesGroup.PUT("/:id/status", handler.UpdateOrderStatus)
+	}
+}
@@ -1,31 +1,41 @@
 package myOrders
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/messageErrors"
 	"icecreamshop/internal/services/payment"
 	"icecreamshop/internal/storage"
 	"icecreamshop/internal/types"
 	"icecreamshop/internal/utils"
+	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
 type handler struct {
-	Store storage.Storage
+	Store    storage.Storage
+	Payments config.Payment
+	Settings types.StoreSettings
 }
 
-func newHandler(store storage.Storage) *handler {
-	return &handler{store}
+func newHandler(store storage.Storage, payments config.Payment, settings types.StoreSettings) *handler {
+	return &handler{store, payments, settings}
 }
 
 // GetAllMyOrders handles the GET request to obtain all order from the user who is logged in.
 func (h *handler) GetAllMyOrders(c *gin.Context) {
 	userEmail, _ := c.Get("user-email")
-	orders := h.Store.GetAllOrdersByUserEmail(userEmail.(string))
+	orders := h.Store.GetAllOrdersByUserEmail(c.Request.Context(), userEmail.(string))
 	c.JSON(http.StatusOK, orders)
 }
 
-// CreateOrder handles the POST request to create a new order for the user who is logged in.
+// CreateOrder handles the POST request to create a new order for the user who is logged in, optionally with its tubs.
+// The order is created with all its tubs or not at all. Rejected tubs are listed with their position in the order.
 func (h *handler) CreateOrder(c *gin.Context) {
 	userID, _ := c.Get("user-id")
 
@@ -36,12 +46,17 @@ func (h *handler) CreateOrder(c *gin.Context) {
 	}
 	order.UserID = userID.(uint)
 
-	if err := order.Validate(); err != nil {
+	if err := order.Validate(h.Settings); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err := h.Store.CreateOrder(&order)
+	err := h.Store.CreateOrder(c.Request.Context(), &order)
+	var tubsErr *types.InvalidTubsError
+	if errors.As(err, &tubsErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "tubErrors": tubsErr.Tubs})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -58,7 +73,7 @@ func (h *handler) GetMyOrderByID(c *gin.Context) {
 		return
 	}
 	userID, _ := c.Get("user-id")
-	order, err := h.Store.GetUserOrderByID(id, userID.(uint))
+	order, err := h.Store.GetUserOrderByID(c.Request.Context(), id, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -66,6 +81,30 @@ func (h *handler) GetMyOrderByID(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// GetMyOrderHistory handles the GET request to obtain the history of an order from the user who is logged in, oldest first.
+// Internal events are left out, and other users who changed the order are not identified.
+func (h *handler) GetMyOrderHistory(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), id, userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	events, err := h.Store.GetOrderHistory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, types.VisibleToOwner(events, userID.(uint)))
+}
+
 // UpdateMyOrderByID handles the PUT request to update an order by id from the user who is logged in.
 func (h *handler) UpdateMyOrderByID(c *gin.Context) {
 	userID, _ := c.Get("user-id")
@@ -82,15 +121,19 @@ func (h *handler) UpdateMyOrderByID(c *gin.Context) {
 		return
 	}
 
-	if err := updatedOrder.Validate(); err != nil {
+	if err := updatedOrder.Validate(h.Settings); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	updatedOrder.ID = id
 	updatedOrder.UserID = userID.(uint)
-	order, err := h.Store.UpdateOrderByID(id, &updatedOrder)
+	order, err := h.Store.UpdateOrderByID(c.Request.Context(), id, &updatedOrder)
 	if err != nil {
+		if err.Error() == messageErrors.OrderDeliveryCannotBeChanged {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -107,19 +150,39 @@ func (h *handler) GetIceCreamTubsFromOrderByID(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user-id")
-	if _, err := h.Store.GetUserOrderByID(id, userID.(uint)); err != nil {
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), id, userID.(uint)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	tubs, err := h.Store.GetIceCreamTubsByOrderID(id)
+	tubs, err := h.Store.GetIceCreamTubsByOrderID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range tubs {
+		tubs[i].Allergens = h.combinedAllergens(c.Request.Context(), tubs[i].Flavors)
+	}
 	c.JSON(http.StatusOK, tubs)
 }
 
+// combinedAllergens obtains all allergens contained in the flavors, without repetitions.
+func (h *handler) combinedAllergens(ctx context.Context, flavorIDs []string) []string {
+	var allergens []string
+	for _, flavorID := range flavorIDs {
+		flavor, err := h.Store.GetFlavorByID(ctx, flavorID)
+		if err != nil {
+			continue
+		}
+		for _, allergen := range flavor.Allergens {
+			if !slices.Contains(allergens, allergen) {
+				allergens = append(allergens, allergen)
+			}
+		}
+	}
+	return allergens
+}
+
 // AddIceCreamTubToOrderByID handles the POST request to add a new ice cream tub to an order by its id. User must be the order's owner.
 func (h *handler) AddIceCreamTubToOrderByID(c *gin.Context) {
 	orderID, err := utils.StringToUint(c.Param("id"))
@@ -139,12 +202,12 @@ func (h *handler) AddIceCreamTubToOrderByID(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user-id")
-	if _, err := h.Store.GetUserOrderByID(orderID, userID.(uint)); err != nil {
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
 		return
 	}
 
-	err = h.Store.AddIceCreamTubByOrderID(orderID, &tub)
+	err = h.Store.AddIceCreamTubByOrderID(c.Request.Context(), orderID, &tub)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -168,14 +231,19 @@ func (h *handler) DeleteIceCreamTubByIDFromOrder(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user-id")
-	if _, err := h.Store.GetUserOrderByID(orderID, userID.(uint)); err != nil {
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
 		return
 	}
 
-	err = h.Store.DeleteIceCreamTubByOrderID(tubID, orderID)
+	err = h.Store.DeleteIceCreamTubByOrderID(c.Request.Context(), tubID, orderID)
 	if err != nil {
+		if err.Error() == messageErrors.OrderIsNotADraft || err.Error() == messageErrors.OrderIsAlreadyPaid || err.Error() == messageErrors.OrderIsBeingPaid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
@@ -190,12 +258,12 @@ func (h *handler) GetDeliveryDriverFromOrder(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user-id")
-	if _, err := h.Store.GetUserOrderByID(orderID, userID.(uint)); err != nil {
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
 		return
 	}
 
-	deliveryDriverID, err := h.Store.GetDeliveryDriverFromOrder(orderID)
+	deliveryDriverID, err := h.Store.GetDeliveryDriverFromOrder(c.Request.Context(), orderID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -204,6 +272,189 @@ func (h *handler) GetDeliveryDriverFromOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, deliveryDriverID)
 }
 
+// PlaceOrder handles the POST request to place a draft order by its id, so the shop starts preparing it. User must be order's owner.
+func (h *handler) PlaceOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	order, err := h.Store.UpdateOrderStatus(c.Request.Context(), orderID, types.OrderPlaced, userID.(uint))
+	if err != nil {
+		if err.Error() == messageErrors.IllegalOrderStatusTransition {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelMyOrder handles the POST request to cancel an order by its id before its preparation starts. User must be order's owner.
+// Paid orders are refunded once they are cancelled.
+func (h *handler) CancelMyOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	// The reason is optional for customers, so the body can be empty.
+	request := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	// The order is cancelled before refunding it, so concurrent cancellations cannot refund it twice:
+	// only the one that cancels it gets its previous payment state as paid.
+	cancellation := types.OrderCancellation{CancelledBy: userID.(uint), Reason: request.Reason}
+	cancelledOrder, previousPaymentState, err := h.Store.CancelOrder(c.Request.Context(), orderID, cancellation)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if previousPaymentState == types.PaymentPaid {
+		cancelledOrder, err = payment.RefundOrder(c.Request.Context(), h.Store, orderID, userID.(uint), h.Payments)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, cancelledOrder)
+}
+
+// ReorderMyOrder handles the POST request to create a new draft order with the address and tubs of a previous order. User must be order's owner.
+// Flavors are validated again and tubs are charged at current prices. Retired or unavailable flavors are left out of their tubs,
+// and tubs that cannot be added are skipped, so the response lists warnings instead of failing.
+func (h *handler) ReorderMyOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previousOrder, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	// The new order and its tubs are saved as one unit of work, so the order is never seen half copied.
+	order := types.Order{Address: previousOrder.Address, UserID: userID.(uint)}
+	warnings := []types.ReorderWarning{}
+	err = h.Store.WithinTransaction(c.Request.Context(), func(store storage.Storage) error {
+		if err := store.CreateOrder(c.Request.Context(), &order); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, previousTub := range previousOrder.IceCreamTubs {
+			flavors, flavorWarnings := orderableFlavors(c.Request.Context(), store, previousTub, now)
+			warnings = append(warnings, flavorWarnings...)
+			if len(flavors) == 0 {
+				warnings = append(warnings, types.ReorderWarning{TubID: previousTub.ID, Message: messageErrors.NoFlavorsLeftInTub})
+				continue
+			}
+			tub := types.IceCreamTub{Weight: previousTub.Weight, Flavors: flavors}
+			if err := store.AddIceCreamTubByOrderID(c.Request.Context(), order.ID, &tub); err != nil {
+				warnings = append(warnings, types.ReorderWarning{TubID: previousTub.ID, Message: err.Error()})
+			}
+		}
+		var err error
+		order, err = store.GetOrderByID(c.Request.Context(), order.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, types.Reorder{Order: order, Warnings: warnings})
+}
+
+// orderableFlavors obtains the flavors of a tub that can still be ordered at a given moment, with a warning for each one left out.
+func orderableFlavors(ctx context.Context, store storage.Storage, tub types.IceCreamTub, moment time.Time) ([]string, []types.ReorderWarning) {
+	flavors := []string{}
+	warnings := []types.ReorderWarning{}
+	for _, flavorID := range tub.Flavors {
+		flavor, err := store.GetFlavorByID(ctx, flavorID)
+		switch {
+		case err != nil || flavor.Retired:
+			warnings = append(warnings, types.ReorderWarning{TubID: tub.ID, FlavorID: flavorID, Message: messageErrors.RetiredFlavor})
+		case !flavor.IsAvailableAt(moment):
+			warnings = append(warnings, types.ReorderWarning{TubID: tub.ID, FlavorID: flavorID, Message: messageErrors.UnavailableFlavor})
+		default:
+			flavors = append(flavors, flavorID)
+		}
+	}
+	return flavors, warnings
+}
+
+// ApplyPromoCode handles the POST request to apply a promo code to an order by its id. User must be order's owner.
+func (h *handler) ApplyPromoCode(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	orderID, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
+		return
+	}
+
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(request.Code))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.PromoCodeIsRequired})
+		return
+	}
+
+	order, err := h.Store.ApplyPromoCodeToOrder(c.Request.Context(), orderID, code, time.Now())
+	if err != nil {
+		if err.Error() == messageErrors.PromoCodeNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
 // ProcessOrderPayment handles the POST request to process the order payment by its id. User must be order's owner.
 func (h *handler) ProcessOrderPayment(c *gin.Context) {
 	userID, _ := c.Get("user-id")
@@ -214,7 +465,7 @@ func (h *handler) ProcessOrderPayment(c *gin.Context) {
 		return
 	}
 
-	order, err := h.Store.GetUserOrderByID(orderID, userID.(uint))
+	order, err := h.Store.GetUserOrderByID(c.Request.Context(), orderID, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": messageErrors.OrderNotFound})
 		return
@@ -226,16 +477,27 @@ func (h *handler) ProcessOrderPayment(c *gin.Context) {
 		return
 	}
 
-	paymentResponse, err := payment.ProcessPayment(paymentData, order.TotalCost)
+	// The payment starts before charging the customer, so the order cannot change, nor be charged twice, while it is being paid.
+	order, err = h.Store.StartOrderPayment(c.Request.Context(), orderID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	order.PaymentState = "paid"
-	_, err = h.Store.UpdateOrderByID(orderID, &order)
+	paymentResponse, err := payment.ProcessPayment(paymentData, order.TotalCost, h.Payments)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = h.Store.AbortOrderPayment(c.Request.Context(), orderID)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paymentReference := payment.ReferenceOf(paymentResponse)
+	err = h.Store.MarkOrderAsPaid(c.Request.Context(), orderID, paymentReference, order.TotalCost)
+	if err != nil {
+		// The order was cancelled while it was being paid, so the charge is given back instead of recorded.
+		_, _ = payment.RefundPayment(paymentReference, order.TotalCost, h.Payments)
+		_ = h.Store.AbortOrderPayment(c.Request.Context(), orderID)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -2,23 +2,30 @@ package myOrders
 
 import (
 	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/middleware"
 	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
 )
 
-func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
-	handler := newHandler(storage)
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware, payments config.Payment, settings types.StoreSettings) {
+	handler := newHandler(storage, payments, settings)
 
 	myOrdersGroup := router.Group("/my-orders", middleware.AuthenticateUser)
 	{
 		myOrdersGroup.GET("", handler.GetAllMyOrders)
 		myOrdersGroup.POST("", handler.CreateOrder)
 		myOrdersGroup.GET("/:id", handler.GetMyOrderByID)
+		myOrdersGroup.GET("/:id/history", handler.GetMyOrderHistory)
 		myOrdersGroup.PUT("/:id", handler.UpdateMyOrderByID)
 		myOrdersGroup.GET("/:id/tubs", handler.GetIceCreamTubsFromOrderByID)
 		myOrdersGroup.POST("/:id/tubs", handler.AddIceCreamTubToOrderByID)
 		myOrdersGroup.DELETE("/:orderID/tubs/:tubID", handler.DeleteIceCreamTubByIDFromOrder)
 		myOrdersGroup.GET("/:id/delivery-driver", handler.GetDeliveryDriverFromOrder)
+		myOrdersGroup.POST("/:id/place", handler.PlaceOrder)
+		myOrdersGroup.POST("/:id/cancel", handler.CancelMyOrder)
+		myOrdersGroup.POST("/:id/reorder", handler.ReorderMyOrder)
+		myOrdersGroup.POST("/:id/promo", handler.ApplyPromoCode)
 		myOrdersGroup.POST("/:id/pay", handler.ProcessOrderPayment)
 	}
 }
@@ -2,23 +2,37 @@ package order
 
 import (
 	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/services/payment"
 	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
 	"icecreamshop/internal/utils"
 	"net/http"
+	"time"
 )
 
 type handler struct {
-	Store storage.Storage
+	Store    storage.Storage
+	Payments config.Payment
 }
 
-func newHandler(store storage.Storage) *handler {
-	return &handler{store}
+func newHandler(store storage.Storage, payments config.Payment) *handler {
+	return &handler{store, payments}
 }
 
 // GetAllOrders handles the GET request to obtain all order from all users (only admins).
+// With the scheduledFor query param, like 2024-05-10, it only obtains the orders to deliver on that date, to plan production.
 func (h *handler) GetAllOrders(c *gin.Context) {
-	orders := h.Store.GetAllOrders()
+	if date, ok := c.GetQuery("scheduledFor"); ok {
+		if _, err := time.Parse(types.DateLayout, date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidScheduledDate})
+			return
+		}
+		c.JSON(http.StatusOK, h.Store.GetOrdersScheduledFor(c.Request.Context(), date))
+		return
+	}
+	orders := h.Store.GetAllOrders(c.Request.Context())
 	c.JSON(http.StatusOK, orders)
 }
 
@@ -30,7 +44,7 @@ func (h *handler) GetOrderByID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.Store.GetOrderByID(id)
+	order, err := h.Store.GetOrderByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -38,8 +52,132 @@ func (h *handler) GetOrderByID(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// GetOrderHistory handles the GET request to obtain every event of an order by its ID, oldest first (only admins).
+func (h *handler) GetOrderHistory(c *gin.Context) {
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.Store.GetOrderHistory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// UpdateOrderStatus handles the PUT request to move any order to a new fulfillment status (only admins).
+func (h *handler) UpdateOrderStatus(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := struct {
+		Status string `json:"status"`
+	}{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	order, err := h.Store.UpdateOrderStatus(c.Request.Context(), id, request.Status, userID.(uint))
+	if err != nil {
+		switch err.Error() {
+		case messageErrors.OrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case messageErrors.IllegalOrderStatusTransition:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder handles the POST request to cancel any order by its ID at any time, with a reason (only admins).
+// Paid orders are refunded once they are cancelled.
+func (h *handler) CancelOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	// The order is cancelled before refunding it, so concurrent cancellations cannot refund it twice:
+	// only the one that cancels it gets its previous payment state as paid.
+	cancellation := types.OrderCancellation{CancelledBy: userID.(uint), ByAdmin: true, Reason: request.Reason}
+	cancelledOrder, previousPaymentState, err := h.Store.CancelOrder(c.Request.Context(), id, cancellation)
+	if err != nil {
+		switch err.Error() {
+		case messageErrors.OrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case messageErrors.CancellationReasonIsRequired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if previousPaymentState == types.PaymentPaid {
+		cancelledOrder, err = payment.RefundOrder(c.Request.Context(), h.Store, id, userID.(uint), h.Payments)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, cancelledOrder)
+}
+
+// RefundOrder handles the POST request to retry the refund of a cancelled order whose refund could not be issued when it was cancelled (only admins).
+func (h *handler) RefundOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := payment.RefundOrder(c.Request.Context(), h.Store, id, userID.(uint), h.Payments)
+	if err != nil {
+		switch err.Error() {
+		case messageErrors.OrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case messageErrors.OrderHasNoPendingRefund:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
 // AssignDeliveryDriverToOrder handles the PUT request to assign a delivery driver to an order by its ID (only admins).
 func (h *handler) AssignDeliveryDriverToOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
 	orderID, err := utils.StringToUint(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -54,8 +192,12 @@ func (h *handler) AssignDeliveryDriverToOrder(c *gin.Context) {
 		return
 	}
 
-	err = h.Store.AssignDeliveryDriverToOrder(orderID, deliveryDriverID.ID)
+	err = h.Store.AssignDeliveryDriverToOrder(c.Request.Context(), orderID, deliveryDriverID.ID, userID.(uint))
 	if err != nil {
+		if err.Error() == messageErrors.OrderCannotGetADriver {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -65,13 +207,15 @@ func (h *handler) AssignDeliveryDriverToOrder(c *gin.Context) {
 
 // DeleteDeliveryDriverFromOrder handles the DELETE request to delete a delivery driver from an order by its ID (only admins).
 func (h *handler) DeleteDeliveryDriverFromOrder(c *gin.Context) {
+	userID, _ := c.Get("user-id")
+
 	id, err := utils.StringToUint(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = h.Store.DeleteDeliveryDriverFromOrder(id)
+	err = h.Store.DeleteDeliveryDriverFromOrder(c.Request.Context(), id, userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
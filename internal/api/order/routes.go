@@ -2,17 +2,22 @@ package order
 
 import (
 	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/middleware"
 	"icecreamshop/internal/storage"
 )
 
-func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware) {
-	orders := newHandler(storage)
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware, payments config.Payment) {
+	orders := newHandler(storage, payments)
 
 	ordersGroup := router.Group("/orders", middleware.AuthenticateAdmin)
 	{
 		ordersGroup.GET("", orders.GetAllOrders)
 		ordersGroup.GET("/:id", orders.GetOrderByID)
+		ordersGroup.GET("/:id/history", orders.GetOrderHistory)
+		ordersGroup.PUT("/:id/status", orders.UpdateOrderStatus)
+		ordersGroup.POST("/:id/cancel", orders.CancelOrder)
+		ordersGroup.POST("/:id/refund", orders.RefundOrder)
 		ordersGroup.PUT("/:id/delivery-driver", orders.AssignDeliveryDriverToOrder)
 		ordersGroup.DELETE("/:id/delivery-driver", orders.DeleteDeliveryDriverFromOrder)
 	}
@@ -0,0 +1,108 @@
+package price
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"icecreamshop/internal/utils"
+	"net/http"
+)
+
+type handler struct {
+	Store    storage.Storage
+	Settings types.StoreSettings
+}
+
+func newHandler(storage storage.Storage, settings types.StoreSettings) *handler {
+	return &handler{storage, settings}
+}
+
+// GetPrices handles the GET request to obtain all tub sizes on sale with their prices.
+func (handler *handler) GetPrices(c *gin.Context) {
+	c.JSON(http.StatusOK, handler.Store.GetPrices(c.Request.Context()))
+}
+
+// GetPriceByWeight handles the GET request to obtain the price of a tub size by its weight.
+func (handler *handler) GetPriceByWeight(c *gin.Context) {
+	weight, err := utils.StringToUint(c.Param("weight"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := handler.Store.GetPriceByWeight(c.Request.Context(), weight)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, price)
+}
+
+// AddPrice handles the POST request to add a new tub size (only admins).
+func (handler *handler) AddPrice(c *gin.Context) {
+	var price types.IceCreamTubPrice
+	if err := c.ShouldBindJSON(&price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	if err := price.Validate(handler.Settings.DefaultCurrency()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.Store.AddPrice(c.Request.Context(), price)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// UpdatePrice handles the PUT request to change the price and max amount of flavors of a tub size (only admins).
+func (handler *handler) UpdatePrice(c *gin.Context) {
+	weight, err := utils.StringToUint(c.Param("weight"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var price types.IceCreamTubPrice
+	if err := c.ShouldBindJSON(&price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	price.Weight = weight
+	if err := price.Validate(handler.Settings.DefaultCurrency()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedPrice, err := handler.Store.UpdatePrice(c.Request.Context(), weight, price)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedPrice)
+}
+
+// DeletePrice handles the DELETE request to stop selling a tub size (only admins).
+func (handler *handler) DeletePrice(c *gin.Context) {
+	weight, err := utils.StringToUint(c.Param("weight"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = handler.Store.DeletePrice(c.Request.Context(), weight)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
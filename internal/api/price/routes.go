@@ -0,0 +1,21 @@
+package price
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/middleware"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+)
+
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware, settings types.StoreSettings) {
+	handler := newHandler(storage, settings)
+
+	pricesGroup := router.Group("/prices")
+	{
+		pricesGroup.GET("", handler.GetPrices)
+		pricesGroup.GET("/:weight", handler.GetPriceByWeight)
+		pricesGroup.POST("", middleware.AuthenticateAdmin, handler.AddPrice)
+		pricesGroup.PUT("/:weight", middleware.AuthenticateAdmin, handler.UpdatePrice)
+		pricesGroup.DELETE("/:weight", middleware.AuthenticateAdmin, handler.DeletePrice)
+	}
+}
@@ -0,0 +1,100 @@
+package promoCode
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"net/http"
+	"strings"
+)
+
+type handler struct {
+	Store    storage.Storage
+	Settings types.StoreSettings
+}
+
+func newHandler(storage storage.Storage, settings types.StoreSettings) *handler {
+	return &handler{storage, settings}
+}
+
+// GetPromoCodes handles the GET request to obtain all promo codes (only admins).
+func (handler *handler) GetPromoCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, handler.Store.GetPromoCodes(c.Request.Context()))
+}
+
+// GetPromoCodeByCode handles the GET request to obtain a promo code by its code (only admins).
+func (handler *handler) GetPromoCodeByCode(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+	promo, err := handler.Store.GetPromoCodeByCode(c.Request.Context(), code)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, promo)
+}
+
+// AddPromoCode handles the POST request to add a new promo code (only admins).
+func (handler *handler) AddPromoCode(c *gin.Context) {
+	var promo types.PromoCode
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	if err := promo.Validate(handler.Settings.DefaultCurrency()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := handler.Store.AddPromoCode(c.Request.Context(), promo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// UpdatePromoCode handles the PUT request to replace the conditions of a promo code (only admins).
+func (handler *handler) UpdatePromoCode(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	var promo types.PromoCode
+	if err := c.ShouldBindJSON(&promo); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": messageErrors.InvalidJsonFormat})
+		return
+	}
+
+	promo.Code = code
+	if err := promo.Validate(handler.Settings.DefaultCurrency()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedPromo, err := handler.Store.UpdatePromoCode(c.Request.Context(), code, promo)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedPromo)
+}
+
+// DeletePromoCode handles the DELETE request to delete a promo code (only admins).
+// Codes already applied to orders cannot be deleted, but they can be expired by updating their dates.
+func (handler *handler) DeletePromoCode(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+
+	err := handler.Store.DeletePromoCode(c.Request.Context(), code)
+	if err != nil {
+		if err.Error() == messageErrors.PromoCodeNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,21 @@
+package promoCode
+
+import (
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/middleware"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+)
+
+func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *middleware.Middleware, settings types.StoreSettings) {
+	handler := newHandler(storage, settings)
+
+	promoCodesGroup := router.Group("/promo-codes", middleware.AuthenticateAdmin)
+	{
+		promoCodesGroup.GET("", handler.GetPromoCodes)
+		promoCodesGroup.GET("/:code", handler.GetPromoCodeByCode)
+		promoCodesGroup.POST("", handler.AddPromoCode)
+		promoCodesGroup.PUT("/:code", handler.UpdatePromoCode)
+		promoCodesGroup.DELETE("/:code", handler.DeletePromoCode)
+	}
+}
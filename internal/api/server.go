@@ -1,46 +1,115 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"icecreamshop/internal/api/deliveryDriver"
 	"icecreamshop/internal/api/flavor"
+	"icecreamshop/internal/api/flavorCategory"
+	"icecreamshop/internal/api/kitchen"
 	"icecreamshop/internal/api/myAccount"
+	"icecreamshop/internal/api/myDeliveries"
 	"icecreamshop/internal/api/myOrders"
 	"icecreamshop/internal/api/order"
+	"icecreamshop/internal/api/price"
+	"icecreamshop/internal/api/promoCode"
 	"icecreamshop/internal/api/user"
+	"icecreamshop/internal/auth"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/middleware"
 	"icecreamshop/internal/storage"
-	"os"
+	"net"
+	"net/http"
+	"time"
 )
 
 type Server struct {
-	Store storage.Storage
+	Store  storage.Storage
+	Config config.Config
 }
 
-func NewServer(store storage.Storage) *Server {
-	return &Server{store}
+func NewServer(store storage.Storage, settings config.Config) *Server {
+	return &Server{store, settings}
 }
 
-func (server *Server) Start() error {
-	router := server.SetupRouter()
-	return router.Run("localhost:8080")
+// Start listens on the address of the server config and serves the api until ctx is done, see Serve.
+func (server *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", server.Config.Server.Address())
+	if err != nil {
+		return err
+	}
+	return server.Serve(ctx, listener)
+}
+
+// Serve serves the api on a listener until it fails or ctx is done, like when the process is interrupted.
+// Then it stops accepting connections, waits for the requests in progress to be answered, up to the shutdown timeout,
+// and closes the storage.
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	settings := server.Config.Server
+	httpServer := &http.Server{
+		Handler:      server.SetupRouter(),
+		ReadTimeout:  settings.ReadTimeout,
+		WriteTimeout: settings.WriteTimeout,
+		IdleTimeout:  settings.IdleTimeout,
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		if settings.TLS() {
+			served <- httpServer.ServeTLS(listener, settings.TLSCertFile, settings.TLSKeyFile)
+		} else {
+			served <- httpServer.Serve(listener)
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-served:
+	case <-ctx.Done():
+		err = shutdown(httpServer, settings.ShutdownTimeout)
+		<-served
+	}
+	return errors.Join(err, server.Store.Close())
+}
+
+// shutdown stops a server gracefully, closing the connections that are still open once the timeout passes.
+func shutdown(httpServer *http.Server, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := httpServer.Shutdown(ctx)
+	if err != nil {
+		_ = httpServer.Close()
+	}
+	return err
 }
 
 func (server *Server) SetupRouter() *gin.Engine {
-	middle := middleware.NewMiddleware(server.Store)
+	tokens := auth.NewTokens(server.Config.Auth)
+	middle := middleware.NewMiddleware(server.Store, tokens)
 
-	if os.Getenv("API_ENV") == "testing" {
+	if server.Config.Mode == config.Testing {
 		gin.SetMode(gin.TestMode)
 	}
 
 	router := gin.New()
+	router.Use(middleware.Timeout(server.Config.Server.RequestTimeout))
 
 	flavor.RegisterRoutes(router, server.Store, middle)
-	order.RegisterRoutes(router, server.Store, middle)
-	myOrders.RegisterRoutes(router, server.Store, middle)
+	flavorCategory.RegisterRoutes(router, server.Store, middle)
+	price.RegisterRoutes(router, server.Store, middle, server.Config.Store)
+	promoCode.RegisterRoutes(router, server.Store, middle, server.Config.Store)
+	order.RegisterRoutes(router, server.Store, middle, server.Config.Payment)
+	myOrders.RegisterRoutes(router, server.Store, middle, server.Config.Payment, server.Config.Store)
+	kitchen.RegisterRoutes(router, server.Store, middle)
+	myDeliveries.RegisterRoutes(router, server.Store, middle)
 	deliveryDriver.RegisterRoutes(router, server.Store, middle)
 	user.RegisterRoutes(router, server.Store, middle)
-	myAccount.RegisterRoutes(router, server.Store, middle)
+	myAccount.RegisterRoutes(router, server.Store, middle, tokens)
 
 	return router
 }
@@ -18,7 +18,7 @@ func newHandler(store storage.Storage) *handler {
 
 // GetUsers handles the GET request to obtain all users (only admins)
 func (h *handler) GetUsers(c *gin.Context) {
-	users := h.Store.GetAllUsers()
+	users := h.Store.GetAllUsers(c.Request.Context())
 	c.JSON(http.StatusOK, users)
 }
 
@@ -29,7 +29,7 @@ func (h *handler) GetUserByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	user, err := h.Store.GetUserByID(id)
+	user, err := h.Store.GetUserByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -44,7 +44,7 @@ func (h *handler) DeleteUserByID(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = h.Store.DeleteUserByID(id)
+	err = h.Store.DeleteUserByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -59,7 +59,7 @@ func (h *handler) PromoteToAdmin(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err = h.Store.PromoteUserToAdmin(id)
+	err = h.Store.PromoteUserToAdmin(c.Request.Context(), id)
 	if err != nil {
 		if err.Error() == messageErrors.UserIDNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -70,3 +70,22 @@ func (h *handler) PromoteToAdmin(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"description": "The user has been promoted to admin"})
 }
+
+// PromoteToStaff handles the PUT request to add any user to the shop staff by id (only admins)
+func (h *handler) PromoteToStaff(c *gin.Context) {
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.Store.PromoteUserToStaff(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == messageErrors.UserIDNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"description": "The user has been added to the staff"})
+}
@@ -15,5 +15,6 @@ func RegisterRoutes(router *gin.Engine, storage storage.Storage, middleware *mid
 		userRoutes.GET("/:id", handler.GetUserByID)
 		userRoutes.DELETE("/:id", handler.DeleteUserByID)
 		userRoutes.PUT("/:id/admin", handler.PromoteToAdmin)
+		userRoutes.PUT("/:id/staff", handler.PromoteToStaff)
 	}
 }
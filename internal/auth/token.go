@@ -3,31 +3,40 @@ package auth
 import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"log"
-	"os"
+	"icecreamshop/internal/config"
 	"time"
 )
 
-func GenerateTokenFromUserEmail(email string) string {
+// Tokens signs and verifies the session tokens of the users with the secret of the config.
+type Tokens struct {
+	secret []byte
+}
+
+func NewTokens(settings config.Auth) Tokens {
+	return Tokens{secret: []byte(settings.JWTSecret)}
+}
+
+func (tokens Tokens) GenerateTokenFromUserEmail(email string) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": email,
 		"exp": time.Now().Add(time.Hour * 2).Unix(),
 	})
-	tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, _ := token.SignedString(tokens.secret)
 	return tokenString
 }
 
-func ParseToken(tokenString string) *jwt.Token {
+// ParseToken parses and verifies a token string. An error is returned if the token is invalid or expired.
+func (tokens Tokens) ParseToken(tokenString string) (*jwt.Token, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if ok == false {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return tokens.secret, nil
 	})
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return token
+	return token, nil
 }
@@ -0,0 +1,213 @@
+// Package config has the settings of the binary, loaded from the env, an optional YAML or TOML file and flags.
+// The settings are passed to the packages that use them, so no other package reads the env.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"icecreamshop/internal/types"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Modes the binary runs in. The testing mode keeps the data in memory, the others in a database.
+const (
+	Development = "development"
+	Production  = "production"
+	Testing     = "testing"
+)
+
+// Database drivers of the development and production modes.
+const (
+	PostgresDriver = "postgres"
+	SQLiteDriver   = "sqlite"
+)
+
+// Config has every setting of the binary.
+type Config struct {
+	// Mode is development, production or testing.
+	Mode     string
+	Server   Server
+	Database Database
+	// TestDatabase is the Postgres database used instead of the one of Database in the testing mode, like by the integration tests.
+	TestDatabase Postgres
+	Auth         Auth
+	Payment      Payment
+	Store        types.StoreSettings
+}
+
+// Server has the settings of the http server of the api. Zero timeouts disable them.
+type Server struct {
+	Host string
+	Port string
+	// TLSCertFile and TLSKeyFile are the PEM files of the certificate and its private key. The api is served over TLS when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// RequestTimeout is how long a request can be processed before it is cancelled.
+	RequestTimeout time.Duration
+	// ReadTimeout, WriteTimeout and IdleTimeout limit how long a connection can take to send a request,
+	// to receive its response and to stay open between requests.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long the requests in progress have to be answered once the server is stopped.
+	ShutdownTimeout time.Duration
+}
+
+// Database has the settings of the database of the development and production modes.
+type Database struct {
+	// Driver is postgres or sqlite.
+	Driver string
+	// SQLitePath is the file of the SQLite database.
+	SQLitePath string
+	Postgres
+}
+
+// Postgres has the settings to connect to a Postgres database.
+type Postgres struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// Auth has the settings of the session tokens of the users.
+type Auth struct {
+	JWTSecret string
+}
+
+// Payment has the settings of the payment providers.
+type Payment struct {
+	MPAccessToken string
+}
+
+// Default returns the settings used when they are not set.
+// The write timeout is longer than the request timeout, so requests that run out of time can still be answered.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Host:            "localhost",
+			Port:            "8080",
+			RequestTimeout:  10 * time.Second,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: Database{
+			Driver:     PostgresDriver,
+			SQLitePath: "icecreamshop.db",
+		},
+		Store: types.DefaultStoreSettings(),
+	}
+}
+
+// Address is the host and port the server listens on.
+func (server Server) Address() string {
+	return net.JoinHostPort(server.Host, server.Port)
+}
+
+// TLS tells whether the api is served over TLS.
+func (server Server) TLS() bool {
+	return server.TLSCertFile != "" && server.TLSKeyFile != ""
+}
+
+// DSN is the connection string of the database.
+func (postgres Postgres) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		postgres.Host, postgres.Port, postgres.User, postgres.Password, postgres.Name)
+}
+
+// PostgresDatabase is the Postgres database of the mode: the test database when testing, the one of Database otherwise.
+func (config Config) PostgresDatabase() Postgres {
+	if config.Mode == Testing {
+		return config.TestDatabase
+	}
+	return config.Database.Postgres
+}
+
+// Validate checks the settings needed by the mode. The database and the payment providers are only needed outside the
+// testing mode, which keeps the data in memory, and Postgres settings are only needed when it is the database driver.
+func (config Config) Validate() error {
+	if config.Mode == "" {
+		return missing("mode")
+	}
+	if config.Mode != Development && config.Mode != Production && config.Mode != Testing {
+		return invalid("mode", "must be development, production or testing")
+	}
+
+	if config.Server.Port == "" {
+		return missing("server.port")
+	}
+	if port, err := strconv.Atoi(config.Server.Port); err != nil || port < 0 || port > 65535 {
+		return invalid("server.port", "must be a port number")
+	}
+	if (config.Server.TLSCertFile == "") != (config.Server.TLSKeyFile == "") {
+		return invalid("server.tls_cert_file", "must be set together with server.tls_key_file")
+	}
+
+	if config.Auth.JWTSecret == "" {
+		return missing("auth.jwt_secret")
+	}
+
+	if !types.IsValidCurrency(config.Store.Currency) {
+		return invalid("store.currency", "must be a three letters ISO 4217 code")
+	}
+	if !types.IsValidClock(config.Store.OpeningTime) {
+		return invalid("store.opening_time", "must have the format HH:MM")
+	}
+	if !types.IsValidClock(config.Store.ClosingTime) {
+		return invalid("store.closing_time", "must have the format HH:MM")
+	}
+
+	if config.Mode == Testing {
+		return nil
+	}
+	if err := config.ValidateDatabase(); err != nil {
+		return err
+	}
+	if config.Payment.MPAccessToken == "" {
+		return missing("payment.mp_access_token")
+	}
+	return nil
+}
+
+// ValidateDatabase checks the settings of the database of the mode, see PostgresDatabase.
+// Commands that use the database in the testing mode, like migrate, check them besides Validate.
+func (config Config) ValidateDatabase() error {
+	switch config.Database.Driver {
+	case SQLiteDriver:
+		if config.Database.SQLitePath == "" {
+			return missing("database.sqlite_path")
+		}
+		return nil
+	case PostgresDriver:
+		section := "database"
+		if config.Mode == Testing {
+			section = "test_database"
+		}
+		postgres := config.PostgresDatabase()
+		for _, setting := range []struct{ key, value string }{
+			{"host", postgres.Host}, {"port", postgres.Port}, {"user", postgres.User}, {"password", postgres.Password}, {"name", postgres.Name},
+		} {
+			if setting.value == "" {
+				return missing(section + "." + setting.key)
+			}
+		}
+		return nil
+	default:
+		return invalid("database.driver", "must be postgres or sqlite")
+	}
+}
+
+// missing is the error of a setting that is needed but not set.
+func missing(key string) error {
+	return fmt.Errorf("%s is needed, set %s", key, sourcesOf(key))
+}
+
+// invalid is the error of a setting with a wrong value.
+func invalid(key string, reason string) error {
+	return errors.New(key + " " + reason)
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// setting is a field of the config, with the names it has in the config file, the env and the flags.
+// Settings without a flag, like secrets, cannot be given in the command line, where other users of the machine can see them.
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	usage string
+	set   func(config *Config, value string) error
+}
+
+var settings = []setting{
+	{"mode", "API_ENV", "mode", "development, production or testing", lowerText(func(c *Config) *string { return &c.Mode })},
+
+	{"server.host", "API_HOST", "host", "host the server listens on, 0.0.0.0 for every interface", text(func(c *Config) *string { return &c.Server.Host })},
+	{"server.port", "API_PORT", "port", "port the server listens on", text(func(c *Config) *string { return &c.Server.Port })},
+	{"server.tls_cert_file", "TLS_CERT_FILE", "tls-cert-file", "PEM file of the TLS certificate", text(func(c *Config) *string { return &c.Server.TLSCertFile })},
+	{"server.tls_key_file", "TLS_KEY_FILE", "tls-key-file", "PEM file of the private key of the TLS certificate", text(func(c *Config) *string { return &c.Server.TLSKeyFile })},
+	{"server.request_timeout", "REQUEST_TIMEOUT", "request-timeout", "how long a request can be processed", duration(func(c *Config) *time.Duration { return &c.Server.RequestTimeout })},
+	{"server.read_timeout", "READ_TIMEOUT", "read-timeout", "how long a connection can take to send a request", duration(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"server.write_timeout", "WRITE_TIMEOUT", "write-timeout", "how long a connection can take to receive a response", duration(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"server.idle_timeout", "IDLE_TIMEOUT", "idle-timeout", "how long a connection can stay open between requests", duration(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long the requests in progress have to be answered on shutdown", duration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+
+	{"database.driver", "DB_DRIVER", "db-driver", "postgres or sqlite", lowerText(func(c *Config) *string { return &c.Database.Driver })},
+	{"database.sqlite_path", "SQLITE_PATH", "sqlite-path", "file of the SQLite database", text(func(c *Config) *string { return &c.Database.SQLitePath })},
+	{"database.host", "DB_HOST", "db-host", "host of the Postgres database", text(func(c *Config) *string { return &c.Database.Host })},
+	{"database.port", "DB_PORT", "db-port", "port of the Postgres database", text(func(c *Config) *string { return &c.Database.Port })},
+	{"database.user", "DB_USER", "db-user", "user of the Postgres database", text(func(c *Config) *string { return &c.Database.User })},
+	{"database.password", "DB_PASSWORD", "", "", text(func(c *Config) *string { return &c.Database.Password })},
+	{"database.name", "DB_NAME", "db-name", "name of the Postgres database", text(func(c *Config) *string { return &c.Database.Name })},
+
+	{"test_database.host", "TEST_DB_HOST", "", "", text(func(c *Config) *string { return &c.TestDatabase.Host })},
+	{"test_database.port", "TEST_DB_PORT", "", "", text(func(c *Config) *string { return &c.TestDatabase.Port })},
+	{"test_database.user", "TEST_DB_USER", "", "", text(func(c *Config) *string { return &c.TestDatabase.User })},
+	{"test_database.password", "TEST_DB_PASSWORD", "", "", text(func(c *Config) *string { return &c.TestDatabase.Password })},
+	{"test_database.name", "TEST_DB_NAME", "", "", text(func(c *Config) *string { return &c.TestDatabase.Name })},
+
+	{"auth.jwt_secret", "JWT_SECRET", "", "", text(func(c *Config) *string { return &c.Auth.JWTSecret })},
+
+	{"payment.mp_access_token", "MP_ACCESS_TOKEN", "", "", text(func(c *Config) *string { return &c.Payment.MPAccessToken })},
+
+	{"store.currency", "STORE_CURRENCY", "currency", "ISO 4217 code of the currency of the prices", upperText(func(c *Config) *string { return &c.Store.Currency })},
+	{"store.opening_time", "STORE_OPENING_TIME", "opening-time", "time of the day the store opens, like 11:00", text(func(c *Config) *string { return &c.Store.OpeningTime })},
+	{"store.closing_time", "STORE_CLOSING_TIME", "closing-time", "time of the day the store closes, like 23:00", text(func(c *Config) *string { return &c.Store.ClosingTime })},
+	{"store.scheduling_lead_time", "SCHEDULING_LEAD_TIME", "scheduling-lead-time", "how long in advance a delivery window must start", duration(func(c *Config) *time.Duration { return &c.Store.SchedulingLeadTime })},
+	{"store.driver_notice", "DRIVER_NOTICE", "driver-notice", "how long before its delivery window an order can be assigned to drivers", duration(func(c *Config) *time.Duration { return &c.Store.DriverNotice })},
+}
+
+// configFileEnv and configFileFlag set the path of the config file.
+const (
+	configFileEnv  = "CONFIG_FILE"
+	configFileFlag = "config"
+)
+
+// Load reads the settings from the env, the config file and the flags in args, in that order, so each source overrides the
+// ones before it. Settings that are not set keep their default value, see Default.
+// The config file is set with the CONFIG_FILE env variable or the --config flag, and can be YAML or TOML, by its extension.
+// Flags go before the command, and the rest of args, starting with the command, is returned.
+// When args ask for help, flag.ErrHelp is returned.
+func Load(args []string) (Config, []string, error) {
+	config := Default()
+
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if err := s.set(&config, value); err != nil {
+			return Config{}, nil, fmt.Errorf("%s env %w", s.env, err)
+		}
+	}
+
+	flags, values := newFlagSet(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	path := strings.TrimSpace(os.Getenv(configFileEnv))
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == configFileFlag {
+			path = f.Value.String()
+		}
+	})
+	if path != "" {
+		if err := loadFile(&config, path); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		s, ok := values[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if setErr := s.set(&config, f.Value.String()); setErr != nil {
+			err = fmt.Errorf("--%s flag %w", f.Name, setErr)
+		}
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return config, flags.Args(), nil
+}
+
+// PrintFlags writes the flags accepted by Load and what they set.
+func PrintFlags(output io.Writer) {
+	flags, _ := newFlagSet(output)
+	flags.PrintDefaults()
+}
+
+// newFlagSet creates the flags of the settings, returning them by name.
+// Their values are read once parsed, so only the flags that were given override the other sources.
+func newFlagSet(output io.Writer) (*flag.FlagSet, map[string]setting) {
+	flags := flag.NewFlagSet("icecreamshop", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {}
+	flags.String(configFileFlag, "", "YAML or TOML config file, also set with the "+configFileEnv+" env")
+	values := map[string]setting{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		flags.String(s.flag, "", fmt.Sprintf("%s (%s env, %s in the config file)", s.usage, s.env, s.key))
+		values[s.flag] = s
+	}
+	return flags, values
+}
+
+// loadFile sets the settings of a YAML or TOML config file. Unknown settings are an error, so typos are not ignored.
+func loadFile(config *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	document := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &document)
+	case ".toml":
+		err = toml.Unmarshal(content, &document)
+	default:
+		return fmt.Errorf("the config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("the config file %s is invalid: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten("", document, values)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s, ok := settingByKey(key)
+		if !ok {
+			return fmt.Errorf("%s in %s is not a setting", key, path)
+		}
+		if err := s.set(config, values[key]); err != nil {
+			return fmt.Errorf("%s in %s %w", key, path, err)
+		}
+	}
+	return nil
+}
+
+// flatten lists the values of a document by their dotted keys, like server.port. Empty values are left out.
+func flatten(prefix string, document map[string]any, values map[string]string) {
+	for key, value := range document {
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(prefix+key+".", value, values)
+		case nil:
+		default:
+			if text := fmt.Sprint(value); strings.TrimSpace(text) != "" {
+				values[prefix+key] = text
+			}
+		}
+	}
+}
+
+func settingByKey(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// sourcesOf tells where a setting can be set, for the error messages.
+func sourcesOf(key string) string {
+	s, ok := settingByKey(key)
+	if !ok {
+		return key + " in the config file"
+	}
+	if s.flag == "" {
+		return fmt.Sprintf("the %s env or %s in the config file", s.env, s.key)
+	}
+	return fmt.Sprintf("the %s env, %s in the config file or the --%s flag", s.env, s.key, s.flag)
+}
+
+func text(field func(c *Config) *string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		*field(config) = strings.TrimSpace(value)
+		return nil
+	}
+}
+
+func lowerText(field func(c *Config) *string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		*field(config) = strings.ToLower(strings.TrimSpace(value))
+		return nil
+	}
+}
+
+func upperText(field func(c *Config) *string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		*field(config) = strings.ToUpper(strings.TrimSpace(value))
+		return nil
+	}
+}
+
+func duration(field func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || parsed < 0 {
+			return errors.New("must be a duration, like 10s")
+		}
+		*field(config) = parsed
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ const (
 	UserEmailNotFound      = "No user found with this email."
 	EmailAlreadyExists     = "Email already exists."
 	UserIsAlreadyAnAdmin   = "User is already an admin."
+	UserIsAlreadyStaff     = "User is already part of the staff."
 	UserIsAlreadyADriver   = "User is already a delivery driver."
 	InvalidEmailOrPassword = "Invalid email or password."
 	EmailIsRequired        = "Email is required."
@@ -18,20 +19,98 @@ const (
 	InvalidJsonFormat           = "Invalid json format."
 	ErrorWhileProcessingRequest = "An error occurred while processing your request. Please try again later."
 	MustBeAnInteger             = "The value must be a positive number"
+	RequestTimedOut             = "The request took too long to be processed."
+	RequestCancelled            = "The request was cancelled before it could be processed."
 
 	//Order messageErrors
-	OrderNotFound          = "No order found with this ID."
-	FlavorNotFound         = "No flavor found with this ID."
-	AlreadyExistingFlavor  = "This flavor ID already exists."
-	NonExistingFlavors     = "One or more flavor do not exist."
-	WeightNotAvailable     = "Weight not available."
-	WeightCannotBeZero     = "Weight must be a positive number."
-	IceCreamTubNotFound    = "No ice cream tub found with this ID."
-	FlavorIdIsRequired     = "Flavor id is required."
-	FlavorNameIsRequired   = "Flavor name is required."
-	FlavorTypeIsRequired   = "Flavor type is required."
-	AddressIsRequired      = "Address is required."
-	InvalidAmountOfFlavors = "Flavors cannot be 0 or greater than 4."
+	OrderNotFound            = "No order found with this ID."
+	InvalidIceCreamTubs      = "One or more ice cream tubs are invalid."
+	FlavorNotFound           = "No flavor found with this ID."
+	AlreadyExistingFlavor    = "This flavor ID already exists."
+	FlavorIsAlreadyRetired   = "This flavor is already retired."
+	NonExistingFlavors       = "One or more flavor do not exist."
+	WeightNotAvailable       = "Weight not available."
+	WeightCannotBeZero       = "Weight must be a positive number."
+	IceCreamTubNotFound      = "No ice cream tub found with this ID."
+	FlavorIdIsRequired       = "Flavor id is required."
+	FlavorNameIsRequired     = "Flavor name is required."
+	FlavorCategoryIsRequired = "Flavor category is required."
+	AddressIsRequired        = "Address is required."
+	InvalidAmountOfFlavors   = "Flavors cannot be 0 or more than allowed for this weight."
+	OutOfStockFlavors        = "One or more flavors are out of stock."
+	UnavailableFlavors       = "One or more flavors are not available at this moment."
+
+	//Order status messageErrors
+	InvalidOrderStatus           = "Invalid order status."
+	IllegalOrderStatusTransition = "The order cannot move from its current status to the requested one."
+	OrderStatusNotAllowedForRole = "You are not allowed to move an order to this status."
+	OrderIsNotADraft             = "The order has already been placed and its tubs cannot be changed."
+	OrderHasNoIceCreamTubs       = "The order has no ice cream tubs."
+	CancelOrderInstead           = "Orders can only be cancelled through their cancel endpoint."
+	OrderCannotBeCancelled       = "The order cannot be cancelled once its preparation has started."
+	OrderIsAlreadyCancelled      = "The order is already cancelled."
+	CancellationReasonIsRequired = "A reason is required to cancel the order."
+	OrderCannotGetADriver        = "The order cannot get a delivery driver once it left the shop or was cancelled."
+	OrderDeliveryCannotBeChanged = "The address and delivery window of the order cannot be changed once it left the shop or was cancelled."
+
+	//Scheduled orders messageErrors
+	InvalidDeliveryWindow             = "The delivery window must end after it starts."
+	DeliveryWindowIsTooSoon           = "The delivery window starts too soon to prepare the order."
+	DeliveryWindowOutsideOpeningHours = "The delivery window must be within the store opening hours of a single day."
+	InvalidScheduledDate              = "Scheduled date must have the format YYYY-MM-DD."
+
+	//Reorder messageErrors
+	RetiredFlavor      = "This flavor is no longer sold."
+	UnavailableFlavor  = "This flavor is not available at this moment."
+	NoFlavorsLeftInTub = "None of the flavors of this tub can be ordered now."
+
+	//Prices messageErrors
+	AlreadyExistingPrice   = "A price for this weight already exists."
+	PriceCannotBeZero      = "Price must be a positive number."
+	MaxFlavorsCannotBeZero = "Max flavors must be a positive number."
+
+	//Money messageErrors
+	InvalidCurrency  = "Currency must be a three letters ISO 4217 code, like ARS."
+	CurrencyMismatch = "Amounts in different currencies cannot be combined."
+
+	//Promo codes messageErrors
+	PromoCodeNotFound           = "No promo code found with this code."
+	AlreadyExistingPromoCode    = "This promo code already exists."
+	PromoCodeIsInUse            = "This promo code was already applied to orders."
+	PromoCodeIsRequired         = "Promo code is required."
+	InvalidPromoCodeKind        = "Promo code kind must be one of: percentage, fixedAmount, freeTub."
+	InvalidPromoCodePercentage  = "Percentage must be between 1 and 100."
+	PromoCodeAmountCannotBeZero = "Discount amount must be a positive number."
+	PromoCodeWeightIsRequired   = "Free tub weight is required."
+	InvalidPromoCodeDate        = "Promo code dates must have the format YYYY-MM-DD."
+	InvalidPromoCodeValidity    = "Valid from date cannot be after valid until date."
+	PromoCodeIsNotValidNow      = "This promo code is not valid at this moment."
+	PromoCodeUsageLimitReached  = "This promo code has reached its usage limit."
+	PromoCodeUserLimitReached   = "You have already used this promo code the maximum number of times."
+	OrderTotalBelowPromoMinimum = "The order total is below the minimum required by this promo code."
+	PromoCodeNotApplicable      = "This promo code does not give any discount to this order."
+	PromoCodeAlreadyApplied     = "The order already has a promo code."
+	OrderIsAlreadyPaid          = "The order is already paid."
+	OrderIsBeingPaid            = "The order is already being paid."
+	OrderPaymentNotStarted      = "The payment of the order has not started."
+	PaidAmountMismatch          = "The amount charged does not match the order total."
+	OrderTotalIsZero            = "The order total must be greater than zero to be paid."
+
+	//Flavor categories messageErrors
+	FlavorCategoryNotFound        = "No flavor category found with this ID."
+	AlreadyExistingFlavorCategory = "This flavor category ID already exists."
+	FlavorCategoryIsInUse         = "This flavor category still has flavors."
+	FlavorCategoryIdIsRequired    = "Flavor category id is required."
+	FlavorCategoryNameIsRequired  = "Flavor category name is required."
+
+	//Flavor availability messageErrors
+	InvalidAvailabilityDate   = "Availability dates must have the format YYYY-MM-DD."
+	InvalidAvailabilityWindow = "Available from date cannot be after available until date."
+	InvalidWeekday            = "Weekdays must be day names in english, like monday."
+
+	//Flavor allergens and diets messageErrors
+	InvalidAllergen = "Allergens must be one of: nuts, peanuts, gluten, lactose, eggs, soy."
+	InvalidDiet     = "Diets must be one of: vegan, sugar-free, gluten-free, lactose-free."
 
 	//Delivery drivers messageErrors
 	DeliveryDriverNotFound      = "No delivery driver found with this ID."
@@ -49,4 +128,8 @@ const (
 	InvalidExpirationMonth         = "Invalid expiration month."
 	InvalidExpirationYear          = "Invalid expiration year."
 	InvalidCVV                     = "Invalid CVV."
+	PaymentReferenceNotFound       = "No payment found to refund."
+	RefundAmountMustBePositive     = "The amount to refund must be greater than zero."
+	OrderHasNoPendingRefund        = "The order has no refund pending."
+	RefundIsPending                = "The order was cancelled, but its payment could not be refunded yet."
 )
@@ -11,11 +11,12 @@ import (
 )
 
 type Middleware struct {
-	Store storage.Storage
+	Store  storage.Storage
+	Tokens auth.Tokens
 }
 
-func NewMiddleware(store storage.Storage) *Middleware {
-	return &Middleware{Store: store}
+func NewMiddleware(store storage.Storage, tokens auth.Tokens) *Middleware {
+	return &Middleware{Store: store, Tokens: tokens}
 }
 
 // CheckIfNotLoggedIn checks if there is NOT an user logged in. Otherwise, aborts.
@@ -30,6 +31,39 @@ func (middleware *Middleware) CheckIfNotLoggedIn(c *gin.Context) {
 	c.Next()
 }
 
+// IdentifyUser identifies the user who is logged in, if there is one.
+// Unlike AuthenticateUser, it never aborts, so it can be used in public endpoints.
+func (middleware *Middleware) IdentifyUser(c *gin.Context) {
+	tokenString, err := c.Cookie("Authorization")
+	if err != nil || tokenString == "" {
+		c.Next()
+		return
+	}
+
+	token, err := middleware.Tokens.ParseToken(tokenString)
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || tokenIsExpired(claims) {
+		c.Next()
+		return
+	}
+
+	user, err := middleware.Store.GetUserByEmail(c.Request.Context(), claims["sub"].(string))
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	c.Set("user-email", user.Email)
+	c.Set("user-id", user.ID)
+	c.Set("is-admin", user.IsAdmin())
+	c.Next()
+}
+
 // AuthenticateUser authenticates if an user is logged in.
 func (middleware *Middleware) AuthenticateUser(c *gin.Context) {
 	tokenString, err := c.Cookie("Authorization")
@@ -38,7 +72,11 @@ func (middleware *Middleware) AuthenticateUser(c *gin.Context) {
 		return
 	}
 
-	token := auth.ParseToken(tokenString)
+	token, err := middleware.Tokens.ParseToken(tokenString)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 
 	if !ok {
@@ -51,7 +89,7 @@ func (middleware *Middleware) AuthenticateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := middleware.Store.GetUserByEmail(claims["sub"].(string))
+	user, err := middleware.Store.GetUserByEmail(c.Request.Context(), claims["sub"].(string))
 	if err != nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
@@ -70,7 +108,11 @@ func (middleware *Middleware) AuthenticateAdmin(c *gin.Context) {
 		return
 	}
 
-	token := auth.ParseToken(tokenString)
+	token, err := middleware.Tokens.ParseToken(tokenString)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 
 	if !ok {
@@ -83,7 +125,7 @@ func (middleware *Middleware) AuthenticateAdmin(c *gin.Context) {
 		return
 	}
 
-	user, err := middleware.Store.GetUserByEmail(claims["sub"].(string))
+	user, err := middleware.Store.GetUserByEmail(c.Request.Context(), claims["sub"].(string))
 	if err != nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
@@ -106,7 +148,11 @@ func (middleware *Middleware) AuthenticateDeliveryDriver(c *gin.Context) {
 		return
 	}
 
-	token := auth.ParseToken(tokenString)
+	token, err := middleware.Tokens.ParseToken(tokenString)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 
 	if !ok {
@@ -119,7 +165,7 @@ func (middleware *Middleware) AuthenticateDeliveryDriver(c *gin.Context) {
 		return
 	}
 
-	user, err := middleware.Store.GetUserByEmail(claims["sub"].(string))
+	user, err := middleware.Store.GetUserByEmail(c.Request.Context(), claims["sub"].(string))
 	if err != nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
@@ -134,6 +180,47 @@ func (middleware *Middleware) AuthenticateDeliveryDriver(c *gin.Context) {
 	c.Next()
 }
 
+// AuthenticateStaff authenticates if a member of the shop staff is logged in. Admins are considered staff too.
+func (middleware *Middleware) AuthenticateStaff(c *gin.Context) {
+	tokenString, err := c.Cookie("Authorization")
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	token, err := middleware.Tokens.ParseToken(tokenString)
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if tokenIsExpired(claims) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	user, err := middleware.Store.GetUserByEmail(c.Request.Context(), claims["sub"].(string))
+	if err != nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if !user.IsAdmin() && !user.IsStaff() {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	c.Set("user-email", user.Email)
+	c.Set("user-id", user.ID)
+	c.Next()
+}
+
 func tokenIsExpired(claims jwt.MapClaims) bool {
 	if float64(time.Now().Unix()) > claims["exp"].(float64) {
 		return true
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"icecreamshop/internal/messageErrors"
+	"net/http"
+	"time"
+)
+
+// Timeout sets a deadline to the context of each request, so the storage queries it runs are cancelled once the timeout passes.
+// Requests that run out of time are answered with 504, and requests whose context is cancelled before, like when the client
+// disconnects, with 503. Whatever the handler writes after its context is done is discarded. Zero disables the timeout.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout == 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if ctx.Err() == nil || writer.Written() {
+			return
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": messageErrors.RequestTimedOut})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": messageErrors.RequestCancelled})
+	}
+}
+
+// timeoutWriter discards the response of a handler once the context of its request is done,
+// so the Timeout middleware can answer instead.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.ctx.Err() == nil {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if w.ctx.Err() == nil {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, w.ctx.Err()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
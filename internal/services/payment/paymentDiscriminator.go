@@ -2,11 +2,13 @@ package payment
 
 import (
 	"errors"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/types"
 )
 
-// ProcessPayment discriminates payment data and process the payment method chosen
-func ProcessPayment(paymentData PaymentRequest, totalCost uint) (any, error) {
+// ProcessPayment discriminates payment data and process the payment method chosen, with the providers of the config
+func ProcessPayment(paymentData PaymentRequest, totalCost types.Money, settings config.Payment) (any, error) {
 	switch paymentData.PaymentType {
 	case CreditCardType:
 		if paymentData.CreditCard == nil {
@@ -25,7 +27,7 @@ func ProcessPayment(paymentData PaymentRequest, totalCost uint) (any, error) {
 			return "", errors.New(messageErrors.InvalidPaymentData)
 		}
 		paymentData.PreferenceMP.Amount = totalCost
-		return paymentData.PreferenceMP.Process()
+		return paymentData.PreferenceMP.Process(settings.MPAccessToken)
 	default:
 		return "", errors.New(messageErrors.UnsupportedPaymentType)
 	}
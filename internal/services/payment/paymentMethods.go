@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"icecreamshop/internal/messageErrors"
-	"os"
+	"icecreamshop/internal/types"
 
 	"github.com/mercadopago/sdk-go/pkg/config"
 	"github.com/mercadopago/sdk-go/pkg/preference"
@@ -15,9 +15,12 @@ const CreditCardType = "creditCard"
 const DigitalWalletType = "digitalWallet"
 const PreferenceMPType = "preferenceMP"
 
+// simulatedReference is the reference of the payments of the simulated credit card and digital wallet providers.
+const simulatedReference = "ABCDE123"
+
 type Payment struct {
-	Amount      uint   `json:"amount"`
-	PaymentType string `json:"payment_type"`
+	Amount      types.Money `json:"amount"`
+	PaymentType string      `json:"payment_type"`
 }
 
 type CreditCard struct {
@@ -54,15 +57,15 @@ func (p *CreditCard) Process() (string, error) {
 	//External integration service is needed
 	//For testing, we assume it paid.
 
-	return "ABCDE123", nil
+	return simulatedReference, nil
 }
 
-func (p *PreferenceMP) Process() (*preference.Response, error) {
-	if p.Amount <= 0 {
+// Process creates a MercadoPago preference for the amount, with the access token of the shop account.
+func (p *PreferenceMP) Process(accessToken string) (*preference.Response, error) {
+	if !p.Amount.IsPositive() {
 		return &preference.Response{}, errors.New(messageErrors.MustBeAnInteger)
 	}
 
-	accessToken := os.Getenv("MP_ACCESS_TOKEN")
 	cfg, err := config.New(accessToken)
 	if err != nil {
 		fmt.Println(err)
@@ -73,9 +76,10 @@ func (p *PreferenceMP) Process() (*preference.Response, error) {
 	request := preference.Request{
 		Items: []preference.ItemRequest{
 			{
-				Title:     "icrecreamshop-backend Payment",
-				Quantity:  1,
-				UnitPrice: float64(p.Amount),
+				Title:      "icrecreamshop-backend Payment",
+				Quantity:   1,
+				CurrencyID: p.Amount.Currency,
+				UnitPrice:  p.Amount.MajorUnits(),
 			},
 		},
 	}
@@ -97,11 +101,11 @@ func (p *DigitalWallet) Process() (string, error) {
 	//External integration service is needed
 	//For testing, we assume it paid.
 
-	return "ABCDE123", nil
+	return simulatedReference, nil
 }
 
 func (p *CreditCard) Validate() error {
-	if p.Amount <= 0 {
+	if !p.Amount.IsPositive() {
 		return errors.New(messageErrors.MustBeAnInteger)
 	}
 	if len(p.CardNumber) != 16 {
@@ -123,7 +127,7 @@ func (p *CreditCard) Validate() error {
 }
 
 func (p *DigitalWallet) Validate() error {
-	if p.Amount <= 0 {
+	if !p.Amount.IsPositive() {
 		return errors.New(messageErrors.MustBeAnInteger)
 	}
 	if len(p.WalletID) == 0 {
@@ -0,0 +1,106 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"icecreamshop/internal/config"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"strconv"
+	"sync"
+
+	mpconfig "github.com/mercadopago/sdk-go/pkg/config"
+	"github.com/mercadopago/sdk-go/pkg/merchantorder"
+	"github.com/mercadopago/sdk-go/pkg/preference"
+	"github.com/mercadopago/sdk-go/pkg/refund"
+)
+
+const approvedMPPaymentStatus = "approved"
+
+// refunds serializes the refunds issued by the server, so an order whose refund is retried twice at once is refunded only once.
+var refunds sync.Mutex
+
+// ReferenceOf obtains the id given by the provider from the response of a processed payment.
+// It must be saved with the order, so the payment can be refunded later.
+// For MercadoPago it is the id of the preference, the checkout the customer pays in, and not the id of a payment:
+// MercadoPago only creates the payment once the customer pays, so it must be looked up by the preference to refund it.
+func ReferenceOf(paymentResponse any) string {
+	switch response := paymentResponse.(type) {
+	case string:
+		return response
+	case *preference.Response:
+		return response.ID
+	default:
+		return ""
+	}
+}
+
+// RefundOrder issues the refund of a cancelled order that is refund pending and marks it as refunded, on behalf of the user with the actor id.
+// It is used right after a paid order is cancelled and to retry the refunds that failed then: if the provider fails, the order stays refund pending.
+func RefundOrder(ctx context.Context, store storage.Storage, idOrder uint, actorID uint, settings config.Payment) (types.Order, error) {
+	refunds.Lock()
+	defer refunds.Unlock()
+
+	order, err := store.GetOrderByID(ctx, idOrder)
+	if err != nil {
+		return types.Order{}, err
+	}
+	if order.PaymentState != types.PaymentRefundPending {
+		return types.Order{}, errors.New(messageErrors.OrderHasNoPendingRefund)
+	}
+
+	refundReference, err := RefundPayment(order.PaymentReference, order.TotalCost, settings)
+	if err != nil {
+		return types.Order{}, errors.New(messageErrors.RefundIsPending)
+	}
+	return store.MarkOrderAsRefunded(ctx, idOrder, refundReference, actorID)
+}
+
+// RefundPayment gives back the amount of a processed payment through its provider, with the providers of the config, and returns the refund id.
+// The reference is the one returned by ReferenceOf when the order was paid.
+func RefundPayment(paymentReference string, amount types.Money, settings config.Payment) (string, error) {
+	if paymentReference == "" {
+		return "", errors.New(messageErrors.PaymentReferenceNotFound)
+	}
+	if !amount.IsPositive() {
+		return "", errors.New(messageErrors.RefundAmountMustBePositive)
+	}
+
+	if paymentReference == simulatedReference {
+		//External integration service is needed
+		//For testing, we assume it was refunded.
+		return "REFUND-" + paymentReference, nil
+	}
+	return refundMPPreference(paymentReference, amount, settings.MPAccessToken)
+}
+
+// refundMPPreference refunds the approved payment of a MercadoPago preference, with the access token of the shop account.
+// The payment is found through the merchant order of the preference, which lists the payments made in its checkout.
+func refundMPPreference(preferenceID string, amount types.Money, accessToken string) (string, error) {
+	cfg, err := mpconfig.New(accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	merchantOrders, err := merchantorder.NewClient(cfg).Search(context.Background(), merchantorder.SearchRequest{
+		Filters: map[string]string{"preference_id": preferenceID},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, merchantOrder := range merchantOrders.Elements {
+		for _, mpPayment := range merchantOrder.Payments {
+			if mpPayment.Status != approvedMPPaymentStatus {
+				continue
+			}
+			resource, err := refund.NewClient(cfg).CreatePartialRefund(context.Background(), mpPayment.ID, amount.MajorUnits())
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(resource.ID), nil
+		}
+	}
+	return "", errors.New(messageErrors.PaymentReferenceNotFound)
+}
@@ -1,145 +1,565 @@
 package storage
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage/migrations"
 	"icecreamshop/internal/types"
 	"icecreamshop/internal/utils"
-	"os"
+	"time"
 )
 
 type DbStorage struct {
 	DB *gorm.DB
+	// testing allows CleanDB, so the data of a development or production database cannot be deleted by mistake.
+	testing bool
+	// settings are the ones of the store whose data is kept in the database.
+	settings types.StoreSettings
 }
 
-func setDBPath() string {
-	if os.Getenv("API_ENV") == "testing" {
-		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-			os.Getenv("TEST_DB_HOST"),
-			os.Getenv("TEST_DB_PORT"),
-			os.Getenv("TEST_DB_USER"),
-			os.Getenv("TEST_DB_PASSWORD"),
-			os.Getenv("TEST_DB_NAME"),
-		)
-	} else {
-		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-			os.Getenv("DB_HOST"),
-			os.Getenv("DB_PORT"),
-			os.Getenv("DB_USER"),
-			os.Getenv("DB_PASSWORD"),
-			os.Getenv("DB_NAME"),
-		)
-	}
-}
-
-func NewDBStorage(flavors []types.Flavor, users []types.User, prices map[uint]uint) *DbStorage {
-	dbPath := setDBPath()
-
-	db, err := gorm.Open(postgres.Open(dbPath), &gorm.Config{})
+// NewDBStorage connects to the Postgres database of the mode of the config and applies its pending migrations.
+// The initial data is not added, see Seed.
+func NewDBStorage(settings config.Config) *DbStorage {
+	db, err := OpenPostgres(settings.PostgresDatabase())
 	if err != nil {
 		panic("failed to connect to database")
 	}
 
-	err = db.AutoMigrate(&types.User{}, &types.DeliveryDriver{}, &types.Order{}, &types.Flavor{}, &types.IceCreamTub{}, &types.IceCreamTubPrice{})
+	_, err = Migrate(db, settings.Store.DefaultCurrency())
 	if err != nil {
-		panic("failed to automigrate data")
+		panic("failed to migrate the database: " + err.Error())
 	}
 
-	var iceCreamTubPrices []types.IceCreamTubPrice
-	for key, value := range prices {
-		iceCreamTubPrices = append(iceCreamTubPrices, types.IceCreamTubPrice{key, value})
-	}
+	return &DbStorage{DB: db, testing: settings.Mode == config.Testing, settings: settings.Store}
+}
 
-	db.Create(&flavors)
-	db.Create(&users)
-	db.Create(&iceCreamTubPrices)
+// OpenPostgres connects to a Postgres database.
+func OpenPostgres(database config.Postgres) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(database.DSN()), &gorm.Config{})
+}
 
-	db.Exec("SELECT setval('users_id_seq', (SELECT MAX(id) FROM users));")
+// Migrate applies the pending migrations to the schema of db and returns them.
+// Databases created with AutoMigrate, before migrations existed, are first adopted by them,
+// and their amounts stored without currency are taken as amounts of the given one.
+func Migrate(db *gorm.DB, currency string) ([]migrations.Migration, error) {
+	if err := adoptAutoMigratedSchema(db, currency); err != nil {
+		return nil, err
+	}
+	return migrations.Up(db)
+}
 
-	return &DbStorage{DB: db}
+// Seed adds the initial data that is missing. Rows that already exist are left as they are, so it can run more than once.
+func Seed(db *gorm.DB, categories []types.FlavorCategory, flavors []types.Flavor, users []types.User, prices []types.IceCreamTubPrice) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := createMissing(tx, categories); err != nil {
+			return err
+		}
+		if err := createMissing(tx, flavors); err != nil {
+			return err
+		}
+		if err := createMissing(tx, users); err != nil {
+			return err
+		}
+		if err := createMissing(tx, prices); err != nil {
+			return err
+		}
+		if tx.Dialector.Name() == "postgres" {
+			// Users are seeded with their ids, so the sequence must continue after them
+			return tx.Exec("SELECT setval('users_id_seq', (SELECT MAX(id) FROM users))").Error
+		}
+		return nil
+	})
 }
 
 /*******************/
 /***** FLAVORS *****/
 /*******************/
 
-func (dbStorage *DbStorage) GetFlavors() []types.Flavor {
+func (dbStorage *DbStorage) GetFlavors(ctx context.Context) []types.Flavor {
+	db := dbStorage.DB.WithContext(ctx)
 	var flavors []types.Flavor
-	dbStorage.DB.Find(&flavors)
+	db.Where("retired = ?", false).Find(&flavors)
 	return flavors
 }
 
-func (dbStorage *DbStorage) GetFlavorsByType(kind string) []types.Flavor {
+func (dbStorage *DbStorage) GetFlavorsByType(ctx context.Context, categoryID string) []types.Flavor {
+	db := dbStorage.DB.WithContext(ctx)
 	var flavors []types.Flavor
-	dbStorage.DB.Where("type = ?", kind).Find(&flavors)
+	db.Where("category_id = ? AND retired = ?", categoryID, false).Find(&flavors)
 	return flavors
 }
 
-func (dbStorage *DbStorage) GetFlavorByID(flavorID string) (types.Flavor, error) {
+func (dbStorage *DbStorage) GetFlavorByID(ctx context.Context, flavorID string) (types.Flavor, error) {
+	db := dbStorage.DB.WithContext(ctx)
 	var flavor types.Flavor
-	err := dbStorage.DB.First(&flavor, "id = ?", flavorID).Error
+	err := db.First(&flavor, "id = ?", flavorID).Error
 	if err != nil {
 		return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
 	}
 	return flavor, nil
 }
 
-func (dbStorage *DbStorage) AddFlavor(flavor types.Flavor) error {
-	err := dbStorage.DB.Create(&flavor).Error
+func (dbStorage *DbStorage) AddFlavor(ctx context.Context, flavor types.Flavor) error {
+	db := dbStorage.DB.WithContext(ctx)
+	if _, err := dbStorage.GetFlavorCategoryByID(ctx, flavor.CategoryID); err != nil {
+		return err
+	}
+	err := db.Create(&flavor).Error
 	if err != nil {
 		return errors.New(messageErrors.AlreadyExistingFlavor)
 	}
 	return nil
 }
 
+func (dbStorage *DbStorage) UpdateFlavor(ctx context.Context, idFlavor string, flavor types.Flavor) (types.Flavor, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	oldFlavor, err := dbStorage.GetFlavorByID(ctx, idFlavor)
+	if err != nil {
+		return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+	}
+	if _, err = dbStorage.GetFlavorCategoryByID(ctx, flavor.CategoryID); err != nil {
+		return types.Flavor{}, err
+	}
+	oldFlavor.Name = flavor.Name
+	oldFlavor.CategoryID = flavor.CategoryID
+	oldFlavor.AvailableFrom = flavor.AvailableFrom
+	oldFlavor.AvailableUntil = flavor.AvailableUntil
+	// Empty lists are saved as [] so the JSON columns are always overwritten
+	oldFlavor.AvailableWeekdays = append([]string{}, flavor.AvailableWeekdays...)
+	oldFlavor.Allergens = append([]string{}, flavor.Allergens...)
+	oldFlavor.Diets = append([]string{}, flavor.Diets...)
+	err = db.Model(&oldFlavor).
+		Select("name", "category_id", "available_from", "available_until", "available_weekdays", "allergens", "diets").
+		Updates(&oldFlavor).Error
+	if err != nil {
+		return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+	}
+	return dbStorage.GetFlavorByID(ctx, idFlavor)
+}
+
+func (dbStorage *DbStorage) RetireFlavor(ctx context.Context, idFlavor string) error {
+	db := dbStorage.DB.WithContext(ctx)
+	flavor, err := dbStorage.GetFlavorByID(ctx, idFlavor)
+	if err != nil {
+		return errors.New(messageErrors.FlavorNotFound)
+	}
+	if flavor.Retired {
+		return errors.New(messageErrors.FlavorIsAlreadyRetired)
+	}
+	err = db.Model(&flavor).Update("retired", true).Error
+	if err != nil {
+		return errors.New(messageErrors.FlavorNotFound)
+	}
+	return nil
+}
+
+func (dbStorage *DbStorage) UpdateFlavorStock(ctx context.Context, idFlavor string, stock uint) (types.Flavor, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	flavor, err := dbStorage.GetFlavorByID(ctx, idFlavor)
+	if err != nil {
+		return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+	}
+	err = db.Model(&flavor).Update("stock", stock).Error
+	if err != nil {
+		return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+	}
+	return flavor, nil
+}
+
+func (dbStorage *DbStorage) GetLowStockFlavors(ctx context.Context, threshold uint) []types.Flavor {
+	db := dbStorage.DB.WithContext(ctx)
+	flavors := []types.Flavor{}
+	db.Where("stock < ? AND retired = ?", threshold, false).Find(&flavors)
+	return flavors
+}
+
+/*****************************/
+/***** FLAVOR CATEGORIES *****/
+/*****************************/
+
+func (dbStorage *DbStorage) GetFlavorCategories(ctx context.Context) []types.FlavorCategory {
+	db := dbStorage.DB.WithContext(ctx)
+	categories := []types.FlavorCategory{}
+	db.Order("sort_order, name").Find(&categories)
+	return categories
+}
+
+func (dbStorage *DbStorage) GetFlavorCategoryByID(ctx context.Context, idCategory string) (types.FlavorCategory, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	var category types.FlavorCategory
+	err := db.First(&category, "id = ?", idCategory).Error
+	if err != nil {
+		return types.FlavorCategory{}, errors.New(messageErrors.FlavorCategoryNotFound)
+	}
+	return category, nil
+}
+
+func (dbStorage *DbStorage) AddFlavorCategory(ctx context.Context, category types.FlavorCategory) error {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.Create(&category).Error
+	if err != nil {
+		return errors.New(messageErrors.AlreadyExistingFlavorCategory)
+	}
+	return nil
+}
+
+func (dbStorage *DbStorage) UpdateFlavorCategory(ctx context.Context, idCategory string, category types.FlavorCategory) (types.FlavorCategory, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	oldCategory, err := dbStorage.GetFlavorCategoryByID(ctx, idCategory)
+	if err != nil {
+		return types.FlavorCategory{}, err
+	}
+	oldCategory.Name = category.Name
+	oldCategory.SortOrder = category.SortOrder
+	err = db.Model(&oldCategory).Select("name", "sort_order").Updates(&oldCategory).Error
+	if err != nil {
+		return types.FlavorCategory{}, errors.New(messageErrors.FlavorCategoryNotFound)
+	}
+	return oldCategory, nil
+}
+
+func (dbStorage *DbStorage) DeleteFlavorCategory(ctx context.Context, idCategory string) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var category types.FlavorCategory
+		err := tx.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, "id = ?", idCategory).Error
+		if err != nil {
+			return errors.New(messageErrors.FlavorCategoryNotFound)
+		}
+		var count int64
+		if err := tx.DB.Model(&types.Flavor{}).Where("category_id = ?", idCategory).Count(&count).Error; err != nil {
+			return errors.New(messageErrors.ErrorWhileProcessingRequest)
+		}
+		if count > 0 {
+			return errors.New(messageErrors.FlavorCategoryIsInUse)
+		}
+		// The foreign key of the flavors rejects the delete if a flavor was added to the category meanwhile
+		if err := tx.DB.Delete(&category).Error; err != nil {
+			return errors.New(messageErrors.FlavorCategoryIsInUse)
+		}
+		return nil
+	})
+}
+
 /******************/
-/***** ORDERS *****/
+/***** PRICES *****/
 /******************/
 
-func (dbStorage *DbStorage) CreateOrder(order *types.Order) error {
-	err := dbStorage.DB.First(&types.User{}, order.UserID).Error
+func (dbStorage *DbStorage) GetPrices(ctx context.Context) []types.IceCreamTubPrice {
+	db := dbStorage.DB.WithContext(ctx)
+	prices := []types.IceCreamTubPrice{}
+	db.Order("weight").Find(&prices)
+	return prices
+}
+
+func (dbStorage *DbStorage) GetPriceByWeight(ctx context.Context, weight uint) (types.IceCreamTubPrice, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	var price types.IceCreamTubPrice
+	err := db.First(&price, "weight = ?", weight).Error
 	if err != nil {
-		return errors.New(messageErrors.UserIDNotFound)
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.WeightNotAvailable)
 	}
-	order.PaymentState = "pending"
-	err = dbStorage.DB.Create(&order).Error
+	return price, nil
+}
+
+func (dbStorage *DbStorage) AddPrice(ctx context.Context, price types.IceCreamTubPrice) error {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.Create(&price).Error
+	if err != nil {
+		return errors.New(messageErrors.AlreadyExistingPrice)
+	}
+	return nil
+}
+
+func (dbStorage *DbStorage) UpdatePrice(ctx context.Context, weight uint, price types.IceCreamTubPrice) (types.IceCreamTubPrice, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	oldPrice, err := dbStorage.GetPriceByWeight(ctx, weight)
+	if err != nil {
+		return types.IceCreamTubPrice{}, err
+	}
+	oldPrice.Price = price.Price
+	oldPrice.MaxFlavors = price.MaxFlavors
+	err = db.Model(&oldPrice).Select("price_amount", "price_currency", "max_flavors").Updates(&oldPrice).Error
+	if err != nil {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.WeightNotAvailable)
+	}
+	return oldPrice, nil
+}
+
+func (dbStorage *DbStorage) DeletePrice(ctx context.Context, weight uint) error {
+	db := dbStorage.DB.WithContext(ctx)
+	result := db.Delete(&types.IceCreamTubPrice{}, "weight = ?", weight)
+	if result.RowsAffected == 0 {
+		return errors.New(messageErrors.WeightNotAvailable)
+	}
+	return nil
+}
+
+/***********************/
+/***** PROMO CODES *****/
+/***********************/
+
+func (dbStorage *DbStorage) GetPromoCodes(ctx context.Context) []types.PromoCode {
+	db := dbStorage.DB.WithContext(ctx)
+	promos := []types.PromoCode{}
+	db.Find(&promos)
+	return promos
+}
+
+func (dbStorage *DbStorage) GetPromoCodeByCode(ctx context.Context, code string) (types.PromoCode, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	var promo types.PromoCode
+	err := db.First(&promo, "code = ?", code).Error
+	if err != nil {
+		return types.PromoCode{}, errors.New(messageErrors.PromoCodeNotFound)
+	}
+	return promo, nil
+}
+
+func (dbStorage *DbStorage) AddPromoCode(ctx context.Context, promo types.PromoCode) error {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.Create(&promo).Error
 	if err != nil {
-		print(err.Error())
+		return errors.New(messageErrors.AlreadyExistingPromoCode)
 	}
 	return nil
 }
 
-func (dbStorage *DbStorage) GetOrderByID(idOrder uint) (types.Order, error) {
+func (dbStorage *DbStorage) UpdatePromoCode(ctx context.Context, code string, promo types.PromoCode) (types.PromoCode, error) {
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		if _, err := lockPromoCodeInDB(code, tx.DB); err != nil {
+			return err
+		}
+		promo.Code = code
+		if err := tx.DB.Save(&promo).Error; err != nil {
+			return errors.New(messageErrors.PromoCodeNotFound)
+		}
+		var orderIDs []uint
+		tx.DB.Model(&types.Order{}).Where("promo_code = ? AND payment_state = ? AND status <> ?", code, types.PaymentPending, types.OrderCancelled).Pluck("id", &orderIDs)
+		for _, orderID := range orderIDs {
+			// The order is checked again once it is locked, as it may have started its payment or been cancelled meanwhile.
+			order, err := lockOrderInDB(orderID, tx.DB)
+			if err != nil {
+				return err
+			}
+			if !order.IsRepriceable() {
+				continue
+			}
+			if err := updateOrderTotalsInDB(orderID, tx.DB); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.PromoCode{}, err
+	}
+	return promo, nil
+}
+
+func (dbStorage *DbStorage) DeletePromoCode(ctx context.Context, code string) error {
+	db := dbStorage.DB.WithContext(ctx)
+	promo, err := dbStorage.GetPromoCodeByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	var count int64
+	db.Model(&types.Order{}).Where("promo_code = ?", code).Count(&count)
+	if count > 0 {
+		return errors.New(messageErrors.PromoCodeIsInUse)
+	}
+	return db.Delete(&promo).Error
+}
+
+func (dbStorage *DbStorage) ApplyPromoCodeToOrder(ctx context.Context, idOrder uint, code string, moment time.Time) (types.Order, error) {
 	var order types.Order
-	err := dbStorage.DB.Preload("IceCreamTubs").First(&order, idOrder).Error
+	// The promo code is locked too, so concurrent orders cannot use it beyond its usage limits.
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		promo, err := lockPromoCodeInDB(code, tx.DB)
+		if err != nil {
+			return err
+		}
+		order, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckUnpaid(); err != nil {
+			return err
+		}
+		if order.Status == types.OrderCancelled {
+			return errors.New(messageErrors.OrderIsAlreadyCancelled)
+		}
+		if order.PromoCode != "" {
+			return errors.New(messageErrors.PromoCodeAlreadyApplied)
+		}
+		if err := promo.CheckApplicableTo(order, moment); err != nil {
+			return err
+		}
+		var totalUses, userUses int64
+		tx.DB.Model(&types.Order{}).Where("promo_code = ?", code).Count(&totalUses)
+		tx.DB.Model(&types.Order{}).Where("promo_code = ? AND user_id = ?", code, order.UserID).Count(&userUses)
+		if err := promo.CheckUsageLimits(uint(totalUses), uint(userUses)); err != nil {
+			return err
+		}
+		err = tx.DB.Model(&types.Order{}).Where("id = ?", idOrder).Update("promo_code", code).Error
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		err = updateOrderTotalsInDB(idOrder, tx.DB)
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		order, err = tx.GetOrderByID(ctx, idOrder)
+		if err != nil {
+			return err
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.PromoCodeAppliedEvent, order.UserID, map[string]any{"code": code, "discount": order.Discount}), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return types.Order{}, err
+	}
+	return order, nil
+}
+
+/******************/
+/***** ORDERS *****/
+/******************/
+
+func (dbStorage *DbStorage) CreateOrder(ctx context.Context, order *types.Order) error {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.First(&types.User{}, order.UserID).Error
+	if err != nil {
+		return errors.New(messageErrors.UserIDNotFound)
+	}
+	order.PaymentState = types.PaymentPending
+	order.Status = types.OrderDraft
+	order.PromoCode = ""
+	order.TotalCost = types.NewMoney(0, dbStorage.settings.DefaultCurrency())
+
+	// The order, its tubs, the stock they reserve and the events are saved in one transaction, so nothing is saved if any tub is rejected.
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		tubErrors := []types.TubError{}
+		for i := range order.IceCreamTubs {
+			tub := &order.IceCreamTubs[i]
+			var price types.IceCreamTubPrice
+			err := tub.Validate()
+			if err == nil {
+				price, err = priceOfInDB(tub, tx.DB)
+			}
+			if err == nil && price.Price.Currency != order.TotalCost.Currency {
+				err = errors.New(messageErrors.CurrencyMismatch)
+			}
+			if err == nil {
+				err = reserveStockInDB(tub.GramsPerFlavor(), tx.DB)
+			}
+			if err != nil {
+				tubErrors = append(tubErrors, types.TubError{Index: i, Error: err.Error()})
+				continue
+			}
+			tub.ID = 0
+			tub.UnitPrice = price.Price
+		}
+		if len(tubErrors) > 0 {
+			return &types.InvalidTubsError{Tubs: tubErrors}
+		}
+
+		order.ComputeTotals(nil)
+		if err := tx.DB.Create(order).Error; err != nil {
+			return errors.New(messageErrors.ErrorWhileProcessingRequest)
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(order.ID, types.OrderCreatedEvent, order.UserID, types.CreatedEventPayload(*order)), tx.DB); err != nil {
+			return err
+		}
+		for _, tub := range order.IceCreamTubs {
+			if err := recordOrderEventInDB(types.NewOrderEvent(order.ID, types.TubAddedEvent, order.UserID, types.TubEventPayload(tub)), tx.DB); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (dbStorage *DbStorage) GetOrderByID(ctx context.Context, idOrder uint) (types.Order, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	var order types.Order
+	err := db.Preload("IceCreamTubs").First(&order, idOrder).Error
 	if err != nil {
 		return types.Order{}, errors.New(messageErrors.OrderNotFound)
 	}
 	return order, nil
 }
 
-func (dbStorage *DbStorage) GetAllOrders() []types.Order {
-	var orders []types.Order
-	dbStorage.DB.Find(&orders)
+func (dbStorage *DbStorage) GetAllOrders(ctx context.Context) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
+	orders := []types.Order{}
+	db.Preload("IceCreamTubs").Order("id").Find(&orders)
+	return orders
+}
+
+func (dbStorage *DbStorage) GetOrdersByStatus(ctx context.Context, statuses ...string) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
+	orders := []types.Order{}
+	db.Preload("IceCreamTubs").Where("status IN ?", statuses).Order("id").Find(&orders)
+	return orders
+}
+
+func (dbStorage *DbStorage) GetOrdersByDeliveryDriverID(ctx context.Context, idUser uint) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
+	orders := []types.Order{}
+	db.Preload("IceCreamTubs").Where("delivery_driver_id = ?", idUser).Order("id").Find(&orders)
 	return orders
 }
 
-func (dbStorage *DbStorage) GetAllOrdersByUserEmail(email string) []types.Order {
+func (dbStorage *DbStorage) GetOrdersScheduledFor(ctx context.Context, date string) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
+	orders := []types.Order{}
+	day, err := time.ParseInLocation(types.DateLayout, date, time.Local)
+	if err != nil {
+		return orders
+	}
+	db.Preload("IceCreamTubs").
+		Where("delivery_window_start >= ? AND delivery_window_start < ? AND status <> ?", day, day.AddDate(0, 0, 1), types.OrderCancelled).
+		Order("delivery_window_start, id").Find(&orders)
+	return orders
+}
+
+func (dbStorage *DbStorage) GetAssignableOrders(ctx context.Context, moment time.Time) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
+	var candidates []types.Order
+	db.Preload("IceCreamTubs").
+		Where("delivery_driver_id = 0 AND status IN ?", types.AssignableOrderStatuses).
+		Order("id").Find(&candidates)
+	orders := []types.Order{}
+	for _, order := range candidates {
+		if order.IsAssignableAt(moment, dbStorage.settings.DriverNotice) {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+func (dbStorage *DbStorage) GetAllOrdersByUserEmail(ctx context.Context, email string) []types.Order {
+	db := dbStorage.DB.WithContext(ctx)
 	var user types.User
-	dbStorage.DB.Preload("Orders").Where("email = ?", email).First(&user)
+	db.Preload("Orders", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Orders.IceCreamTubs").Where("email = ?", email).First(&user)
 	return user.Orders
 }
 
-func (dbStorage *DbStorage) GetUserOrderByID(idOrder uint, idUser uint) (types.Order, error) {
-	err := dbStorage.DB.First(&types.User{}, idUser).Error
+func (dbStorage *DbStorage) GetUserOrderByID(ctx context.Context, idOrder uint, idUser uint) (types.Order, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.First(&types.User{}, idUser).Error
 	if err != nil {
 		return types.Order{}, errors.New(messageErrors.UserIDNotFound)
 	}
-	order, err := dbStorage.GetOrderByID(idOrder)
+	order, err := dbStorage.GetOrderByID(ctx, idOrder)
 	if err != nil {
 		return types.Order{}, errors.New(messageErrors.OrderNotFound)
 	}
@@ -149,206 +569,475 @@ func (dbStorage *DbStorage) GetUserOrderByID(idOrder uint, idUser uint) (types.O
 	return order, nil
 }
 
-func (dbStorage *DbStorage) UpdateOrderByID(idOrder uint, order *types.Order) (types.Order, error) {
-	oldPedido, err := dbStorage.GetOrderByID(idOrder)
+func (dbStorage *DbStorage) UpdateOrderByID(ctx context.Context, idOrder uint, order *types.Order) (types.Order, error) {
+	var oldPedido types.Order
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var err error
+		oldPedido, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if oldPedido.UserID != order.UserID {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if err := oldPedido.CheckDeliveryEditable(); err != nil {
+			return err
+		}
+		previousAddress, previousWindow := oldPedido.Address, oldPedido.DeliveryWindow
+		oldPedido.Address = order.Address
+		oldPedido.DeliveryWindow = order.DeliveryWindow
+		// The columns are selected so a removed window is saved too, after BeforeSave splits the window into them.
+		err = tx.DB.Model(&oldPedido).Select("address", "delivery_window_start", "delivery_window_end").Updates(&oldPedido).Error
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if previousAddress != order.Address {
+			if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.AddressChangedEvent, oldPedido.UserID, map[string]any{"from": previousAddress, "to": order.Address}), tx.DB); err != nil {
+				return err
+			}
+		}
+		if !types.SameDeliveryWindow(previousWindow, order.DeliveryWindow) {
+			if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.WindowChangedEvent, oldPedido.UserID, map[string]any{"from": previousWindow, "to": order.DeliveryWindow}), tx.DB); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return types.Order{}, errors.New(messageErrors.OrderNotFound)
-	}
-	if oldPedido.UserID != order.UserID {
-		return types.Order{}, errors.New(messageErrors.OrderNotFound)
+		return types.Order{}, err
 	}
-	oldPedido.PaymentState = order.PaymentState
-	oldPedido.Address = order.Address
-	err = dbStorage.DB.Save(&oldPedido).Error
-	if err != nil {
-		return types.Order{}, errors.New(messageErrors.OrderNotFound)
-	}
-	oldPedido.Address = order.Address
 	return oldPedido, nil
 }
 
-func (dbStorage *DbStorage) GetIceCreamTubsByOrderID(idOrder uint) ([]types.IceCreamTub, error) {
-	err := dbStorage.DB.First(&types.Order{}, idOrder).Error
-	if err != nil {
-		return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
-	}
-	var tubs []types.IceCreamTub
-	err = dbStorage.DB.Find(&tubs, "order_id = ?", idOrder).Error
+func (dbStorage *DbStorage) StartOrderPayment(ctx context.Context, idOrder uint) (types.Order, error) {
+	var order types.Order
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var err error
+		order, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckPayable(); err != nil {
+			return err
+		}
+		// The payment state is part of the condition, so the payment of an order cannot start twice.
+		res := tx.DB.Model(&types.Order{}).Where("id = ? AND payment_state = ?", idOrder, types.PaymentPending).
+			Update("payment_state", types.PaymentProcessing)
+		if res.Error != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New(messageErrors.OrderIsBeingPaid)
+		}
+		order.PaymentState = types.PaymentProcessing
+		return nil
+	})
 	if err != nil {
-		return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
+		return types.Order{}, err
 	}
-	return tubs, nil
+	return order, nil
 }
 
-func (dbStorage *DbStorage) AddIceCreamTubByOrderID(orderID uint, tub *types.IceCreamTub) error {
-	if ok := areFlavorIDsRegisteredInDB(tub.Flavors, dbStorage.DB); !ok {
-		return errors.New(messageErrors.NonExistingFlavors)
-	}
+func (dbStorage *DbStorage) AbortOrderPayment(ctx context.Context, idOrder uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		order, err := lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if order.PaymentState != types.PaymentProcessing {
+			return nil
+		}
+		return tx.DB.Model(&types.Order{}).Where("id = ?", idOrder).Update("payment_state", types.PaymentPending).Error
+	})
+}
 
-	var price uint
-	err := dbStorage.DB.Model(&types.IceCreamTubPrice{}).Select("price").Where("weight=?", tub.Weight).First(&price).Error
-	if err != nil {
-		return errors.New(messageErrors.WeightNotAvailable)
-	}
+func (dbStorage *DbStorage) MarkOrderAsPaid(ctx context.Context, idOrder uint, paymentReference string, amount types.Money) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		order, err := lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckPaidWith(amount); err != nil {
+			return err
+		}
+		// The payment state is part of the condition, so an order cannot be marked as paid twice.
+		res := tx.DB.Model(&types.Order{}).Where("id = ? AND payment_state = ?", idOrder, types.PaymentProcessing).
+			Updates(map[string]any{"payment_state": types.PaymentPaid, "payment_reference": paymentReference})
+		if res.Error != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New(messageErrors.OrderIsAlreadyPaid)
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.OrderPaidEvent, order.UserID, map[string]any{"amount": order.TotalCost}), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
+}
 
-	order, err := dbStorage.GetOrderByID(orderID)
+func (dbStorage *DbStorage) CancelOrder(ctx context.Context, idOrder uint, cancellation types.OrderCancellation) (types.Order, string, error) {
+	var order types.Order
+	var previousPaymentState string
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var err error
+		order, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckCancellable(cancellation); err != nil {
+			return err
+		}
+		releasesStock := order.ReleasesStock()
+		previousStatus := order.Status
+		previousPaymentState = order.PaymentState
+		deliveryDriverID := order.DeliveryDriverID
+		order.Cancel(cancellation)
+		// The previous status is part of the condition, so an order cannot be cancelled twice by concurrent requests.
+		res := tx.DB.Model(&types.Order{}).Where("id = ? AND status = ?", idOrder, previousStatus).Updates(map[string]any{
+			"status":              order.Status,
+			"cancelled_by":        order.CancelledBy,
+			"cancellation_reason": order.CancellationReason,
+			"delivery_driver_id":  order.DeliveryDriverID,
+			"payment_state":       order.PaymentState,
+		})
+		if res.Error != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New(messageErrors.OrderIsAlreadyCancelled)
+		}
+		if releasesStock {
+			for _, tub := range order.IceCreamTubs {
+				if err := releaseStockInDB(tub.GramsPerFlavor(), tx.DB); err != nil {
+					return err
+				}
+			}
+		}
+		if deliveryDriverID != 0 {
+			if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.DriverUnassignedEvent, cancellation.CancelledBy, map[string]any{"deliveryDriverID": deliveryDriverID}), tx.DB); err != nil {
+				return err
+			}
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.OrderCancelledEvent, cancellation.CancelledBy, map[string]any{"reason": order.CancellationReason, "refundPending": order.PaymentState == types.PaymentRefundPending}), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
+		return types.Order{}, "", err
 	}
+	return order, previousPaymentState, nil
+}
 
-	tub.OrderID = order.ID
-	order.TotalCost += price
-	err = dbStorage.DB.Create(&tub).Error
+func (dbStorage *DbStorage) MarkOrderAsRefunded(ctx context.Context, idOrder uint, refundReference string, actorID uint) (types.Order, error) {
+	var order types.Order
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var err error
+		order, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if order.PaymentState != types.PaymentRefundPending {
+			return errors.New(messageErrors.OrderHasNoPendingRefund)
+		}
+		err = tx.DB.Model(&types.Order{}).Where("id = ?", idOrder).Update("payment_state", types.PaymentRefunded).Error
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		order.PaymentState = types.PaymentRefunded
+		return recordOrderEventInDB(types.NewOrderEvent(idOrder, types.OrderRefundedEvent, actorID, map[string]any{"amount": order.TotalCost, "refundReference": refundReference}), tx.DB)
+	})
 	if err != nil {
-		return errors.New("Couldn't create IceCreamTub")
+		return types.Order{}, err
 	}
+	return order, nil
+}
 
-	err = dbStorage.DB.Model(&types.Order{}).Where("id=?", order.ID).Update("total_cost", order.TotalCost).Error
+func (dbStorage *DbStorage) UpdateOrderStatus(ctx context.Context, idOrder uint, status string, actorID uint) (types.Order, error) {
+	var order types.Order
+	err := dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var err error
+		order, err = lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckStatusTransition(status); err != nil {
+			return err
+		}
+		if status == types.OrderPlaced && len(order.IceCreamTubs) == 0 {
+			return errors.New(messageErrors.OrderHasNoIceCreamTubs)
+		}
+		// The current status is part of the condition, so concurrent requests cannot apply two transitions from the same status.
+		res := tx.DB.Model(&types.Order{}).Where("id = ? AND status = ?", idOrder, order.Status).Update("status", status)
+		if res.Error != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if res.RowsAffected == 0 {
+			return errors.New(messageErrors.IllegalOrderStatusTransition)
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.StatusChangedEvent, actorID, map[string]any{"from": order.Status, "to": status}), tx.DB); err != nil {
+			return err
+		}
+		order.Status = status
+		return nil
+	})
 	if err != nil {
-		return errors.New("Couldn't update IceCreamTub")
+		return types.Order{}, err
 	}
-	return nil
+	return order, nil
 }
 
-func (dbStorage *DbStorage) DeleteIceCreamTubByOrderID(idTub uint, idOrder uint) error {
-	var order types.Order
-	err := dbStorage.DB.First(&order, idOrder).Error
+func (dbStorage *DbStorage) GetOrderHistory(ctx context.Context, idOrder uint) ([]types.OrderEvent, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.First(&types.Order{}, idOrder).Error
 	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
+		return []types.OrderEvent{}, errors.New(messageErrors.OrderNotFound)
 	}
-	var tub types.IceCreamTub
-	err = dbStorage.DB.First(&tub, idTub).Error
+	events := []types.OrderEvent{}
+	db.Where("order_id = ?", idOrder).Order("created_at, id").Find(&events)
+	return events, nil
+}
+
+func (dbStorage *DbStorage) GetIceCreamTubsByOrderID(ctx context.Context, idOrder uint) ([]types.IceCreamTub, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	err := db.First(&types.Order{}, idOrder).Error
 	if err != nil {
-		return errors.New(messageErrors.IceCreamTubNotFound)
-	}
-	if tub.OrderID != idOrder {
-		return errors.New(messageErrors.IceCreamTubNotFound)
-	}
-	var price uint
-	dbStorage.DB.Model(&types.IceCreamTubPrice{}).Select("price").Where("weight=?", tub.Weight).First(&price)
-	result := dbStorage.DB.Delete(&types.IceCreamTub{}, idTub)
-	if result.RowsAffected == 0 {
-		return errors.New(messageErrors.IceCreamTubNotFound)
+		return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
 	}
-	order.TotalCost -= price
-	err = dbStorage.DB.Model(&types.Order{}).Where("id=?", order.ID).Update("total_cost", order.TotalCost).Error
+	var tubs []types.IceCreamTub
+	err = db.Find(&tubs, "order_id = ?", idOrder).Error
 	if err != nil {
-		return errors.New("Couldn't update IceCreamTub")
+		return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
 	}
-	return nil
+	return tubs, nil
+}
+
+func (dbStorage *DbStorage) AddIceCreamTubByOrderID(ctx context.Context, orderID uint, tub *types.IceCreamTub) error {
+	// The order row stays locked until the tub is saved and the totals are updated,
+	// so tubs added at the same time to the same order cannot overwrite each other's totals.
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		price, err := priceOfInDB(tub, tx.DB)
+		if err != nil {
+			return err
+		}
+
+		order, err := lockOrderInDB(orderID, tx.DB)
+		if err != nil {
+			return err
+		}
+
+		if err := order.CheckUnpaid(); err != nil {
+			return err
+		}
+		if !order.IsDraft() {
+			return errors.New(messageErrors.OrderIsNotADraft)
+		}
+
+		if price.Price.Currency != order.TotalCost.Currency {
+			return errors.New(messageErrors.CurrencyMismatch)
+		}
+
+		err = reserveStockInDB(tub.GramsPerFlavor(), tx.DB)
+		if err != nil {
+			return err
+		}
+
+		tub.OrderID = order.ID
+		tub.UnitPrice = price.Price
+		err = tx.DB.Create(&tub).Error
+		if err != nil {
+			return errors.New("Couldn't create IceCreamTub")
+		}
+
+		err = updateOrderTotalsInDB(order.ID, tx.DB)
+		if err != nil {
+			return errors.New("Couldn't update IceCreamTub")
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(order.ID, types.TubAddedEvent, order.UserID, types.TubEventPayload(*tub)), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (dbStorage *DbStorage) DeleteIceCreamTubByOrderID(ctx context.Context, idTub uint, idOrder uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		order, err := lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := order.CheckUnpaid(); err != nil {
+			return err
+		}
+		if !order.IsDraft() {
+			return errors.New(messageErrors.OrderIsNotADraft)
+		}
+		var tub types.IceCreamTub
+		err = tx.DB.First(&tub, idTub).Error
+		if err != nil {
+			return errors.New(messageErrors.IceCreamTubNotFound)
+		}
+		if tub.OrderID != idOrder {
+			return errors.New(messageErrors.IceCreamTubNotFound)
+		}
+		result := tx.DB.Delete(&types.IceCreamTub{}, idTub)
+		if result.RowsAffected == 0 {
+			return errors.New(messageErrors.IceCreamTubNotFound)
+		}
+		if err := releaseStockInDB(tub.GramsPerFlavor(), tx.DB); err != nil {
+			return err
+		}
+		err = updateOrderTotalsInDB(order.ID, tx.DB)
+		if err != nil {
+			return errors.New("Couldn't update IceCreamTub")
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(order.ID, types.TubRemovedEvent, order.UserID, types.TubEventPayload(tub)), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
 /****************************/
 /***** DELIVERY DRIVERS *****/
 /****************************/
 
-func (dbStorage *DbStorage) GetDeliveryDrivers() []types.DeliveryDriver {
+func (dbStorage *DbStorage) GetDeliveryDrivers(ctx context.Context) []types.DeliveryDriver {
+	db := dbStorage.DB.WithContext(ctx)
 	var deliveryDrivers []types.DeliveryDriver
-	dbStorage.DB.Find(&deliveryDrivers)
+	db.Find(&deliveryDrivers)
 	return deliveryDrivers
 }
 
-func (dbStorage *DbStorage) GetDeliveryDriverByID(idUser uint) (types.DeliveryDriver, error) {
+func (dbStorage *DbStorage) GetDeliveryDriverByID(ctx context.Context, idUser uint) (types.DeliveryDriver, error) {
+	db := dbStorage.DB.WithContext(ctx)
 	var deliveryDriver types.DeliveryDriver
-	err := dbStorage.DB.First(&deliveryDriver, "user_id = ?", idUser).Error
+	err := db.First(&deliveryDriver, "user_id = ?", idUser).Error
 	if err != nil {
 		return types.DeliveryDriver{}, errors.New(messageErrors.DeliveryDriverNotFound)
 	}
 	return deliveryDriver, nil
 }
 
-func (dbStorage *DbStorage) UpdateDeliveryDriverByID(idUser uint, deliveryDriver *types.DeliveryDriver) error {
+func (dbStorage *DbStorage) UpdateDeliveryDriverByID(ctx context.Context, idUser uint, deliveryDriver *types.DeliveryDriver) error {
+	db := dbStorage.DB.WithContext(ctx)
 	deliveryDriver.UserID = idUser
-	res := dbStorage.DB.Model(&deliveryDriver).Where("user_id=?", idUser).Updates(deliveryDriver)
+	res := db.Model(&deliveryDriver).Where("user_id=?", idUser).Updates(deliveryDriver)
 	if res.Error != nil || res.RowsAffected == 0 {
 		return errors.New(messageErrors.DeliveryDriverNotFound)
 	}
 	return nil
 }
 
-func (dbStorage *DbStorage) DeleteDeliveryDriverByID(idUser uint) error {
-	res := dbStorage.DB.Delete(&types.DeliveryDriver{}, "user_id=?", idUser)
-	if res.RowsAffected == 0 {
-		return errors.New(messageErrors.DeliveryDriverNotFound)
-	}
-	var user types.User
-	err := dbStorage.DB.First(&user, idUser).Error
-	if err != nil {
-		return errors.New(messageErrors.UserIDNotFound)
-	}
+func (dbStorage *DbStorage) DeleteDeliveryDriverByID(ctx context.Context, idUser uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		res := tx.DB.Delete(&types.DeliveryDriver{}, "user_id=?", idUser)
+		if res.RowsAffected == 0 {
+			return errors.New(messageErrors.DeliveryDriverNotFound)
+		}
+		var user types.User
+		err := tx.DB.First(&user, idUser).Error
+		if err != nil {
+			return errors.New(messageErrors.UserIDNotFound)
+		}
 
-	user.Permissions = utils.DeletePermission(user.Permissions, "delivery")
-	err = dbStorage.DB.Save(&user).Error
-	if err != nil {
-		return errors.New("Couldn't update user")
-	}
+		user.Permissions = utils.DeletePermission(user.Permissions, "delivery")
+		err = tx.DB.Save(&user).Error
+		if err != nil {
+			return errors.New("Couldn't update user")
+		}
 
-	return nil
+		return nil
+	})
 }
 
-func (dbStorage *DbStorage) GetVehiclesByDeliveryDriverID(idUser uint) ([]string, error) {
+func (dbStorage *DbStorage) GetVehiclesByDeliveryDriverID(ctx context.Context, idUser uint) ([]string, error) {
+	db := dbStorage.DB.WithContext(ctx)
 	var deliveryDriver types.DeliveryDriver
-	err := dbStorage.DB.First(&deliveryDriver, "user_id=?", idUser).Error
+	err := db.First(&deliveryDriver, "user_id=?", idUser).Error
 	if err != nil {
 		return []string{}, errors.New(messageErrors.DeliveryDriverNotFound)
 	}
 	return deliveryDriver.Vehicles, nil
 }
 
-func (dbStorage *DbStorage) AddDeliveryDriver(deliveryDriver *types.DeliveryDriver) error {
-	var user types.User
-	err := dbStorage.DB.First(&user, deliveryDriver.UserID).Error
-	if err != nil {
-		return errors.New(messageErrors.UserIDNotFound)
-	}
+func (dbStorage *DbStorage) AddDeliveryDriver(ctx context.Context, deliveryDriver *types.DeliveryDriver) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		var user types.User
+		err := tx.DB.First(&user, deliveryDriver.UserID).Error
+		if err != nil {
+			return errors.New(messageErrors.UserIDNotFound)
+		}
 
-	if user.IsDeliveryDriver() {
-		return errors.New(messageErrors.UserIsAlreadyADriver)
-	}
-	dbStorage.DB.Create(&deliveryDriver)
+		if user.IsDeliveryDriver() {
+			return errors.New(messageErrors.UserIsAlreadyADriver)
+		}
+		err = tx.DB.Create(&deliveryDriver).Error
+		if err != nil {
+			return errors.New(messageErrors.ErrorWhileProcessingRequest)
+		}
 
-	user.Permissions = append(user.Permissions, "delivery")
-	err = dbStorage.DB.Save(&user).Error
-	if err != nil {
-		return errors.New("Couldn't update user")
-	}
+		user.Permissions = append(user.Permissions, "delivery")
+		err = tx.DB.Save(&user).Error
+		if err != nil {
+			return errors.New("Couldn't update user")
+		}
 
-	return nil
+		return nil
+	})
 }
 
-func (dbStorage *DbStorage) AssignDeliveryDriverToOrder(idOrder uint, idDeliveryDriver uint) error {
-	oldOrder, err := dbStorage.GetOrderByID(idOrder)
-	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
-	}
+func (dbStorage *DbStorage) AssignDeliveryDriverToOrder(ctx context.Context, idOrder uint, idDeliveryDriver uint, idActor uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		oldOrder, err := lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if err := oldOrder.CheckDriverAssignable(); err != nil {
+			return err
+		}
 
-	err = dbStorage.DB.First(&types.DeliveryDriver{}, "user_id=?", idDeliveryDriver).Error
-	if err != nil {
-		return errors.New(messageErrors.DeliveryDriverNotFound)
-	}
+		err = tx.DB.First(&types.DeliveryDriver{}, "user_id=?", idDeliveryDriver).Error
+		if err != nil {
+			return errors.New(messageErrors.DeliveryDriverNotFound)
+		}
 
-	err = dbStorage.DB.Model(&oldOrder).Update("DeliveryDriverID", idDeliveryDriver).Error
-	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
-	}
+		err = tx.DB.Model(&oldOrder).Update("DeliveryDriverID", idDeliveryDriver).Error
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
 
-	return nil
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.DriverAssignedEvent, idActor, map[string]any{"deliveryDriverID": idDeliveryDriver}), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
-func (dbStorage *DbStorage) DeleteDeliveryDriverFromOrder(idOrder uint) error {
-	oldOrder, err := dbStorage.GetOrderByID(idOrder)
-	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
-	}
-	err = dbStorage.DB.Model(&oldOrder).Update("DeliveryDriverID", uint(0)).Error
-	if err != nil {
-		return errors.New(messageErrors.OrderNotFound)
-	}
-	return nil
+func (dbStorage *DbStorage) DeleteDeliveryDriverFromOrder(ctx context.Context, idOrder uint, idActor uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		oldOrder, err := lockOrderInDB(idOrder, tx.DB)
+		if err != nil {
+			return err
+		}
+		if oldOrder.DeliveryDriverID == 0 {
+			return nil
+		}
+		deliveryDriverID := oldOrder.DeliveryDriverID
+		err = tx.DB.Model(&oldOrder).Update("DeliveryDriverID", uint(0)).Error
+		if err != nil {
+			return errors.New(messageErrors.OrderNotFound)
+		}
+		if err := recordOrderEventInDB(types.NewOrderEvent(idOrder, types.DriverUnassignedEvent, idActor, map[string]any{"deliveryDriverID": deliveryDriverID}), tx.DB); err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
-func (dbStorage *DbStorage) GetDeliveryDriverFromOrder(idOrder uint) (uint, error) {
-	order, err := dbStorage.GetOrderByID(idOrder)
+func (dbStorage *DbStorage) GetDeliveryDriverFromOrder(ctx context.Context, idOrder uint) (uint, error) {
+	order, err := dbStorage.GetOrderByID(ctx, idOrder)
 	if err != nil {
 		return 0, errors.New(messageErrors.OrderNotFound)
 	}
@@ -359,22 +1048,23 @@ func (dbStorage *DbStorage) GetDeliveryDriverFromOrder(idOrder uint) (uint, erro
 /***** USERS *****/
 /*****************/
 
-func (dbStorage *DbStorage) SignUpUser(newUser *types.User) error {
+func (dbStorage *DbStorage) SignUpUser(ctx context.Context, newUser *types.User) error {
+	db := dbStorage.DB.WithContext(ctx)
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), 10)
 	if err != nil {
 		return errors.New(messageErrors.ErrorWhileProcessingRequest)
 	}
 
 	newUser.Password = string(hashedPassword)
-	err = dbStorage.DB.Create(&newUser).Error
+	err = db.Create(&newUser).Error
 	if err != nil {
 		return errors.New(messageErrors.EmailAlreadyExists)
 	}
 	return nil
 }
 
-func (dbStorage *DbStorage) LogInUser(email string, password string) error {
-	user, err := dbStorage.GetUserByEmail(email)
+func (dbStorage *DbStorage) LogInUser(ctx context.Context, email string, password string) error {
+	user, err := dbStorage.GetUserByEmail(ctx, email)
 	if err != nil {
 		return errors.New(messageErrors.InvalidEmailOrPassword)
 	}
@@ -385,55 +1075,83 @@ func (dbStorage *DbStorage) LogInUser(email string, password string) error {
 	return nil
 }
 
-func (dbStorage *DbStorage) GetUserByEmail(email string) (types.User, error) {
+func (dbStorage *DbStorage) GetUserByEmail(ctx context.Context, email string) (types.User, error) {
+	db := dbStorage.DB.WithContext(ctx)
 	var user types.User
-	err := dbStorage.DB.Model(&user).Where("Email=?", email).First(&user).Error
+	err := db.Model(&user).Where("Email=?", email).First(&user).Error
 	if err != nil {
 		return user, errors.New(messageErrors.UserEmailNotFound)
 	}
 	return user, nil
 }
 
-func (dbStorage *DbStorage) GetAllUsers() []types.User {
+func (dbStorage *DbStorage) GetAllUsers(ctx context.Context) []types.User {
+	db := dbStorage.DB.WithContext(ctx)
 	var users []types.User
-	dbStorage.DB.Find(&users)
+	db.Find(&users)
 	return users
 }
 
-func (dbStorage *DbStorage) GetUserByID(idUser uint) (types.User, error) {
+func (dbStorage *DbStorage) GetUserByID(ctx context.Context, idUser uint) (types.User, error) {
+	db := dbStorage.DB.WithContext(ctx)
 	var user types.User
-	err := dbStorage.DB.Preload("Orders").First(&user, idUser).Error
+	err := db.Preload("Orders").First(&user, idUser).Error
 	if err != nil {
 		return user, errors.New(messageErrors.UserIDNotFound)
 	}
 	return user, nil
 }
 
-func (dbStorage *DbStorage) DeleteUserByID(idUser uint) error {
-	result := dbStorage.DB.Delete(&types.User{}, "ID=?", idUser)
-	if result.RowsAffected == 0 {
-		return errors.New(messageErrors.UserIDNotFound)
-	}
-	return nil
+func (dbStorage *DbStorage) DeleteUserByID(ctx context.Context, idUser uint) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		// The delivery driver of the user is deleted with them
+		if err := tx.DB.Delete(&types.DeliveryDriver{}, "user_id=?", idUser).Error; err != nil {
+			return err
+		}
+		result := tx.DB.Delete(&types.User{}, "ID=?", idUser)
+		if result.RowsAffected == 0 {
+			return errors.New(messageErrors.UserIDNotFound)
+		}
+		return nil
+	})
 }
 
-func (dbStorage *DbStorage) UpdateUser(updatedUser types.User) (types.User, error) {
-	oldUser, err := dbStorage.GetUserByID(updatedUser.ID)
+func (dbStorage *DbStorage) UpdateUser(ctx context.Context, updatedUser types.User) (types.User, error) {
+	db := dbStorage.DB.WithContext(ctx)
+	oldUser, err := dbStorage.GetUserByID(ctx, updatedUser.ID)
 	if err != nil {
 		return updatedUser, errors.New(messageErrors.UserIDNotFound)
 	}
 	oldUser.Email = updatedUser.Email
 	oldUser.Name = updatedUser.Name
 	oldUser.LastName = updatedUser.LastName
-	err = dbStorage.DB.Save(&oldUser).Error
+	err = db.Save(&oldUser).Error
 	if err != nil {
 		return updatedUser, errors.New(messageErrors.UserIDNotFound)
 	}
 	return oldUser, nil
 }
 
-func (dbStorage *DbStorage) PromoteUserToAdmin(idUser uint) error {
-	user, err := dbStorage.GetUserByID(idUser)
+func (dbStorage *DbStorage) UpdateUserPassword(ctx context.Context, userID uint, password string) error {
+	db := dbStorage.DB.WithContext(ctx)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return errors.New(messageErrors.ErrorWhileProcessingRequest)
+	}
+
+	result := db.Model(&types.User{}).Where("id = ?", userID).Update("password", string(hashedPassword))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(messageErrors.UserIDNotFound)
+	}
+	return nil
+}
+
+func (dbStorage *DbStorage) PromoteUserToAdmin(ctx context.Context, idUser uint) error {
+	db := dbStorage.DB.WithContext(ctx)
+	user, err := dbStorage.GetUserByID(ctx, idUser)
 	if err != nil {
 		return errors.New(messageErrors.UserIDNotFound)
 	}
@@ -441,22 +1159,50 @@ func (dbStorage *DbStorage) PromoteUserToAdmin(idUser uint) error {
 		return errors.New(messageErrors.UserIsAlreadyAnAdmin)
 	}
 	user.Permissions = append(user.Permissions, "admin")
-	err = dbStorage.DB.Save(&user).Error
+	err = db.Save(&user).Error
 	if err != nil {
 		return errors.New("User Not Found")
 	}
 	return nil
 }
 
+func (dbStorage *DbStorage) PromoteUserToStaff(ctx context.Context, idUser uint) error {
+	db := dbStorage.DB.WithContext(ctx)
+	user, err := dbStorage.GetUserByID(ctx, idUser)
+	if err != nil {
+		return errors.New(messageErrors.UserIDNotFound)
+	}
+	if user.IsStaff() {
+		return errors.New(messageErrors.UserIsAlreadyStaff)
+	}
+	user.Permissions = append(user.Permissions, "staff")
+	err = db.Save(&user).Error
+	if err != nil {
+		return errors.New(messageErrors.UserIDNotFound)
+	}
+	return nil
+}
+
 // Others
 
-func (dbStorage *DbStorage) CleanDB() error {
-	if os.Getenv("API_ENV") == "testing" {
-		return dbStorage.DB.Exec(
-			"TRUNCATE TABLE users, delivery_drivers, orders, flavors, ice_cream_tubs, ice_cream_tub_prices RESTART IDENTITY CASCADE",
+func (dbStorage *DbStorage) CleanDB(ctx context.Context) error {
+	db := dbStorage.DB.WithContext(ctx)
+	if dbStorage.testing {
+		if db.Dialector.Name() == "sqlite" {
+			return cleanSQLite(db)
+		}
+		return db.Exec(
+			"TRUNCATE TABLE users, delivery_drivers, orders, promo_codes, order_events, flavor_categories, flavors, ice_cream_tubs, ice_cream_tub_prices RESTART IDENTITY CASCADE",
 		).Error
 	}
-	return errors.New("API_ENV must be set to testing in order to completely clean DB")
+	return errors.New("the mode must be testing in order to completely clean DB")
+}
+
+// WithinTransaction runs fn in a database transaction, which is committed if fn returns nil and rolled back otherwise.
+func (dbStorage *DbStorage) WithinTransaction(ctx context.Context, fn func(store Storage) error) error {
+	return dbStorage.inTransaction(ctx, func(tx *DbStorage) error {
+		return fn(tx)
+	})
 }
 
 func (dbStorage *DbStorage) Close() error {
@@ -469,9 +1215,10 @@ func (dbStorage *DbStorage) Close() error {
 
 // Auxiliary functions
 
+// isFlavorIDRegisteredInDB checks if a flavor exists and is not retired.
 func isFlavorIDRegisteredInDB(flavorID string, db *gorm.DB) bool {
 	var flavor types.Flavor
-	err := db.First(&flavor, "ID=?", flavorID).Error
+	err := db.First(&flavor, "ID=? AND retired=?", flavorID, false).Error
 	if err != nil {
 		return false
 	}
@@ -486,3 +1233,132 @@ func areFlavorIDsRegisteredInDB(flavorIDs []string, db *gorm.DB) bool {
 	}
 	return true
 }
+
+// areFlavorsAvailableInDB checks if all flavors can be sold at a given moment.
+func areFlavorsAvailableInDB(flavorIDs []string, db *gorm.DB, moment time.Time) bool {
+	var flavors []types.Flavor
+	db.Where("id IN ?", flavorIDs).Find(&flavors)
+	for _, flavor := range flavors {
+		if !flavor.IsAvailableAt(moment) {
+			return false
+		}
+	}
+	return true
+}
+
+// priceOfInDB checks that the flavors of a tub can be sold now and obtains the current price of its size.
+func priceOfInDB(tub *types.IceCreamTub, db *gorm.DB) (types.IceCreamTubPrice, error) {
+	if ok := areFlavorIDsRegisteredInDB(tub.Flavors, db); !ok {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.NonExistingFlavors)
+	}
+
+	if ok := areFlavorsAvailableInDB(tub.Flavors, db, time.Now()); !ok {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.UnavailableFlavors)
+	}
+
+	var price types.IceCreamTubPrice
+	if err := db.First(&price, "weight = ?", tub.Weight).Error; err != nil {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.WeightNotAvailable)
+	}
+
+	if uint(len(tub.Flavors)) > price.MaxFlavors {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.InvalidAmountOfFlavors)
+	}
+	return price, nil
+}
+
+// reserveStockInDB subtracts the grams needed from each flavor.
+// Each update only succeeds if the flavor has enough stock. If one fails, the previous ones are released.
+func reserveStockInDB(grams map[string]uint, db *gorm.DB) error {
+	reserved := make(map[string]uint)
+	for flavorID, needed := range grams {
+		res := db.Model(&types.Flavor{}).
+			Where("id = ? AND stock >= ?", flavorID, needed).
+			Update("stock", gorm.Expr("stock - ?", needed))
+		if res.Error != nil || res.RowsAffected == 0 {
+			if err := releaseStockInDB(reserved, db); err != nil {
+				return err
+			}
+			return errors.New(messageErrors.OutOfStockFlavors)
+		}
+		reserved[flavorID] = needed
+	}
+	return nil
+}
+
+// inTransaction runs fn with a storage bound to a new transaction. Transactions started inside another one become savepoints of it.
+func (dbStorage *DbStorage) inTransaction(ctx context.Context, fn func(tx *DbStorage) error) error {
+	return dbStorage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&DbStorage{DB: tx, testing: dbStorage.testing, settings: dbStorage.settings})
+	})
+}
+
+// lockOrderInDB obtains an order with its tubs and locks its row until the transaction of db ends,
+// so concurrent changes to the same order are applied one after another.
+func lockOrderInDB(idOrder uint, db *gorm.DB) (types.Order, error) {
+	var order types.Order
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("IceCreamTubs").First(&order, idOrder).Error
+	if err != nil {
+		return types.Order{}, errors.New(messageErrors.OrderNotFound)
+	}
+	return order, nil
+}
+
+// lockPromoCodeInDB obtains a promo code and locks its row until the transaction of db ends.
+func lockPromoCodeInDB(code string, db *gorm.DB) (types.PromoCode, error) {
+	var promo types.PromoCode
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, "code = ?", code).Error
+	if err != nil {
+		return types.PromoCode{}, errors.New(messageErrors.PromoCodeNotFound)
+	}
+	return promo, nil
+}
+
+// recordOrderEventInDB appends an event to the history of an order.
+// Its error must be returned to the transaction, so a change is never saved without its event.
+func recordOrderEventInDB(event types.OrderEvent, db *gorm.DB) error {
+	if err := db.Create(&event).Error; err != nil {
+		return errors.New(messageErrors.ErrorWhileProcessingRequest)
+	}
+	return nil
+}
+
+// releaseStockInDB gives back the grams reserved to each flavor.
+func releaseStockInDB(grams map[string]uint, db *gorm.DB) error {
+	for flavorID, reserved := range grams {
+		err := db.Model(&types.Flavor{}).Where("id = ?", flavorID).Update("stock", gorm.Expr("stock + ?", reserved)).Error
+		if err != nil {
+			return errors.New(messageErrors.ErrorWhileProcessingRequest)
+		}
+	}
+	return nil
+}
+
+// createMissing inserts the rows whose keys are not in the database yet.
+func createMissing[T any](db *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// updateOrderTotalsInDB recomputes the subtotal, discount and total cost of an order
+// from the unit prices of its tubs and the promo code applied to it, if any.
+func updateOrderTotalsInDB(orderID uint, db *gorm.DB) error {
+	var order types.Order
+	err := db.Preload("IceCreamTubs").First(&order, orderID).Error
+	if err != nil {
+		return err
+	}
+	var promo *types.PromoCode
+	if order.PromoCode != "" {
+		var applied types.PromoCode
+		if db.First(&applied, "code = ?", order.PromoCode).Error == nil {
+			promo = &applied
+		}
+	}
+	order.ComputeTotals(promo)
+	return db.Model(&types.Order{}).Where("id = ?", orderID).
+		Select("subtotal_amount", "subtotal_currency", "discount_amount", "discount_currency", "total_cost_amount", "total_cost_currency").
+		Updates(&order).Error
+}
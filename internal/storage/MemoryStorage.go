@@ -1,148 +1,538 @@
 package storage
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"golang.org/x/crypto/bcrypt"
 	"icecreamshop/internal/messageErrors"
 	"icecreamshop/internal/types"
 	"icecreamshop/internal/utils"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 )
 
+// Memory keeps all the data in memory. It is safe for concurrent use: methods that only read the data
+// share a lock, while methods that change it hold the lock alone. Data is copied in and out of it,
+// so callers cannot change it through the slices they pass or obtain.
 type Memory struct {
+	*memoryData
+	settings types.StoreSettings
+	mu       sync.RWMutex
+	locked   bool // set in the memory that units of work receive, since they already hold the lock
+}
+
+// memoryData is the data kept by a Memory, apart from its lock, so units of work can share it.
+type memoryData struct {
+	Categories      []types.FlavorCategory
 	Flavors         []types.Flavor
 	Users           []types.User
 	DeliveryDrivers []types.DeliveryDriver
 	Orders          []types.Order
-	Prices          map[uint]uint
+	Prices          []types.IceCreamTubPrice
+	PromoCodes      []types.PromoCode
+	OrderEvents     []types.OrderEvent
 	idOrders        uint
 	idUsers         uint
 	idTubs          uint
+	idOrderEvents   uint
 }
 
-func NewMemoryStorage(flavors []types.Flavor, users []types.User, prices map[uint]uint) *Memory {
+// NewMemoryStorage creates a memory filled with copies of the given data, for a store with the given settings.
+func NewMemoryStorage(settings types.StoreSettings, categories []types.FlavorCategory, flavors []types.Flavor, users []types.User, prices []types.IceCreamTubPrice) *Memory {
 
 	// Copying slices
-	flavorsCopy := append([]types.Flavor(nil), flavors...)
-	usersCopy := append([]types.User(nil), users...)
-
-	// Copying map
-	pricesCopy := make(map[uint]uint)
-	for k, v := range prices {
-		pricesCopy[k] = v
-	}
+	categoriesCopy := append([]types.FlavorCategory(nil), categories...)
+	flavorsCopy := cloneAll(flavors, cloneFlavor)
+	usersCopy := cloneAll(users, cloneUser)
+	pricesCopy := append([]types.IceCreamTubPrice(nil), prices...)
 
-	return &Memory{
+	return &Memory{memoryData: &memoryData{
+		Categories:      categoriesCopy,
 		Flavors:         flavorsCopy,
 		Users:           usersCopy,
 		DeliveryDrivers: []types.DeliveryDriver{},
 		Orders:          []types.Order{},
-		Prices:          prices,
+		Prices:          pricesCopy,
+		PromoCodes:      []types.PromoCode{},
+		OrderEvents:     []types.OrderEvent{},
 		idOrders:        1,
 		idUsers:         uint(len(users) + 1),
 		idTubs:          1,
-	}
+		idOrderEvents:   1,
+	}, settings: settings}
 }
 
 /*******************/
 /***** FLAVORS *****/
 /*******************/
 
-func (memory *Memory) GetFlavors() []types.Flavor {
-	return memory.Flavors
+func (memory *Memory) GetFlavors(ctx context.Context) []types.Flavor {
+	defer memory.read()()
+	flavors := []types.Flavor{}
+	for _, flavor := range memory.Flavors {
+		if !flavor.Retired {
+			flavors = append(flavors, cloneFlavor(flavor))
+		}
+	}
+	return flavors
 }
 
-func (memory *Memory) GetFlavorsByType(kind string) []types.Flavor {
+func (memory *Memory) GetFlavorsByType(ctx context.Context, categoryID string) []types.Flavor {
+	defer memory.read()()
 	var flavors []types.Flavor
 	for _, flavor := range memory.Flavors {
-		if flavor.Type == kind {
-			flavors = append(flavors, flavor)
+		if flavor.CategoryID == categoryID && !flavor.Retired {
+			flavors = append(flavors, cloneFlavor(flavor))
 		}
 	}
 	return flavors
 }
 
-func (memory *Memory) GetFlavorByID(idFlavor string) (types.Flavor, error) {
+func (memory *Memory) GetFlavorByID(ctx context.Context, idFlavor string) (types.Flavor, error) {
+	defer memory.read()()
 	for _, flavor := range memory.Flavors {
 		if flavor.ID == idFlavor {
-			return flavor, nil
+			return cloneFlavor(flavor), nil
 		}
 	}
 	return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
 }
 
-func (memory *Memory) AddFlavor(newFlavor types.Flavor) error {
+func (memory *Memory) AddFlavor(ctx context.Context, newFlavor types.Flavor) error {
+	defer memory.write()()
+	if _, err := memory.flavorCategoryByID(newFlavor.CategoryID); err != nil {
+		return err
+	}
 	for _, flavor := range memory.Flavors {
 		if flavor.ID == newFlavor.ID {
 			return errors.New(messageErrors.AlreadyExistingFlavor)
 		}
 	}
-	memory.Flavors = append(memory.Flavors, newFlavor)
+	memory.Flavors = append(memory.Flavors, cloneFlavor(newFlavor))
+	return nil
+}
+
+func (memory *Memory) UpdateFlavor(ctx context.Context, idFlavor string, updatedFlavor types.Flavor) (types.Flavor, error) {
+	defer memory.write()()
+	for i := range memory.Flavors {
+		if memory.Flavors[i].ID == idFlavor {
+			if _, err := memory.flavorCategoryByID(updatedFlavor.CategoryID); err != nil {
+				return types.Flavor{}, err
+			}
+			memory.Flavors[i].Name = updatedFlavor.Name
+			memory.Flavors[i].CategoryID = updatedFlavor.CategoryID
+			memory.Flavors[i].AvailableFrom = updatedFlavor.AvailableFrom
+			memory.Flavors[i].AvailableUntil = updatedFlavor.AvailableUntil
+			memory.Flavors[i].AvailableWeekdays = slices.Clone(updatedFlavor.AvailableWeekdays)
+			memory.Flavors[i].Allergens = slices.Clone(updatedFlavor.Allergens)
+			memory.Flavors[i].Diets = slices.Clone(updatedFlavor.Diets)
+			return cloneFlavor(memory.Flavors[i]), nil
+		}
+	}
+	return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+}
+
+func (memory *Memory) RetireFlavor(ctx context.Context, idFlavor string) error {
+	defer memory.write()()
+	for i := range memory.Flavors {
+		if memory.Flavors[i].ID == idFlavor {
+			if memory.Flavors[i].Retired {
+				return errors.New(messageErrors.FlavorIsAlreadyRetired)
+			}
+			memory.Flavors[i].Retired = true
+			return nil
+		}
+	}
+	return errors.New(messageErrors.FlavorNotFound)
+}
+
+func (memory *Memory) UpdateFlavorStock(ctx context.Context, idFlavor string, stock uint) (types.Flavor, error) {
+	defer memory.write()()
+	for i := range memory.Flavors {
+		if memory.Flavors[i].ID == idFlavor {
+			memory.Flavors[i].Stock = stock
+			return cloneFlavor(memory.Flavors[i]), nil
+		}
+	}
+	return types.Flavor{}, errors.New(messageErrors.FlavorNotFound)
+}
+
+func (memory *Memory) GetLowStockFlavors(ctx context.Context, threshold uint) []types.Flavor {
+	defer memory.read()()
+	flavors := []types.Flavor{}
+	for _, flavor := range memory.Flavors {
+		if flavor.Stock < threshold && !flavor.Retired {
+			flavors = append(flavors, cloneFlavor(flavor))
+		}
+	}
+	return flavors
+}
+
+/*****************************/
+/***** FLAVOR CATEGORIES *****/
+/*****************************/
+
+func (memory *Memory) GetFlavorCategories(ctx context.Context) []types.FlavorCategory {
+	defer memory.read()()
+	categories := append([]types.FlavorCategory{}, memory.Categories...)
+	slices.SortStableFunc(categories, compareFlavorCategories)
+	return categories
+}
+
+func (memory *Memory) GetFlavorCategoryByID(ctx context.Context, idCategory string) (types.FlavorCategory, error) {
+	defer memory.read()()
+	return memory.flavorCategoryByID(idCategory)
+}
+
+func (memory *Memory) AddFlavorCategory(ctx context.Context, newCategory types.FlavorCategory) error {
+	defer memory.write()()
+	for _, category := range memory.Categories {
+		if category.ID == newCategory.ID {
+			return errors.New(messageErrors.AlreadyExistingFlavorCategory)
+		}
+	}
+	memory.Categories = append(memory.Categories, newCategory)
 	return nil
 }
 
+func (memory *Memory) UpdateFlavorCategory(ctx context.Context, idCategory string, updatedCategory types.FlavorCategory) (types.FlavorCategory, error) {
+	defer memory.write()()
+	for i := range memory.Categories {
+		if memory.Categories[i].ID == idCategory {
+			memory.Categories[i].Name = updatedCategory.Name
+			memory.Categories[i].SortOrder = updatedCategory.SortOrder
+			return memory.Categories[i], nil
+		}
+	}
+	return types.FlavorCategory{}, errors.New(messageErrors.FlavorCategoryNotFound)
+}
+
+func (memory *Memory) DeleteFlavorCategory(ctx context.Context, idCategory string) error {
+	defer memory.write()()
+	for i, category := range memory.Categories {
+		if category.ID == idCategory {
+			for _, flavor := range memory.Flavors {
+				if flavor.CategoryID == idCategory {
+					return errors.New(messageErrors.FlavorCategoryIsInUse)
+				}
+			}
+			memory.Categories = append(memory.Categories[:i], memory.Categories[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New(messageErrors.FlavorCategoryNotFound)
+}
+
+/******************/
+/***** PRICES *****/
+/******************/
+
+func (memory *Memory) GetPrices(ctx context.Context) []types.IceCreamTubPrice {
+	defer memory.read()()
+	prices := append([]types.IceCreamTubPrice{}, memory.Prices...)
+	slices.SortFunc(prices, func(a, b types.IceCreamTubPrice) int {
+		return cmp.Compare(a.Weight, b.Weight)
+	})
+	return prices
+}
+
+func (memory *Memory) GetPriceByWeight(ctx context.Context, weight uint) (types.IceCreamTubPrice, error) {
+	defer memory.read()()
+	return memory.priceByWeight(weight)
+}
+
+func (memory *Memory) AddPrice(ctx context.Context, newPrice types.IceCreamTubPrice) error {
+	defer memory.write()()
+	for _, price := range memory.Prices {
+		if price.Weight == newPrice.Weight {
+			return errors.New(messageErrors.AlreadyExistingPrice)
+		}
+	}
+	memory.Prices = append(memory.Prices, newPrice)
+	return nil
+}
+
+func (memory *Memory) UpdatePrice(ctx context.Context, weight uint, updatedPrice types.IceCreamTubPrice) (types.IceCreamTubPrice, error) {
+	defer memory.write()()
+	for i := range memory.Prices {
+		if memory.Prices[i].Weight == weight {
+			memory.Prices[i].Price = updatedPrice.Price
+			memory.Prices[i].MaxFlavors = updatedPrice.MaxFlavors
+			return memory.Prices[i], nil
+		}
+	}
+	return types.IceCreamTubPrice{}, errors.New(messageErrors.WeightNotAvailable)
+}
+
+func (memory *Memory) DeletePrice(ctx context.Context, weight uint) error {
+	defer memory.write()()
+	for i, price := range memory.Prices {
+		if price.Weight == weight {
+			memory.Prices = append(memory.Prices[:i], memory.Prices[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New(messageErrors.WeightNotAvailable)
+}
+
+/***********************/
+/***** PROMO CODES *****/
+/***********************/
+
+func (memory *Memory) GetPromoCodes(ctx context.Context) []types.PromoCode {
+	defer memory.read()()
+	return append([]types.PromoCode{}, memory.PromoCodes...)
+}
+
+func (memory *Memory) GetPromoCodeByCode(ctx context.Context, code string) (types.PromoCode, error) {
+	defer memory.read()()
+	return memory.promoCodeByCode(code)
+}
+
+func (memory *Memory) AddPromoCode(ctx context.Context, newPromo types.PromoCode) error {
+	defer memory.write()()
+	for _, promo := range memory.PromoCodes {
+		if promo.Code == newPromo.Code {
+			return errors.New(messageErrors.AlreadyExistingPromoCode)
+		}
+	}
+	memory.PromoCodes = append(memory.PromoCodes, newPromo)
+	return nil
+}
+
+func (memory *Memory) UpdatePromoCode(ctx context.Context, code string, updatedPromo types.PromoCode) (types.PromoCode, error) {
+	defer memory.write()()
+	for i := range memory.PromoCodes {
+		if memory.PromoCodes[i].Code == code {
+			updatedPromo.Code = code
+			memory.PromoCodes[i] = updatedPromo
+			for j := range memory.Orders {
+				if memory.Orders[j].PromoCode == code && memory.Orders[j].IsRepriceable() {
+					memory.updateOrderTotals(&memory.Orders[j])
+				}
+			}
+			return updatedPromo, nil
+		}
+	}
+	return types.PromoCode{}, errors.New(messageErrors.PromoCodeNotFound)
+}
+
+func (memory *Memory) DeletePromoCode(ctx context.Context, code string) error {
+	defer memory.write()()
+	for i, promo := range memory.PromoCodes {
+		if promo.Code == code {
+			for _, order := range memory.Orders {
+				if order.PromoCode == code {
+					return errors.New(messageErrors.PromoCodeIsInUse)
+				}
+			}
+			memory.PromoCodes = append(memory.PromoCodes[:i], memory.PromoCodes[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New(messageErrors.PromoCodeNotFound)
+}
+
+func (memory *Memory) ApplyPromoCodeToOrder(ctx context.Context, idOrder uint, code string, moment time.Time) (types.Order, error) {
+	defer memory.write()()
+	promo, err := memory.promoCodeByCode(code)
+	if err != nil {
+		return types.Order{}, err
+	}
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			order := &memory.Orders[i]
+			if err := order.CheckUnpaid(); err != nil {
+				return types.Order{}, err
+			}
+			if order.Status == types.OrderCancelled {
+				return types.Order{}, errors.New(messageErrors.OrderIsAlreadyCancelled)
+			}
+			if order.PromoCode != "" {
+				return types.Order{}, errors.New(messageErrors.PromoCodeAlreadyApplied)
+			}
+			if err := promo.CheckApplicableTo(*order, moment); err != nil {
+				return types.Order{}, err
+			}
+			var totalUses, userUses uint
+			for _, other := range memory.Orders {
+				if other.PromoCode == code {
+					totalUses++
+					if other.UserID == order.UserID {
+						userUses++
+					}
+				}
+			}
+			if err := promo.CheckUsageLimits(totalUses, userUses); err != nil {
+				return types.Order{}, err
+			}
+			order.PromoCode = code
+			order.ComputeTotals(&promo)
+			memory.recordOrderEvent(order.ID, types.PromoCodeAppliedEvent, order.UserID, map[string]any{"code": code, "discount": order.Discount})
+			return cloneOrder(*order), nil
+		}
+	}
+	return types.Order{}, errors.New(messageErrors.OrderNotFound)
+}
+
 /******************/
 /***** ORDERS *****/
 /******************/
 
-func (memory *Memory) CreateOrder(order *types.Order) error {
+func (memory *Memory) CreateOrder(ctx context.Context, order *types.Order) error {
+	defer memory.write()()
+	if !slices.ContainsFunc(memory.Users, func(user types.User) bool { return user.ID == order.UserID }) {
+		return errors.New(messageErrors.UserIDNotFound)
+	}
+
 	order.ID = memory.idOrders
-	order.PaymentState = "pending"
+	order.PaymentState = types.PaymentPending
+	order.Status = types.OrderDraft
+	order.PromoCode = ""
+	order.TotalCost = types.NewMoney(0, memory.settings.DefaultCurrency())
 
-	for i := range memory.Users {
-		if memory.Users[i].ID == order.UserID {
-			memory.Users[i].Orders = append(memory.Users[i].Orders, *order)
-			memory.Orders = append(memory.Orders, *order)
-			memory.idOrders++
-			return nil
+	// Every tub is checked in one unit of work, so the order is created with all its tubs or not at all.
+	return memory.atomically(func() error {
+		tubErrors := []types.TubError{}
+		for i := range order.IceCreamTubs {
+			tub := &order.IceCreamTubs[i]
+			var price types.IceCreamTubPrice
+			err := tub.Validate()
+			if err == nil {
+				price, err = memory.priceOf(tub)
+			}
+			if err == nil && price.Price.Currency != order.TotalCost.Currency {
+				err = errors.New(messageErrors.CurrencyMismatch)
+			}
+			if err == nil {
+				err = memory.reserveStock(tub.GramsPerFlavor())
+			}
+			if err != nil {
+				tubErrors = append(tubErrors, types.TubError{Index: i, Error: err.Error()})
+				continue
+			}
+			tub.UnitPrice = price.Price
+		}
+		if len(tubErrors) > 0 {
+			return &types.InvalidTubsError{Tubs: tubErrors}
+		}
+
+		for i := range order.IceCreamTubs {
+			order.IceCreamTubs[i].ID = memory.idTubs
+			order.IceCreamTubs[i].OrderID = order.ID
+			memory.idTubs++
+		}
+		order.ComputeTotals(nil)
+
+		memory.Orders = append(memory.Orders, cloneOrder(*order))
+		memory.idOrders++
+		memory.recordOrderEvent(order.ID, types.OrderCreatedEvent, order.UserID, types.CreatedEventPayload(*order))
+		for _, tub := range order.IceCreamTubs {
+			memory.recordOrderEvent(order.ID, types.TubAddedEvent, order.UserID, types.TubEventPayload(tub))
+		}
+		return nil
+	})
+}
+
+func (memory *Memory) GetOrderByID(ctx context.Context, idOrder uint) (types.Order, error) {
+	defer memory.read()()
+	return memory.orderByID(idOrder)
+}
+
+func (memory *Memory) GetAllOrders(ctx context.Context) []types.Order {
+	defer memory.read()()
+	return cloneAll(memory.Orders, cloneOrder)
+}
+
+func (memory *Memory) GetOrdersByStatus(ctx context.Context, statuses ...string) []types.Order {
+	defer memory.read()()
+	orders := []types.Order{}
+	for _, order := range memory.Orders {
+		if slices.Contains(statuses, order.Status) {
+			orders = append(orders, cloneOrder(order))
 		}
 	}
+	return orders
+}
 
-	return errors.New(messageErrors.UserIDNotFound)
+func (memory *Memory) GetOrdersByDeliveryDriverID(ctx context.Context, idUser uint) []types.Order {
+	defer memory.read()()
+	orders := []types.Order{}
+	for _, order := range memory.Orders {
+		if order.DeliveryDriverID == idUser {
+			orders = append(orders, cloneOrder(order))
+		}
+	}
+	return orders
 }
 
-func (memory *Memory) GetOrderByID(idOrder uint) (types.Order, error) {
+func (memory *Memory) GetOrdersScheduledFor(ctx context.Context, date string) []types.Order {
+	defer memory.read()()
+	orders := []types.Order{}
 	for _, order := range memory.Orders {
-		if order.ID == idOrder {
-			return order, nil
+		if order.IsScheduled() && order.DeliveryWindow.StartsOn(date) && order.Status != types.OrderCancelled {
+			orders = append(orders, cloneOrder(order))
 		}
 	}
-	return types.Order{}, errors.New(messageErrors.OrderNotFound)
+	slices.SortStableFunc(orders, func(a, b types.Order) int {
+		return a.DeliveryWindow.Start.Compare(b.DeliveryWindow.Start)
+	})
+	return orders
 }
 
-func (memory *Memory) GetAllOrders() []types.Order {
-	return memory.Orders
+func (memory *Memory) GetAssignableOrders(ctx context.Context, moment time.Time) []types.Order {
+	defer memory.read()()
+	orders := []types.Order{}
+	for _, order := range memory.Orders {
+		if order.IsAssignableAt(moment, memory.settings.DriverNotice) {
+			orders = append(orders, cloneOrder(order))
+		}
+	}
+	return orders
 }
 
-func (memory *Memory) GetAllOrdersByUserEmail(email string) []types.Order {
+func (memory *Memory) GetAllOrdersByUserEmail(ctx context.Context, email string) []types.Order {
+	defer memory.read()()
 	for _, user := range memory.Users {
 		if user.Email == email {
-			return user.Orders
+			return memory.ordersOf(user.ID)
 		}
 	}
 	return []types.Order{}
 }
 
-func (memory *Memory) GetUserOrderByID(orderID uint, userID uint) (types.Order, error) {
-	for _, user := range memory.Users {
-		if user.ID == userID {
-			for _, order := range user.Orders {
-				if order.ID == orderID {
-					return memory.GetOrderByID(orderID)
-				}
-			}
-			return types.Order{}, errors.New(messageErrors.OrderNotFound)
-		}
+func (memory *Memory) GetUserOrderByID(ctx context.Context, orderID uint, userID uint) (types.Order, error) {
+	defer memory.read()()
+	if !slices.ContainsFunc(memory.Users, func(user types.User) bool { return user.ID == userID }) {
+		return types.Order{}, errors.New(messageErrors.UserIDNotFound)
+	}
+	order, err := memory.orderByID(orderID)
+	if err != nil || order.UserID != userID {
+		return types.Order{}, errors.New(messageErrors.OrderNotFound)
 	}
-	return types.Order{}, errors.New(messageErrors.UserIDNotFound)
+	return order, nil
 }
 
-func (memory *Memory) UpdateOrderByID(orderID uint, updatedOrder *types.Order) (types.Order, error) {
+func (memory *Memory) UpdateOrderByID(ctx context.Context, orderID uint, updatedOrder *types.Order) (types.Order, error) {
+	defer memory.write()()
 	for i, order := range memory.Orders {
 		if order.ID == orderID {
 			if order.UserID == updatedOrder.UserID {
+				if err := order.CheckDeliveryEditable(); err != nil {
+					return types.Order{}, err
+				}
 				memory.Orders[i].Address = updatedOrder.Address
-				memory.Orders[i].PaymentState = updatedOrder.PaymentState
-				return memory.Orders[i], nil
+				memory.Orders[i].DeliveryWindow = cloneDeliveryWindow(updatedOrder.DeliveryWindow)
+				if order.Address != updatedOrder.Address {
+					memory.recordOrderEvent(order.ID, types.AddressChangedEvent, order.UserID, map[string]any{"from": order.Address, "to": updatedOrder.Address})
+				}
+				if !types.SameDeliveryWindow(order.DeliveryWindow, updatedOrder.DeliveryWindow) {
+					memory.recordOrderEvent(order.ID, types.WindowChangedEvent, order.UserID, map[string]any{"from": order.DeliveryWindow, "to": cloneDeliveryWindow(updatedOrder.DeliveryWindow)})
+				}
+				return cloneOrder(memory.Orders[i]), nil
 			}
 			return types.Order{}, errors.New(messageErrors.OrderNotFound)
 		}
@@ -151,32 +541,162 @@ func (memory *Memory) UpdateOrderByID(orderID uint, updatedOrder *types.Order) (
 	return types.Order{}, errors.New(messageErrors.OrderNotFound)
 }
 
-func (memory *Memory) GetIceCreamTubsByOrderID(idOrder uint) ([]types.IceCreamTub, error) {
-	for _, order := range memory.Orders {
-		if order.ID == idOrder {
-			return order.IceCreamTubs, nil
+func (memory *Memory) StartOrderPayment(ctx context.Context, idOrder uint) (types.Order, error) {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			if err := memory.Orders[i].CheckPayable(); err != nil {
+				return types.Order{}, err
+			}
+			memory.Orders[i].PaymentState = types.PaymentProcessing
+			return cloneOrder(memory.Orders[i]), nil
 		}
 	}
-	return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
+	return types.Order{}, errors.New(messageErrors.OrderNotFound)
+}
+
+func (memory *Memory) AbortOrderPayment(ctx context.Context, idOrder uint) error {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			if memory.Orders[i].PaymentState == types.PaymentProcessing {
+				memory.Orders[i].PaymentState = types.PaymentPending
+			}
+			return nil
+		}
+	}
+	return errors.New(messageErrors.OrderNotFound)
+}
+
+func (memory *Memory) MarkOrderAsPaid(ctx context.Context, idOrder uint, paymentReference string, amount types.Money) error {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			if err := memory.Orders[i].CheckPaidWith(amount); err != nil {
+				return err
+			}
+			memory.Orders[i].PaymentState = types.PaymentPaid
+			memory.Orders[i].PaymentReference = paymentReference
+			memory.recordOrderEvent(idOrder, types.OrderPaidEvent, memory.Orders[i].UserID, map[string]any{"amount": memory.Orders[i].TotalCost})
+			return nil
+		}
+	}
+	return errors.New(messageErrors.OrderNotFound)
+}
+
+func (memory *Memory) CancelOrder(ctx context.Context, idOrder uint, cancellation types.OrderCancellation) (types.Order, string, error) {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			order := &memory.Orders[i]
+			if err := order.CheckCancellable(cancellation); err != nil {
+				return types.Order{}, "", err
+			}
+			if order.ReleasesStock() {
+				for _, tub := range order.IceCreamTubs {
+					memory.releaseStock(tub.GramsPerFlavor())
+				}
+			}
+			deliveryDriverID := order.DeliveryDriverID
+			previousPaymentState := order.PaymentState
+			order.Cancel(cancellation)
+			if deliveryDriverID != 0 {
+				memory.recordOrderEvent(idOrder, types.DriverUnassignedEvent, cancellation.CancelledBy, map[string]any{"deliveryDriverID": deliveryDriverID})
+			}
+			memory.recordOrderEvent(idOrder, types.OrderCancelledEvent, cancellation.CancelledBy, map[string]any{"reason": order.CancellationReason, "refundPending": order.PaymentState == types.PaymentRefundPending})
+			return cloneOrder(*order), previousPaymentState, nil
+		}
+	}
+	return types.Order{}, "", errors.New(messageErrors.OrderNotFound)
+}
+
+func (memory *Memory) MarkOrderAsRefunded(ctx context.Context, idOrder uint, refundReference string, actorID uint) (types.Order, error) {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			order := &memory.Orders[i]
+			if order.PaymentState != types.PaymentRefundPending {
+				return types.Order{}, errors.New(messageErrors.OrderHasNoPendingRefund)
+			}
+			order.PaymentState = types.PaymentRefunded
+			memory.recordOrderEvent(idOrder, types.OrderRefundedEvent, actorID, map[string]any{"amount": order.TotalCost, "refundReference": refundReference})
+			return cloneOrder(*order), nil
+		}
+	}
+	return types.Order{}, errors.New(messageErrors.OrderNotFound)
+}
+
+func (memory *Memory) UpdateOrderStatus(ctx context.Context, idOrder uint, status string, actorID uint) (types.Order, error) {
+	defer memory.write()()
+	for i := range memory.Orders {
+		if memory.Orders[i].ID == idOrder {
+			order := &memory.Orders[i]
+			if err := order.CheckStatusTransition(status); err != nil {
+				return types.Order{}, err
+			}
+			if status == types.OrderPlaced && len(order.IceCreamTubs) == 0 {
+				return types.Order{}, errors.New(messageErrors.OrderHasNoIceCreamTubs)
+			}
+			memory.recordOrderEvent(idOrder, types.StatusChangedEvent, actorID, map[string]any{"from": order.Status, "to": status})
+			order.Status = status
+			return cloneOrder(*order), nil
+		}
+	}
+	return types.Order{}, errors.New(messageErrors.OrderNotFound)
 }
 
-func (memory *Memory) AddIceCreamTubByOrderID(idOrder uint, iceCreamTub *types.IceCreamTub) error {
+func (memory *Memory) GetOrderHistory(ctx context.Context, idOrder uint) ([]types.OrderEvent, error) {
+	defer memory.read()()
+	if _, err := memory.orderByID(idOrder); err != nil {
+		return []types.OrderEvent{}, err
+	}
+	events := []types.OrderEvent{}
+	for _, event := range memory.OrderEvents {
+		if event.OrderID == idOrder {
+			event.Payload = maps.Clone(event.Payload)
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
 
-	if ok := areFlavorIDsRegisteredInMemory(iceCreamTub.Flavors, memory.Flavors); !ok {
-		return errors.New(messageErrors.NonExistingFlavors)
+func (memory *Memory) GetIceCreamTubsByOrderID(ctx context.Context, idOrder uint) ([]types.IceCreamTub, error) {
+	defer memory.read()()
+	for _, order := range memory.Orders {
+		if order.ID == idOrder {
+			return cloneAll(order.IceCreamTubs, cloneIceCreamTub), nil
+		}
 	}
+	return []types.IceCreamTub{}, errors.New(messageErrors.OrderNotFound)
+}
 
-	price, ok := memory.Prices[iceCreamTub.Weight]
-	if !ok {
-		return errors.New(messageErrors.WeightNotAvailable)
+func (memory *Memory) AddIceCreamTubByOrderID(ctx context.Context, idOrder uint, iceCreamTub *types.IceCreamTub) error {
+	defer memory.write()()
+	price, err := memory.priceOf(iceCreamTub)
+	if err != nil {
+		return err
 	}
 
 	for i := 0; i < len(memory.Orders); i++ {
 		if memory.Orders[i].ID == idOrder {
+			if err := memory.Orders[i].CheckUnpaid(); err != nil {
+				return err
+			}
+			if !memory.Orders[i].IsDraft() {
+				return errors.New(messageErrors.OrderIsNotADraft)
+			}
+			if price.Price.Currency != memory.Orders[i].TotalCost.Currency {
+				return errors.New(messageErrors.CurrencyMismatch)
+			}
+			if err := memory.reserveStock(iceCreamTub.GramsPerFlavor()); err != nil {
+				return err
+			}
 			iceCreamTub.ID = memory.idTubs
-			memory.Orders[i].IceCreamTubs = append(memory.Orders[i].IceCreamTubs, *iceCreamTub)
+			iceCreamTub.UnitPrice = price.Price
+			memory.Orders[i].IceCreamTubs = append(memory.Orders[i].IceCreamTubs, cloneIceCreamTub(*iceCreamTub))
 			memory.idTubs++
-			memory.Orders[i].TotalCost += price
+			memory.updateOrderTotals(&memory.Orders[i])
+			memory.recordOrderEvent(idOrder, types.TubAddedEvent, memory.Orders[i].UserID, types.TubEventPayload(*iceCreamTub))
 			return nil
 		}
 	}
@@ -184,13 +704,23 @@ func (memory *Memory) AddIceCreamTubByOrderID(idOrder uint, iceCreamTub *types.I
 	return errors.New(messageErrors.OrderNotFound)
 }
 
-func (memory *Memory) DeleteIceCreamTubByOrderID(tubID uint, orderID uint) error {
+func (memory *Memory) DeleteIceCreamTubByOrderID(ctx context.Context, tubID uint, orderID uint) error {
+	defer memory.write()()
 	for j := 0; j < len(memory.Orders); j++ {
 		if memory.Orders[j].ID == orderID {
+			if err := memory.Orders[j].CheckUnpaid(); err != nil {
+				return err
+			}
+			if !memory.Orders[j].IsDraft() {
+				return errors.New(messageErrors.OrderIsNotADraft)
+			}
 			for i := 0; i < len(memory.Orders[j].IceCreamTubs); i++ {
 				if memory.Orders[j].IceCreamTubs[i].ID == tubID {
-					memory.Orders[j].TotalCost -= memory.Prices[memory.Orders[j].IceCreamTubs[i].Weight]
+					tub := memory.Orders[j].IceCreamTubs[i]
+					memory.releaseStock(tub.GramsPerFlavor())
 					memory.Orders[j].IceCreamTubs = append(memory.Orders[j].IceCreamTubs[:i], memory.Orders[j].IceCreamTubs[i+1:]...)
+					memory.updateOrderTotals(&memory.Orders[j])
+					memory.recordOrderEvent(orderID, types.TubRemovedEvent, memory.Orders[j].UserID, types.TubEventPayload(tub))
 					return nil
 				}
 			}
@@ -204,61 +734,56 @@ func (memory *Memory) DeleteIceCreamTubByOrderID(tubID uint, orderID uint) error
 /***** DELIVERY DRIVERS *****/
 /****************************/
 
-func (memory *Memory) GetDeliveryDrivers() []types.DeliveryDriver {
-	return memory.DeliveryDrivers
+func (memory *Memory) GetDeliveryDrivers(ctx context.Context) []types.DeliveryDriver {
+	defer memory.read()()
+	return cloneAll(memory.DeliveryDrivers, cloneDeliveryDriver)
 }
 
-func (memory *Memory) GetDeliveryDriverByID(idUser uint) (types.DeliveryDriver, error) {
+func (memory *Memory) GetDeliveryDriverByID(ctx context.Context, idUser uint) (types.DeliveryDriver, error) {
+	defer memory.read()()
 	for _, deliveryDriver := range memory.DeliveryDrivers {
 		if deliveryDriver.UserID == idUser {
-			return deliveryDriver, nil
+			return cloneDeliveryDriver(deliveryDriver), nil
 		}
 	}
 	return types.DeliveryDriver{}, errors.New(messageErrors.DeliveryDriverNotFound)
 }
 
-func (memory *Memory) UpdateDeliveryDriverByID(idUser uint, deliveryDriver *types.DeliveryDriver) error {
+func (memory *Memory) UpdateDeliveryDriverByID(ctx context.Context, idUser uint, deliveryDriver *types.DeliveryDriver) error {
+	defer memory.write()()
 	for i := 0; i < len(memory.DeliveryDrivers); i++ {
 		if memory.DeliveryDrivers[i].UserID == idUser {
 			deliveryDriver.UserID = memory.DeliveryDrivers[i].UserID
-			memory.DeliveryDrivers[i] = *deliveryDriver
+			memory.DeliveryDrivers[i] = cloneDeliveryDriver(*deliveryDriver)
 			return nil
 		}
 	}
 	return errors.New(messageErrors.DeliveryDriverNotFound)
 }
 
-func (memory *Memory) DeleteDeliveryDriverByID(idUser uint) error {
-	for i, deliveryDriver := range memory.DeliveryDrivers {
-		if deliveryDriver.UserID == idUser {
-			memory.DeliveryDrivers = append(memory.DeliveryDrivers[:i], memory.DeliveryDrivers[i+1:]...)
-			for i, user := range memory.Users {
-				if user.ID == deliveryDriver.UserID {
-					memory.Users[i].Permissions = utils.DeletePermission(memory.Users[i].Permissions, "delivery")
-				}
-			}
-			return nil
-		}
-	}
-	return errors.New(messageErrors.DeliveryDriverNotFound)
+func (memory *Memory) DeleteDeliveryDriverByID(ctx context.Context, idUser uint) error {
+	defer memory.write()()
+	return memory.deleteDeliveryDriver(idUser)
 }
 
-func (memory *Memory) GetVehiclesByDeliveryDriverID(idUser uint) ([]string, error) {
+func (memory *Memory) GetVehiclesByDeliveryDriverID(ctx context.Context, idUser uint) ([]string, error) {
+	defer memory.read()()
 	for _, deliveryDriver := range memory.DeliveryDrivers {
 		if deliveryDriver.UserID == idUser {
-			return deliveryDriver.Vehicles, nil
+			return slices.Clone(deliveryDriver.Vehicles), nil
 		}
 	}
 	return []string{}, errors.New(messageErrors.DeliveryDriverNotFound)
 }
 
-func (memory *Memory) AddDeliveryDriver(deliveryDriver *types.DeliveryDriver) error {
+func (memory *Memory) AddDeliveryDriver(ctx context.Context, deliveryDriver *types.DeliveryDriver) error {
+	defer memory.write()()
 	for i, user := range memory.Users {
 		if user.ID == deliveryDriver.UserID {
 			if user.IsDeliveryDriver() {
 				return errors.New(messageErrors.UserIsAlreadyADriver)
 			}
-			memory.DeliveryDrivers = append(memory.DeliveryDrivers, *deliveryDriver)
+			memory.DeliveryDrivers = append(memory.DeliveryDrivers, cloneDeliveryDriver(*deliveryDriver))
 			memory.Users[i].Permissions = append(user.Permissions, "delivery")
 			return nil
 		}
@@ -266,14 +791,18 @@ func (memory *Memory) AddDeliveryDriver(deliveryDriver *types.DeliveryDriver) er
 	return errors.New(messageErrors.UserIDNotFound)
 }
 
-func (memory *Memory) AssignDeliveryDriverToOrder(orderID uint, deliveryDriverID uint) error {
-	if !isDelvieryDriverIDRegisteredInMemory(deliveryDriverID, memory.DeliveryDrivers) {
-		return errors.New(messageErrors.DeliveryDriverNotFound)
-	}
-
+func (memory *Memory) AssignDeliveryDriverToOrder(ctx context.Context, orderID uint, deliveryDriverID uint, actorID uint) error {
+	defer memory.write()()
 	for i := 0; i < len(memory.Orders); i++ {
 		if memory.Orders[i].ID == orderID {
+			if err := memory.Orders[i].CheckDriverAssignable(); err != nil {
+				return err
+			}
+			if !isDelvieryDriverIDRegisteredInMemory(deliveryDriverID, memory.DeliveryDrivers) {
+				return errors.New(messageErrors.DeliveryDriverNotFound)
+			}
 			memory.Orders[i].DeliveryDriverID = deliveryDriverID
+			memory.recordOrderEvent(orderID, types.DriverAssignedEvent, actorID, map[string]any{"deliveryDriverID": deliveryDriverID})
 			return nil
 		}
 	}
@@ -281,17 +810,22 @@ func (memory *Memory) AssignDeliveryDriverToOrder(orderID uint, deliveryDriverID
 	return errors.New(messageErrors.OrderNotFound)
 }
 
-func (memory *Memory) DeleteDeliveryDriverFromOrder(idOrder uint) error {
+func (memory *Memory) DeleteDeliveryDriverFromOrder(ctx context.Context, idOrder uint, actorID uint) error {
+	defer memory.write()()
 	for i := 0; i < len(memory.Orders); i++ {
 		if memory.Orders[i].ID == idOrder {
-			memory.Orders[i].DeliveryDriverID = 0
+			if deliveryDriverID := memory.Orders[i].DeliveryDriverID; deliveryDriverID != 0 {
+				memory.Orders[i].DeliveryDriverID = 0
+				memory.recordOrderEvent(idOrder, types.DriverUnassignedEvent, actorID, map[string]any{"deliveryDriverID": deliveryDriverID})
+			}
 			return nil
 		}
 	}
 	return errors.New(messageErrors.OrderNotFound)
 }
 
-func (memory *Memory) GetDeliveryDriverFromOrder(idOrder uint) (uint, error) {
+func (memory *Memory) GetDeliveryDriverFromOrder(ctx context.Context, idOrder uint) (uint, error) {
+	defer memory.read()()
 	for _, order := range memory.Orders {
 		if order.ID == idOrder {
 			return order.DeliveryDriverID, nil
@@ -304,66 +838,72 @@ func (memory *Memory) GetDeliveryDriverFromOrder(idOrder uint) (uint, error) {
 /***** USERS *****/
 /*****************/
 
-func (memory *Memory) GetAllUsers() []types.User {
-	return memory.Users
+func (memory *Memory) GetAllUsers(ctx context.Context) []types.User {
+	defer memory.read()()
+	return cloneAll(memory.Users, cloneUser)
 }
 
-func (memory *Memory) SignUpUser(newUser *types.User) error {
+func (memory *Memory) SignUpUser(ctx context.Context, newUser *types.User) error {
+	// The password is hashed before taking the lock, since it is slow on purpose.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), 10)
+	if err != nil {
+		return errors.New(messageErrors.ErrorWhileProcessingRequest)
+	}
+
+	defer memory.write()()
 	for _, user := range memory.Users {
 		if user.Email == newUser.Email {
 			return errors.New(messageErrors.EmailAlreadyExists)
 		}
 	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), 10)
-	if err != nil {
-		return errors.New(messageErrors.ErrorWhileProcessingRequest)
-	}
 	newUser.Password = string(hashedPassword)
 
 	newUser.ID = memory.idUsers
 	memory.idUsers++
-	memory.Users = append(memory.Users, *newUser)
+	memory.Users = append(memory.Users, cloneUser(*newUser))
 
 	return nil
 }
 
-func (memory *Memory) LogInUser(email string, password string) error {
-	for _, user := range memory.Users {
-		if user.Email == email {
-			err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-			if err != nil {
-				return errors.New(messageErrors.InvalidEmailOrPassword)
-			}
-			return nil
-		}
+func (memory *Memory) LogInUser(ctx context.Context, email string, password string) error {
+	// The password is compared after releasing the lock, since it is slow on purpose.
+	user, err := memory.GetUserByEmail(ctx, email)
+	if err != nil {
+		return errors.New(messageErrors.InvalidEmailOrPassword)
 	}
-	return errors.New(messageErrors.InvalidEmailOrPassword)
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return errors.New(messageErrors.InvalidEmailOrPassword)
+	}
+	return nil
 }
 
-func (memory *Memory) GetUserByEmail(email string) (types.User, error) {
+func (memory *Memory) GetUserByEmail(ctx context.Context, email string) (types.User, error) {
+	defer memory.read()()
 	for _, user := range memory.Users {
 		if user.Email == email {
-			return user, nil
+			return cloneUser(user), nil
 		}
 	}
 	return types.User{}, errors.New(messageErrors.UserEmailNotFound)
 }
 
-func (memory *Memory) GetUserByID(userID uint) (types.User, error) {
+func (memory *Memory) GetUserByID(ctx context.Context, userID uint) (types.User, error) {
+	defer memory.read()()
 	for _, user := range memory.Users {
 		if user.ID == userID {
-			return user, nil
+			return memory.userWithOrders(user), nil
 		}
 	}
 	return types.User{}, errors.New(messageErrors.UserIDNotFound)
 }
 
-func (memory *Memory) DeleteUserByID(userID uint) error {
+func (memory *Memory) DeleteUserByID(ctx context.Context, userID uint) error {
+	defer memory.write()()
 	for i := 0; i < len(memory.Users); i++ {
 		if memory.Users[i].ID == userID {
 			if memory.Users[i].IsDeliveryDriver() {
-				memory.DeleteDeliveryDriverByID(userID)
+				_ = memory.deleteDeliveryDriver(userID)
 			}
 			memory.Users = append(memory.Users[:i], memory.Users[i+1:]...)
 			return nil
@@ -372,19 +912,38 @@ func (memory *Memory) DeleteUserByID(userID uint) error {
 	return errors.New(messageErrors.UserIDNotFound)
 }
 
-func (memory *Memory) UpdateUser(updatedUser types.User) (types.User, error) {
+func (memory *Memory) UpdateUser(ctx context.Context, updatedUser types.User) (types.User, error) {
+	defer memory.write()()
 	for i := 0; i < len(memory.Users); i++ {
 		if memory.Users[i].ID == updatedUser.ID {
 			memory.Users[i].Email = updatedUser.Email
 			memory.Users[i].Name = updatedUser.Name
 			memory.Users[i].LastName = updatedUser.LastName
-			return memory.Users[i], nil
+			return memory.userWithOrders(memory.Users[i]), nil
 		}
 	}
 	return types.User{}, errors.New(messageErrors.UserIDNotFound)
 }
 
-func (memory *Memory) PromoteUserToAdmin(idUser uint) error {
+func (memory *Memory) UpdateUserPassword(ctx context.Context, userID uint, password string) error {
+	// The password is hashed before taking the lock, since it is slow on purpose.
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return errors.New(messageErrors.ErrorWhileProcessingRequest)
+	}
+
+	defer memory.write()()
+	for i := 0; i < len(memory.Users); i++ {
+		if memory.Users[i].ID == userID {
+			memory.Users[i].Password = string(hashedPassword)
+			return nil
+		}
+	}
+	return errors.New(messageErrors.UserIDNotFound)
+}
+
+func (memory *Memory) PromoteUserToAdmin(ctx context.Context, idUser uint) error {
+	defer memory.write()()
 	for i := 0; i < len(memory.Users); i++ {
 		if memory.Users[i].ID == idUser {
 			if memory.Users[i].IsAdmin() {
@@ -397,13 +956,36 @@ func (memory *Memory) PromoteUserToAdmin(idUser uint) error {
 	return errors.New(messageErrors.UserIDNotFound)
 }
 
+func (memory *Memory) PromoteUserToStaff(ctx context.Context, idUser uint) error {
+	defer memory.write()()
+	for i := 0; i < len(memory.Users); i++ {
+		if memory.Users[i].ID == idUser {
+			if memory.Users[i].IsStaff() {
+				return errors.New(messageErrors.UserIsAlreadyStaff)
+			}
+			memory.Users[i].Permissions = append(memory.Users[i].Permissions, "staff")
+			return nil
+		}
+	}
+	return errors.New(messageErrors.UserIDNotFound)
+}
+
 // Others
 
-func (memory *Memory) CleanDB() error {
+func (memory *Memory) CleanDB(ctx context.Context) error {
 	//Do nothing
 	return nil
 }
 
+// WithinTransaction holds the lock of the memory while fn runs, so no other request sees its changes until it finishes.
+// fn receives a memory that shares the data but does not lock it again. If fn returns an error, the data is restored.
+func (memory *Memory) WithinTransaction(ctx context.Context, fn func(store Storage) error) error {
+	defer memory.write()()
+	return memory.atomically(func() error {
+		return fn(&Memory{memoryData: memory.memoryData, settings: memory.settings, locked: true})
+	})
+}
+
 func (memory *Memory) Close() error {
 	//Do nothing
 	return nil
@@ -411,6 +993,173 @@ func (memory *Memory) Close() error {
 
 // Auxiliary functions
 
+// priceOf checks that the flavors of a tub can be sold now and obtains the current price of its size.
+func (memory *Memory) priceOf(tub *types.IceCreamTub) (types.IceCreamTubPrice, error) {
+	if ok := areFlavorIDsRegisteredInMemory(tub.Flavors, memory.Flavors); !ok {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.NonExistingFlavors)
+	}
+
+	if ok := areFlavorsAvailableInMemory(tub.Flavors, memory.Flavors, time.Now()); !ok {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.UnavailableFlavors)
+	}
+
+	price, err := memory.priceByWeight(tub.Weight)
+	if err != nil {
+		return types.IceCreamTubPrice{}, err
+	}
+
+	if uint(len(tub.Flavors)) > price.MaxFlavors {
+		return types.IceCreamTubPrice{}, errors.New(messageErrors.InvalidAmountOfFlavors)
+	}
+	return price, nil
+}
+
+// read takes the lock of the memory to read its data, and returns the function that releases it.
+func (memory *Memory) read() (unlock func()) {
+	if memory.locked {
+		return func() {}
+	}
+	memory.mu.RLock()
+	return memory.mu.RUnlock
+}
+
+// write takes the lock of the memory to change its data, and returns the function that releases it.
+func (memory *Memory) write() (unlock func()) {
+	if memory.locked {
+		return func() {}
+	}
+	memory.mu.Lock()
+	return memory.mu.Unlock
+}
+
+// atomically runs fn and restores the data of the memory if it returns an error. The lock must be held to call it.
+func (memory *Memory) atomically(fn func() error) error {
+	snapshot := memory.snapshot()
+	if err := fn(); err != nil {
+		*memory.memoryData = snapshot
+		return err
+	}
+	return nil
+}
+
+// snapshot copies the data of the memory, so a failed unit of work can restore it.
+// Slices are cloned because methods update their elements in place.
+func (memory *Memory) snapshot() memoryData {
+	snapshot := *memory.memoryData
+	snapshot.Categories = slices.Clone(memory.Categories)
+	snapshot.Flavors = cloneAll(memory.Flavors, cloneFlavor)
+	snapshot.Users = cloneAll(memory.Users, cloneUser)
+	snapshot.DeliveryDrivers = cloneAll(memory.DeliveryDrivers, cloneDeliveryDriver)
+	snapshot.Orders = cloneAll(memory.Orders, cloneOrder)
+	snapshot.Prices = slices.Clone(memory.Prices)
+	snapshot.PromoCodes = slices.Clone(memory.PromoCodes)
+	snapshot.OrderEvents = slices.Clone(memory.OrderEvents)
+	return snapshot
+}
+
+// flavorCategoryByID finds a flavor category. The lock must be held to call it.
+func (memory *Memory) flavorCategoryByID(idCategory string) (types.FlavorCategory, error) {
+	for _, category := range memory.Categories {
+		if category.ID == idCategory {
+			return category, nil
+		}
+	}
+	return types.FlavorCategory{}, errors.New(messageErrors.FlavorCategoryNotFound)
+}
+
+// priceByWeight finds the price of a tub size. The lock must be held to call it.
+func (memory *Memory) priceByWeight(weight uint) (types.IceCreamTubPrice, error) {
+	for _, price := range memory.Prices {
+		if price.Weight == weight {
+			return price, nil
+		}
+	}
+	return types.IceCreamTubPrice{}, errors.New(messageErrors.WeightNotAvailable)
+}
+
+// promoCodeByCode finds a promo code. The lock must be held to call it.
+func (memory *Memory) promoCodeByCode(code string) (types.PromoCode, error) {
+	for _, promo := range memory.PromoCodes {
+		if promo.Code == code {
+			return promo, nil
+		}
+	}
+	return types.PromoCode{}, errors.New(messageErrors.PromoCodeNotFound)
+}
+
+// ordersOf copies the orders of a user. Orders are only kept in memory.Orders, so they are never out of date.
+func (memory *Memory) ordersOf(idUser uint) []types.Order {
+	orders := []types.Order{}
+	for _, order := range memory.Orders {
+		if order.UserID == idUser {
+			orders = append(orders, cloneOrder(order))
+		}
+	}
+	return orders
+}
+
+// userWithOrders copies a user with their orders, like the users obtained by their ID from the database.
+func (memory *Memory) userWithOrders(user types.User) types.User {
+	user = cloneUser(user)
+	user.Orders = memory.ordersOf(user.ID)
+	return user
+}
+
+// orderByID obtains a copy of an order. The lock must be held to call it.
+func (memory *Memory) orderByID(idOrder uint) (types.Order, error) {
+	for _, order := range memory.Orders {
+		if order.ID == idOrder {
+			return cloneOrder(order), nil
+		}
+	}
+	return types.Order{}, errors.New(messageErrors.OrderNotFound)
+}
+
+// deleteDeliveryDriver deletes a delivery driver and takes the delivery permission from their user. The lock must be held to call it.
+func (memory *Memory) deleteDeliveryDriver(idUser uint) error {
+	for i, deliveryDriver := range memory.DeliveryDrivers {
+		if deliveryDriver.UserID == idUser {
+			memory.DeliveryDrivers = append(memory.DeliveryDrivers[:i], memory.DeliveryDrivers[i+1:]...)
+			for i, user := range memory.Users {
+				if user.ID == deliveryDriver.UserID {
+					memory.Users[i].Permissions = utils.DeletePermission(memory.Users[i].Permissions, "delivery")
+				}
+			}
+			return nil
+		}
+	}
+	return errors.New(messageErrors.DeliveryDriverNotFound)
+}
+
+// recordOrderEvent appends an event to the history of an order.
+func (memory *Memory) recordOrderEvent(orderID uint, kind string, actorID uint, payload map[string]any) {
+	event := types.NewOrderEvent(orderID, kind, actorID, payload)
+	event.ID = memory.idOrderEvents
+	memory.OrderEvents = append(memory.OrderEvents, event)
+	memory.idOrderEvents++
+}
+
+// reserveStock subtracts the grams needed from each flavor.
+// If any flavor does not have enough stock, nothing is subtracted.
+func (memory *Memory) reserveStock(grams map[string]uint) error {
+	for i := range memory.Flavors {
+		if needed, ok := grams[memory.Flavors[i].ID]; ok && memory.Flavors[i].Stock < needed {
+			return errors.New(messageErrors.OutOfStockFlavors)
+		}
+	}
+	for i := range memory.Flavors {
+		memory.Flavors[i].Stock -= grams[memory.Flavors[i].ID]
+	}
+	return nil
+}
+
+// releaseStock gives back the grams reserved to each flavor.
+func (memory *Memory) releaseStock(grams map[string]uint) {
+	for i := range memory.Flavors {
+		memory.Flavors[i].Stock += grams[memory.Flavors[i].ID]
+	}
+}
+
 func isDelvieryDriverIDRegisteredInMemory(idUser uint, deliveryDrivers []types.DeliveryDriver) bool {
 	for _, deliveryDriver := range deliveryDrivers {
 		if deliveryDriver.UserID == idUser {
@@ -420,9 +1169,10 @@ func isDelvieryDriverIDRegisteredInMemory(idUser uint, deliveryDrivers []types.D
 	return false
 }
 
+// isFlavorIDRegisteredInMemory checks if a flavor exists and is not retired.
 func isFlavorIDRegisteredInMemory(flavorID string, flavors []types.Flavor) bool {
 	for _, flavor := range flavors {
-		if flavor.ID == flavorID {
+		if flavor.ID == flavorID && !flavor.Retired {
 			return true
 		}
 	}
@@ -437,3 +1187,86 @@ func areFlavorIDsRegisteredInMemory(flavorIDs []string, flavors []types.Flavor)
 	}
 	return true
 }
+
+// areFlavorsAvailableInMemory checks if all flavors can be sold at a given moment.
+func areFlavorsAvailableInMemory(flavorIDs []string, flavors []types.Flavor, moment time.Time) bool {
+	for _, flavor := range flavors {
+		if slices.Contains(flavorIDs, flavor.ID) && !flavor.IsAvailableAt(moment) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateOrderTotals recomputes the totals of an order with the promo code applied to it, if any.
+func (memory *Memory) updateOrderTotals(order *types.Order) {
+	if order.PromoCode == "" {
+		order.ComputeTotals(nil)
+		return
+	}
+	promo, err := memory.promoCodeByCode(order.PromoCode)
+	if err != nil {
+		order.ComputeTotals(nil)
+		return
+	}
+	order.ComputeTotals(&promo)
+}
+
+// compareFlavorCategories sorts categories by sort order, and then by name.
+func compareFlavorCategories(a, b types.FlavorCategory) int {
+	if a.SortOrder != b.SortOrder {
+		return cmp.Compare(a.SortOrder, b.SortOrder)
+	}
+	return cmp.Compare(a.Name, b.Name)
+}
+
+// cloneAll copies a slice with a function that copies each of its elements. Nil slices stay nil.
+func cloneAll[T any](items []T, clone func(T) T) []T {
+	if items == nil {
+		return nil
+	}
+	cloned := make([]T, 0, len(items))
+	for _, item := range items {
+		cloned = append(cloned, clone(item))
+	}
+	return cloned
+}
+
+func cloneFlavor(flavor types.Flavor) types.Flavor {
+	flavor.AvailableWeekdays = slices.Clone(flavor.AvailableWeekdays)
+	flavor.Allergens = slices.Clone(flavor.Allergens)
+	flavor.Diets = slices.Clone(flavor.Diets)
+	return flavor
+}
+
+func cloneIceCreamTub(tub types.IceCreamTub) types.IceCreamTub {
+	tub.Flavors = slices.Clone(tub.Flavors)
+	tub.Allergens = slices.Clone(tub.Allergens)
+	return tub
+}
+
+func cloneDeliveryWindow(window *types.DeliveryWindow) *types.DeliveryWindow {
+	if window == nil {
+		return nil
+	}
+	cloned := *window
+	return &cloned
+}
+
+func cloneOrder(order types.Order) types.Order {
+	order.IceCreamTubs = cloneAll(order.IceCreamTubs, cloneIceCreamTub)
+	order.DeliveryWindow = cloneDeliveryWindow(order.DeliveryWindow)
+	return order
+}
+
+// cloneUser copies a user without their orders, see userWithOrders.
+func cloneUser(user types.User) types.User {
+	user.Orders = []types.Order{}
+	user.Permissions = slices.Clone(user.Permissions)
+	return user
+}
+
+func cloneDeliveryDriver(deliveryDriver types.DeliveryDriver) types.DeliveryDriver {
+	deliveryDriver.Vehicles = slices.Clone(deliveryDriver.Vehicles)
+	return deliveryDriver
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"icecreamshop/internal/config"
+)
+
+// sqliteOptions are added to the path of every SQLite database:
+//   - foreign keys are checked, like in Postgres.
+//   - transactions take the write lock when they begin. SQLite has no row locks, so the whole database is locked
+//     instead of the rows read with FOR UPDATE, which the driver leaves out of the queries.
+//   - the journal is written ahead, so reads are not blocked by writes.
+//   - connections wait up to 5 seconds for the write lock before failing.
+const sqliteOptions = "_foreign_keys=on&_txlock=immediate&_journal_mode=WAL&_busy_timeout=5000"
+
+// NewSQLiteStorage opens, or creates, the SQLite database in the path of the config and applies its pending migrations.
+// It is a DbStorage, so it behaves like the Postgres one, without needing a database server. The initial data is not added, see Seed.
+func NewSQLiteStorage(settings config.Config) *DbStorage {
+	db, err := OpenSQLite(settings.Database.SQLitePath)
+	if err != nil {
+		panic("failed to open the sqlite database")
+	}
+
+	_, err = Migrate(db, settings.Store.DefaultCurrency())
+	if err != nil {
+		panic("failed to migrate the database: " + err.Error())
+	}
+
+	return &DbStorage{DB: db, testing: settings.Mode == config.Testing, settings: settings.Store}
+}
+
+// OpenSQLite opens, or creates, the SQLite database in path.
+func OpenSQLite(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open("file:"+path+"?"+sqliteOptions), &gorm.Config{})
+}
+
+// cleanSQLite deletes all the data of a SQLite database and restarts its ids, like TRUNCATE does in Postgres.
+func cleanSQLite(db *gorm.DB) error {
+	// Tables are emptied after the ones referencing them, so foreign keys are never broken
+	tables := []string{"order_events", "ice_cream_tubs", "orders", "delivery_drivers", "users", "promo_codes", "flavors", "flavor_categories", "ice_cream_tub_prices"}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("DELETE FROM sqlite_sequence").Error
+	})
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"icecreamshop/internal/storage/migrations"
+	"icecreamshop/internal/types"
+	"math"
+)
+
+// autoMigratedVersion is the last migration whose schema was created with AutoMigrate, before migrations existed.
+const autoMigratedVersion = 1
+
+// adoptAutoMigratedSchema brings databases created with AutoMigrate to the schema of autoMigratedVersion,
+// and records the migrations up to it as applied. Databases created by migrations are left as they are.
+// The changes are frozen SQL, so later changes to the types never change what an old database is brought to.
+// Amounts stored without currency are taken as amounts of currency.
+func adoptAutoMigratedSchema(db *gorm.DB, currency string) error {
+	if db.Migrator().HasTable("schema_migrations") || !db.Migrator().HasTable("users") {
+		return nil
+	}
+	// SQLite databases were only ever created with the schema of autoMigratedVersion
+	if db.Dialector.Name() == "postgres" {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, statement := range postgresAutoMigratedTables {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			if err := moveFlavorTypesToCategories(tx); err != nil {
+				return err
+			}
+			if err := addPricesPrimaryKey(tx); err != nil {
+				return err
+			}
+			if err := migrateMoneyColumns(tx, currency); err != nil {
+				return err
+			}
+			if err := backfillTubUnitPrices(tx); err != nil {
+				return err
+			}
+			if err := backfillOrderSubtotals(tx); err != nil {
+				return err
+			}
+			for _, statement := range postgresAutoMigratedConstraints {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return migrations.Baseline(db, autoMigratedVersion)
+}
+
+// postgresAutoMigratedTables create the tables and columns that were added to the models after the first
+// AutoMigrate schemas, as they are in the schema of autoMigratedVersion.
+var postgresAutoMigratedTables = []string{
+	`CREATE TABLE IF NOT EXISTS flavor_categories (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    sort_order bigint NOT NULL DEFAULT 0
+)`,
+	`CREATE TABLE IF NOT EXISTS promo_codes (
+    code                     text PRIMARY KEY,
+    kind                     text NOT NULL,
+    percentage               bigint,
+    fixed_amount             bigint NOT NULL DEFAULT 0,
+    fixed_currency           varchar(3) NOT NULL DEFAULT '',
+    free_tub_weight          bigint,
+    valid_from               text,
+    valid_until              text,
+    max_uses                 bigint,
+    max_uses_per_user        bigint,
+    min_order_total_amount   bigint NOT NULL DEFAULT 0,
+    min_order_total_currency varchar(3) NOT NULL DEFAULT ''
+)`,
+	`CREATE TABLE IF NOT EXISTS order_events (
+    id         bigserial PRIMARY KEY,
+    order_id   bigint NOT NULL,
+    kind       text NOT NULL,
+    actor_id   bigint,
+    created_at timestamptz NOT NULL,
+    payload    jsonb DEFAULT '{}'
+)`,
+	`ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS payment_reference     text,
+    ADD COLUMN IF NOT EXISTS status                text NOT NULL DEFAULT 'draft',
+    ADD COLUMN IF NOT EXISTS promo_code            text,
+    ADD COLUMN IF NOT EXISTS subtotal_amount       bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS subtotal_currency     varchar(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS discount_amount       bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_currency     varchar(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS total_cost_amount     bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total_cost_currency   varchar(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS cancelled_by          bigint,
+    ADD COLUMN IF NOT EXISTS cancellation_reason   text,
+    ADD COLUMN IF NOT EXISTS delivery_window_start timestamptz,
+    ADD COLUMN IF NOT EXISTS delivery_window_end   timestamptz`,
+	`ALTER TABLE flavors
+    ADD COLUMN IF NOT EXISTS category_id        text,
+    ADD COLUMN IF NOT EXISTS stock              bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS retired            boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS available_from     text,
+    ADD COLUMN IF NOT EXISTS available_until    text,
+    ADD COLUMN IF NOT EXISTS available_weekdays jsonb DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS allergens          jsonb DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS diets              jsonb DEFAULT '[]'`,
+	`ALTER TABLE ice_cream_tubs
+    ADD COLUMN IF NOT EXISTS unit_price_amount   bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS unit_price_currency varchar(3) NOT NULL DEFAULT ''`,
+	`ALTER TABLE ice_cream_tub_prices
+    ADD COLUMN IF NOT EXISTS price_amount   bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS price_currency varchar(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS max_flavors    bigint NOT NULL DEFAULT 4`,
+}
+
+// postgresAutoMigratedConstraints create the constraints and indexes of the schema of autoMigratedVersion, once its columns are filled.
+var postgresAutoMigratedConstraints = []string{
+	"ALTER TABLE flavors ALTER COLUMN category_id SET NOT NULL",
+	"CREATE INDEX IF NOT EXISTS idx_orders_delivery_start ON orders (delivery_window_start)",
+	"CREATE INDEX IF NOT EXISTS idx_orders_promo_code ON orders (promo_code)",
+	"CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status)",
+	"CREATE INDEX IF NOT EXISTS idx_flavors_category_id ON flavors (category_id)",
+	"CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events (order_id)",
+}
+
+// moveFlavorTypesToCategories replaces the type of flavors created before categories existed with a category,
+// named like the type and identified by it in lower case with dashes, like "Al agua" and al-agua.
+func moveFlavorTypesToCategories(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("flavors", "type") {
+		return nil
+	}
+	err := db.Exec("UPDATE flavors SET category_id = lower(replace(trim(type), ' ', '-')) WHERE category_id IS NULL").Error
+	if err != nil {
+		return err
+	}
+	err = db.Exec("INSERT INTO flavor_categories (id, name) " +
+		"SELECT DISTINCT ON (category_id) category_id, trim(type) FROM flavors ORDER BY category_id ON CONFLICT DO NOTHING").Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("ALTER TABLE flavors DROP COLUMN type").Error
+}
+
+// addPricesPrimaryKey makes weight the primary key of prices tables created before it had one.
+// Duplicated weights are removed first, keeping the last inserted row.
+func addPricesPrimaryKey(db *gorm.DB) error {
+	if db.Migrator().HasConstraint("ice_cream_tub_prices", "ice_cream_tub_prices_pkey") {
+		return nil
+	}
+	err := db.Exec("DELETE FROM ice_cream_tub_prices a USING ice_cream_tub_prices b WHERE a.weight = b.weight AND a.ctid < b.ctid").Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("ALTER TABLE ice_cream_tub_prices ADD PRIMARY KEY (weight)").Error
+}
+
+// migrateMoneyColumns moves amounts stored as whole units without currency to the money columns,
+// converting them to minor units of currency. Old columns are dropped afterwards.
+func migrateMoneyColumns(db *gorm.DB, currency string) error {
+	factor := int64(math.Pow10(types.NewMoney(0, currency).MinorUnitDigits()))
+	columns := []struct {
+		table  string
+		column string
+	}{
+		{"orders", "total_cost"},
+		{"ice_cream_tub_prices", "price"},
+		{"ice_cream_tubs", "unit_price"},
+	}
+	for _, c := range columns {
+		if !db.Migrator().HasColumn(c.table, c.column) {
+			continue
+		}
+		query := fmt.Sprintf("UPDATE %s SET %s_amount = %s * ?, %s_currency = ?", c.table, c.column, c.column, c.column)
+		if err := db.Exec(query, factor, currency).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", c.table, c.column)).Error; err != nil {
+			return err
+		}
+	}
+	return db.Exec("UPDATE orders SET total_cost_currency = ? WHERE total_cost_currency = ''", currency).Error
+}
+
+// backfillTubUnitPrices sets the unit price of tubs created before it was stored, using the current price of their weight.
+func backfillTubUnitPrices(db *gorm.DB) error {
+	return db.Exec("UPDATE ice_cream_tubs SET unit_price_amount = p.price_amount, unit_price_currency = p.price_currency " +
+		"FROM ice_cream_tub_prices p WHERE ice_cream_tubs.unit_price_amount = 0 AND p.weight = ice_cream_tubs.weight").Error
+}
+
+// backfillOrderSubtotals sets the subtotal and discount of orders created before they were stored.
+// Those orders had no promo codes, so their subtotal is their total cost.
+func backfillOrderSubtotals(db *gorm.DB) error {
+	return db.Exec("UPDATE orders SET subtotal_amount = total_cost_amount, subtotal_currency = total_cost_currency, " +
+		"discount_currency = total_cost_currency WHERE subtotal_currency = ''").Error
+}
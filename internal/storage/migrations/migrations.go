@@ -0,0 +1,233 @@
+// Package migrations applies and reverts the versioned changes to the schema of the database.
+// Each migration is a pair of SQL files, NNNN_name.up.sql and NNNN_name.down.sql, written for every dialect
+// in the folder with its name. Applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Migration is a change to the schema, identified by its version.
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// Status is a migration and whether it has been applied. AppliedAt is nil for pending migrations.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table.
+type schemaMigration struct {
+	Version   uint      `gorm:"primaryKey; autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// All returns the migrations written for the dialect of db, sorted by version.
+func All(db *gorm.DB) ([]Migration, error) {
+	dialect := db.Dialector.Name()
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("there are no migrations for %s", dialect)
+	}
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := files.ReadFile(path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return int(a.Version) - int(b.Version)
+	})
+	return migrations, nil
+}
+
+// Up applies every pending migration, in order, and returns the ones applied.
+// Each migration is applied in its own transaction, so a failing one leaves the previous ones applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+	applied := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(status.up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: status.Version, Name: status.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %04d_%s: %w", status.Version, status.Name, err)
+		}
+		applied = append(applied, status.Migration)
+	}
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the ones reverted.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("the amount of migrations to revert must be at least 1")
+	}
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+	reverted := []Migration{}
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(status.down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, "version = ?", status.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("failed to revert migration %04d_%s: %w", status.Version, status.Name, err)
+		}
+		reverted = append(reverted, status.Migration)
+	}
+	return reverted, nil
+}
+
+// Statuses returns every migration, sorted by version, with the moment it was applied.
+func Statuses(db *gorm.DB) ([]Status, error) {
+	migrations, err := All(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []Status{}
+	for _, migration := range migrations {
+		status := Status{Migration: migration}
+		if row, found := applied[migration.Version]; found {
+			status.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Baseline records the migrations up to version as applied, without running them.
+// It is meant for databases whose schema was created before the migrations existed.
+func Baseline(db *gorm.DB, version uint) error {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, status := range statuses {
+			if status.Version > version || status.AppliedAt != nil {
+				continue
+			}
+			err := tx.Create(&schemaMigration{Version: status.Version, Name: status.Name, AppliedAt: time.Now()}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// appliedMigrations reads the schema_migrations table, creating it if it does not exist.
+func appliedMigrations(db *gorm.DB) (map[uint]schemaMigration, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		if err := db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, err
+		}
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := map[uint]schemaMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// parseFileName splits a file name like 0001_initial_schema.up.sql into its version, name and direction.
+func parseFileName(fileName string) (uint, string, string, error) {
+	base, found := strings.CutSuffix(fileName, ".sql")
+	direction := path.Ext(base)
+	base = strings.TrimSuffix(base, direction)
+	direction = strings.TrimPrefix(direction, ".")
+	rawVersion, name, hasName := strings.Cut(base, "_")
+	version, err := strconv.ParseUint(rawVersion, 10, 32)
+	if !found || !hasName || err != nil || version == 0 || (direction != "up" && direction != "down") {
+		return 0, "", "", fmt.Errorf("migration file %s must be named like 0001_name.up.sql or 0001_name.down.sql", fileName)
+	}
+	return uint(version), name, direction, nil
+}
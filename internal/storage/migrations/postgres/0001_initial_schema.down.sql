@@ -0,0 +1,9 @@
+DROP TABLE order_events;
+DROP TABLE promo_codes;
+DROP TABLE ice_cream_tub_prices;
+DROP TABLE ice_cream_tubs;
+DROP TABLE flavors;
+DROP TABLE flavor_categories;
+DROP TABLE orders;
+DROP TABLE delivery_drivers;
+DROP TABLE users;
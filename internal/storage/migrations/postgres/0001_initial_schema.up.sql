@@ -0,0 +1,105 @@
+CREATE TABLE users (
+    id          bigserial PRIMARY KEY,
+    email       text NOT NULL,
+    name        text NOT NULL,
+    last_name   text NOT NULL,
+    password    text NOT NULL,
+    permissions jsonb DEFAULT '[]',
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE delivery_drivers (
+    user_id  bigint PRIMARY KEY,
+    cuil     text NOT NULL,
+    age      bigint NOT NULL,
+    vehicles jsonb NOT NULL,
+    CONSTRAINT uni_delivery_drivers_cuil UNIQUE (cuil),
+    CONSTRAINT chk_delivery_drivers_age CHECK (age > 17)
+);
+
+CREATE TABLE orders (
+    id                    bigserial PRIMARY KEY,
+    address               text NOT NULL,
+    user_id               bigint NOT NULL,
+    delivery_driver_id    bigint,
+    payment_state         text NOT NULL,
+    payment_reference     text,
+    status                text NOT NULL DEFAULT 'draft',
+    promo_code            text,
+    subtotal_amount       bigint NOT NULL DEFAULT 0,
+    subtotal_currency     varchar(3) NOT NULL DEFAULT '',
+    discount_amount       bigint NOT NULL DEFAULT 0,
+    discount_currency     varchar(3) NOT NULL DEFAULT '',
+    total_cost_amount     bigint NOT NULL DEFAULT 0,
+    total_cost_currency   varchar(3) NOT NULL DEFAULT '',
+    cancelled_by          bigint,
+    cancellation_reason   text,
+    delivery_window_start timestamptz,
+    delivery_window_end   timestamptz,
+    CONSTRAINT fk_users_orders FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_orders_delivery_start ON orders (delivery_window_start);
+CREATE INDEX idx_orders_promo_code ON orders (promo_code);
+CREATE INDEX idx_orders_status ON orders (status);
+
+CREATE TABLE flavor_categories (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    sort_order bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE flavors (
+    id                 text PRIMARY KEY,
+    name               text NOT NULL,
+    category_id        text NOT NULL,
+    stock              bigint NOT NULL DEFAULT 0,
+    retired            boolean NOT NULL DEFAULT false,
+    available_from     text,
+    available_until    text,
+    available_weekdays jsonb DEFAULT '[]',
+    allergens          jsonb DEFAULT '[]',
+    diets              jsonb DEFAULT '[]'
+);
+CREATE INDEX idx_flavors_category_id ON flavors (category_id);
+
+CREATE TABLE ice_cream_tubs (
+    id                  bigserial PRIMARY KEY,
+    weight              bigint NOT NULL,
+    flavor              jsonb,
+    order_id            bigint NOT NULL,
+    unit_price_amount   bigint NOT NULL DEFAULT 0,
+    unit_price_currency varchar(3) NOT NULL DEFAULT '',
+    CONSTRAINT fk_orders_ice_cream_tubs FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+
+CREATE TABLE ice_cream_tub_prices (
+    weight         bigint PRIMARY KEY,
+    price_amount   bigint NOT NULL DEFAULT 0,
+    price_currency varchar(3) NOT NULL DEFAULT '',
+    max_flavors    bigint NOT NULL DEFAULT 4
+);
+
+CREATE TABLE promo_codes (
+    code                     text PRIMARY KEY,
+    kind                     text NOT NULL,
+    percentage               bigint,
+    fixed_amount             bigint NOT NULL DEFAULT 0,
+    fixed_currency           varchar(3) NOT NULL DEFAULT '',
+    free_tub_weight          bigint,
+    valid_from               text,
+    valid_until              text,
+    max_uses                 bigint,
+    max_uses_per_user        bigint,
+    min_order_total_amount   bigint NOT NULL DEFAULT 0,
+    min_order_total_currency varchar(3) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE order_events (
+    id         bigserial PRIMARY KEY,
+    order_id   bigint NOT NULL,
+    kind       text NOT NULL,
+    actor_id   bigint,
+    created_at timestamptz NOT NULL,
+    payload    jsonb DEFAULT '{}'
+);
+CREATE INDEX idx_order_events_order_id ON order_events (order_id);
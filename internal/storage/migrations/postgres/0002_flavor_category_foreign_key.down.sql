@@ -0,0 +1 @@
+ALTER TABLE flavors DROP CONSTRAINT fk_flavor_categories_flavors;
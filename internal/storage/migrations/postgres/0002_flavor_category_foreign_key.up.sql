@@ -0,0 +1,2 @@
+ALTER TABLE flavors
+    ADD CONSTRAINT fk_flavor_categories_flavors FOREIGN KEY (category_id) REFERENCES flavor_categories (id);
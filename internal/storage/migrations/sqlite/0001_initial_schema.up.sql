@@ -0,0 +1,105 @@
+CREATE TABLE users (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    email       text NOT NULL,
+    name        text NOT NULL,
+    last_name   text NOT NULL,
+    password    text NOT NULL,
+    permissions text DEFAULT '[]',
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE delivery_drivers (
+    user_id  integer PRIMARY KEY,
+    cuil     text NOT NULL,
+    age      integer NOT NULL,
+    vehicles text NOT NULL,
+    CONSTRAINT uni_delivery_drivers_cuil UNIQUE (cuil),
+    CONSTRAINT chk_delivery_drivers_age CHECK (age > 17)
+);
+
+CREATE TABLE orders (
+    id                    integer PRIMARY KEY AUTOINCREMENT,
+    address               text NOT NULL,
+    user_id               integer NOT NULL,
+    delivery_driver_id    integer,
+    payment_state         text NOT NULL,
+    payment_reference     text,
+    status                text NOT NULL DEFAULT 'draft',
+    promo_code            text,
+    subtotal_amount       integer NOT NULL DEFAULT 0,
+    subtotal_currency     text NOT NULL DEFAULT '',
+    discount_amount       integer NOT NULL DEFAULT 0,
+    discount_currency     text NOT NULL DEFAULT '',
+    total_cost_amount     integer NOT NULL DEFAULT 0,
+    total_cost_currency   text NOT NULL DEFAULT '',
+    cancelled_by          integer,
+    cancellation_reason   text,
+    delivery_window_start datetime,
+    delivery_window_end   datetime,
+    CONSTRAINT fk_users_orders FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_orders_delivery_start ON orders (delivery_window_start);
+CREATE INDEX idx_orders_promo_code ON orders (promo_code);
+CREATE INDEX idx_orders_status ON orders (status);
+
+CREATE TABLE flavor_categories (
+    id         text PRIMARY KEY,
+    name       text NOT NULL,
+    sort_order integer NOT NULL DEFAULT 0
+);
+
+CREATE TABLE flavors (
+    id                 text PRIMARY KEY,
+    name               text NOT NULL,
+    category_id        text NOT NULL,
+    stock              integer NOT NULL DEFAULT 0,
+    retired            numeric NOT NULL DEFAULT false,
+    available_from     text,
+    available_until    text,
+    available_weekdays text DEFAULT '[]',
+    allergens          text DEFAULT '[]',
+    diets              text DEFAULT '[]'
+);
+CREATE INDEX idx_flavors_category_id ON flavors (category_id);
+
+CREATE TABLE ice_cream_tubs (
+    id                  integer PRIMARY KEY AUTOINCREMENT,
+    weight              integer NOT NULL,
+    flavor              text,
+    order_id            integer NOT NULL,
+    unit_price_amount   integer NOT NULL DEFAULT 0,
+    unit_price_currency text NOT NULL DEFAULT '',
+    CONSTRAINT fk_orders_ice_cream_tubs FOREIGN KEY (order_id) REFERENCES orders (id)
+);
+
+CREATE TABLE ice_cream_tub_prices (
+    weight         integer PRIMARY KEY,
+    price_amount   integer NOT NULL DEFAULT 0,
+    price_currency text NOT NULL DEFAULT '',
+    max_flavors    integer NOT NULL DEFAULT 4
+);
+
+CREATE TABLE promo_codes (
+    code                     text PRIMARY KEY,
+    kind                     text NOT NULL,
+    percentage               integer,
+    fixed_amount             integer NOT NULL DEFAULT 0,
+    fixed_currency           text NOT NULL DEFAULT '',
+    free_tub_weight          integer,
+    valid_from               text,
+    valid_until              text,
+    max_uses                 integer,
+    max_uses_per_user        integer,
+    min_order_total_amount   integer NOT NULL DEFAULT 0,
+    min_order_total_currency text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE order_events (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    order_id   integer NOT NULL,
+    kind       text NOT NULL,
+    actor_id   integer,
+    created_at datetime NOT NULL,
+    payload    text DEFAULT '{}'
+);
+CREATE INDEX idx_order_events_order_id ON order_events (order_id);
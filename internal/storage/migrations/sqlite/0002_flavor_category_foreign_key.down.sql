@@ -0,0 +1,16 @@
+CREATE TABLE flavors_without_category (
+    id                 text PRIMARY KEY,
+    name               text NOT NULL,
+    category_id        text NOT NULL,
+    stock              integer NOT NULL DEFAULT 0,
+    retired            numeric NOT NULL DEFAULT false,
+    available_from     text,
+    available_until    text,
+    available_weekdays text DEFAULT '[]',
+    allergens          text DEFAULT '[]',
+    diets              text DEFAULT '[]'
+);
+INSERT INTO flavors_without_category SELECT id, name, category_id, stock, retired, available_from, available_until, available_weekdays, allergens, diets FROM flavors;
+DROP TABLE flavors;
+ALTER TABLE flavors_without_category RENAME TO flavors;
+CREATE INDEX idx_flavors_category_id ON flavors (category_id);
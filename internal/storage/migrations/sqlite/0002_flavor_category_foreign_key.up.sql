@@ -0,0 +1,18 @@
+-- SQLite cannot add a constraint to a table, so flavors is rebuilt with it
+CREATE TABLE flavors_with_category (
+    id                 text PRIMARY KEY,
+    name               text NOT NULL,
+    category_id        text NOT NULL,
+    stock              integer NOT NULL DEFAULT 0,
+    retired            numeric NOT NULL DEFAULT false,
+    available_from     text,
+    available_until    text,
+    available_weekdays text DEFAULT '[]',
+    allergens          text DEFAULT '[]',
+    diets              text DEFAULT '[]',
+    CONSTRAINT fk_flavor_categories_flavors FOREIGN KEY (category_id) REFERENCES flavor_categories (id)
+);
+INSERT INTO flavors_with_category SELECT id, name, category_id, stock, retired, available_from, available_until, available_weekdays, allergens, diets FROM flavors;
+DROP TABLE flavors;
+ALTER TABLE flavors_with_category RENAME TO flavors;
+CREATE INDEX idx_flavors_category_id ON flavors (category_id);
@@ -1,84 +1,181 @@
 package storage
 
 import (
+	"context"
 	"icecreamshop/internal/types"
+	"time"
 )
 
 // Storage interface declares the methods needed for the api to work with de database.
+// Every method but Close receives the context of the request it serves, and database queries are cancelled once it is done.
 type Storage interface {
-	// GetFlavors obtains all flavors.
-	GetFlavors() []types.Flavor
-	// GetFlavorsByType obtains all flavors filtered by type
-	GetFlavorsByType(kind string) []types.Flavor
-	// GetFlavorByID obtains a flavor by its ID
-	GetFlavorByID(idFlavor string) (types.Flavor, error)
-	// AddFlavor adds a new flavor
-	AddFlavor(flavor types.Flavor) error
+	// GetFlavors obtains all flavors that are not retired.
+	GetFlavors(ctx context.Context) []types.Flavor
+	// GetFlavorsByType obtains all flavors that are not retired filtered by category id
+	GetFlavorsByType(ctx context.Context, categoryID string) []types.Flavor
+	// GetFlavorByID obtains a flavor by its ID, even if it is retired.
+	GetFlavorByID(ctx context.Context, idFlavor string) (types.Flavor, error)
+	// AddFlavor adds a new flavor. Its category must exist.
+	AddFlavor(ctx context.Context, flavor types.Flavor) error
+	// UpdateFlavor updates the name, category, availability, allergens and diets of a flavor by its ID.
+	// The new category must exist.
+	UpdateFlavor(ctx context.Context, idFlavor string, flavor types.Flavor) (types.Flavor, error)
+	// RetireFlavor takes a flavor off the menu by its ID.
+	// Retired flavors are kept so old orders can still reference them, but they cannot be added to new tubs.
+	RetireFlavor(ctx context.Context, idFlavor string) error
+	// UpdateFlavorStock sets the stock of a flavor by its ID. Stock is measured in grams.
+	UpdateFlavorStock(ctx context.Context, idFlavor string, stock uint) (types.Flavor, error)
+	// GetLowStockFlavors obtains all flavors that are not retired and whose stock is lower than the threshold (in grams).
+	GetLowStockFlavors(ctx context.Context, threshold uint) []types.Flavor
+
+	// GetFlavorCategories obtains all flavor categories sorted by their sort order.
+	GetFlavorCategories(ctx context.Context) []types.FlavorCategory
+	// GetFlavorCategoryByID obtains a flavor category by its ID.
+	GetFlavorCategoryByID(ctx context.Context, idCategory string) (types.FlavorCategory, error)
+	// AddFlavorCategory adds a new flavor category.
+	AddFlavorCategory(ctx context.Context, category types.FlavorCategory) error
+	// UpdateFlavorCategory updates the name and sort order of a flavor category by its ID.
+	UpdateFlavorCategory(ctx context.Context, idCategory string, category types.FlavorCategory) (types.FlavorCategory, error)
+	// DeleteFlavorCategory deletes a flavor category by its ID.
+	// A category cannot be deleted while any flavor, even a retired one, belongs to it.
+	DeleteFlavorCategory(ctx context.Context, idCategory string) error
+
+	// GetPrices obtains all tub sizes on sale sorted by weight.
+	GetPrices(ctx context.Context) []types.IceCreamTubPrice
+	// GetPriceByWeight obtains the price of a tub size by its weight.
+	GetPriceByWeight(ctx context.Context, weight uint) (types.IceCreamTubPrice, error)
+	// AddPrice adds a new tub size.
+	AddPrice(ctx context.Context, price types.IceCreamTubPrice) error
+	// UpdatePrice updates the price and max amount of flavors of a tub size by its weight.
+	UpdatePrice(ctx context.Context, weight uint, price types.IceCreamTubPrice) (types.IceCreamTubPrice, error)
+	// DeletePrice deletes a tub size by its weight, so it can no longer be ordered.
+	DeletePrice(ctx context.Context, weight uint) error
+
+	// GetPromoCodes obtains all promo codes.
+	GetPromoCodes(ctx context.Context) []types.PromoCode
+	// GetPromoCodeByCode obtains a promo code by its code.
+	GetPromoCodeByCode(ctx context.Context, code string) (types.PromoCode, error)
+	// AddPromoCode adds a new promo code.
+	AddPromoCode(ctx context.Context, promo types.PromoCode) error
+	// UpdatePromoCode updates all the fields of a promo code but its code.
+	// Orders that use it get their totals recomputed while they wait to be paid, see types.Order.IsRepriceable.
+	UpdatePromoCode(ctx context.Context, code string, promo types.PromoCode) (types.PromoCode, error)
+	// DeletePromoCode deletes a promo code. Codes already applied to orders cannot be deleted.
+	DeletePromoCode(ctx context.Context, code string) error
+	// ApplyPromoCodeToOrder applies a promo code to an unpaid order that is not cancelled at a given moment and recomputes its totals.
+	// An order can have only one promo code, and the code must be valid and within its usage limits.
+	ApplyPromoCodeToOrder(ctx context.Context, idOrder uint, code string, moment time.Time) (types.Order, error)
 
 	// GetAllOrders obtains all orders from all users
-	GetAllOrders() []types.Order
+	GetAllOrders(ctx context.Context) []types.Order
+	// GetOrdersByStatus obtains all orders from all users whose fulfillment status is one of the given ones.
+	GetOrdersByStatus(ctx context.Context, statuses ...string) []types.Order
+	// GetOrdersByDeliveryDriverID obtains all orders assigned to a delivery driver by their user id.
+	GetOrdersByDeliveryDriverID(ctx context.Context, idUser uint) []types.Order
+	// GetOrdersScheduledFor obtains the orders whose delivery window starts on a date, with the format YYYY-MM-DD.
+	// Orders are sorted by the start of their window, and cancelled orders are left out.
+	GetOrdersScheduledFor(ctx context.Context, date string) []types.Order
+	// GetAssignableOrders obtains the orders a delivery driver can take at a given moment, see types.Order.IsAssignableAt.
+	GetAssignableOrders(ctx context.Context, moment time.Time) []types.Order
 	// GetAllOrdersByUserEmail obtains all orders from an user by their email
-	GetAllOrdersByUserEmail(email string) []types.Order
-	// CreateOrder creates a new order for an user.
-	// The order struct inputted must include the user id.
-	CreateOrder(order *types.Order) error
+	GetAllOrdersByUserEmail(ctx context.Context, email string) []types.Order
+	// CreateOrder creates a new draft order for an user, without promo code, with the tubs included in the order struct.
+	// Tubs are validated and priced as in AddIceCreamTubByOrderID. If any of them is rejected, nothing is saved
+	// and a *types.InvalidTubsError lists every rejected tub. The order struct inputted must include the user id.
+	CreateOrder(ctx context.Context, order *types.Order) error
 	// GetOrderByID obtains an order by its id.
-	GetOrderByID(idOrder uint) (types.Order, error)
+	GetOrderByID(ctx context.Context, idOrder uint) (types.Order, error)
 	// GetUserOrderByID obtains an order from an user.
 	// Method checks if the user is the order's owner. Otherwise, it will return an error.
-	GetUserOrderByID(orderID uint, userID uint) (types.Order, error)
-	// UpdateOrderByID updates an order by its id.
-	// The order struct inputted must include the new data, but it does not need the order id
-	UpdateOrderByID(idOrder uint, order *types.Order) (types.Order, error)
+	GetUserOrderByID(ctx context.Context, orderID uint, userID uint) (types.Order, error)
+	// UpdateOrderByID updates the address and the delivery window of an order by its id.
+	// The order struct inputted must include the new data, but it does not need the order id.
+	// The payment state is not updated, it can only be changed with MarkOrderAsPaid.
+	UpdateOrderByID(ctx context.Context, idOrder uint, order *types.Order) (types.Order, error)
+	// StartOrderPayment sets the payment state of a payable order to processing before its customer is charged, see types.Order.CheckPayable,
+	// and returns the order with the total to charge. Orders being paid cannot change their tubs or promo code, nor start another payment.
+	StartOrderPayment(ctx context.Context, idOrder uint) (types.Order, error)
+	// AbortOrderPayment sets the payment state of an order being paid back to pending, when its customer could not be charged.
+	// Orders that are not being paid are left as they are.
+	AbortOrderPayment(ctx context.Context, idOrder uint) error
+	// MarkOrderAsPaid sets the payment state of an order being paid to paid, once its customer has been charged the amount.
+	// The amount must be the total of the order, see types.Order.CheckPaidWith. The payment reference is kept to refund the order if it is cancelled.
+	MarkOrderAsPaid(ctx context.Context, idOrder uint, paymentReference string, amount types.Money) error
+	// CancelOrder cancels an order, recording who cancelled it and why, and releases its delivery driver.
+	// The stock of its tubs is released if the preparation has not started.
+	// It returns the cancelled order and the payment state it had: paid orders are left refund pending,
+	// and only the caller that cancelled them must issue the refund and then call MarkOrderAsRefunded.
+	CancelOrder(ctx context.Context, idOrder uint, cancellation types.OrderCancellation) (types.Order, string, error)
+	// MarkOrderAsRefunded sets the payment state of a cancelled order from refund pending to refunded, once the refund has been issued,
+	// on behalf of the user with the actor id. The refund reference is recorded in the history of the order.
+	MarkOrderAsRefunded(ctx context.Context, idOrder uint, refundReference string, actorID uint) (types.Order, error)
+	// UpdateOrderStatus moves an order to a new fulfillment status, on behalf of the user with the actor id.
+	// Only the transitions allowed by the order state machine are accepted, and an order needs tubs to be placed.
+	UpdateOrderStatus(ctx context.Context, idOrder uint, status string, actorID uint) (types.Order, error)
+	// GetOrderHistory obtains the events of an order sorted from oldest to newest.
+	// Every change of an order appends an event; changes made by its owner, like adding tubs or paying, are recorded with the owner as actor.
+	GetOrderHistory(ctx context.Context, idOrder uint) ([]types.OrderEvent, error)
 	// GetIceCreamTubsByOrderID obtains all ice cream tubs from an order by its id.
-	GetIceCreamTubsByOrderID(idOrder uint) ([]types.IceCreamTub, error)
-	// AddIceCreamTubByOrderID adds a new ice cream tub to an order by its id.
-	AddIceCreamTubByOrderID(idOrder uint, iceCreamTub *types.IceCreamTub) error
-	// DeleteIceCreamTubByOrderID deletes an ice cream tub from an order.
-	DeleteIceCreamTubByOrderID(tubID uint, orderID uint) error
+	GetIceCreamTubsByOrderID(ctx context.Context, idOrder uint) ([]types.IceCreamTub, error)
+	// AddIceCreamTubByOrderID adds a new ice cream tub to an unpaid draft order by its id.
+	// Flavors must be available at the moment of adding the tub, and cannot be more than the max allowed for its weight.
+	// The tub weight is reserved from the stock of its flavors.
+	AddIceCreamTubByOrderID(ctx context.Context, idOrder uint, iceCreamTub *types.IceCreamTub) error
+	// DeleteIceCreamTubByOrderID deletes an ice cream tub from an unpaid draft order.
+	// The tub weight is released back to the stock of its flavors.
+	DeleteIceCreamTubByOrderID(ctx context.Context, tubID uint, orderID uint) error
 
 	// GetDeliveryDrivers obtains all delivery drivers.
-	GetDeliveryDrivers() []types.DeliveryDriver
+	GetDeliveryDrivers(ctx context.Context) []types.DeliveryDriver
 	// AddDeliveryDriver adds a new delivery driver.
-	AddDeliveryDriver(deliveryDriver *types.DeliveryDriver) error
+	AddDeliveryDriver(ctx context.Context, deliveryDriver *types.DeliveryDriver) error
 	// GetDeliveryDriverByID obtains a delivery driver by their user id.
-	GetDeliveryDriverByID(idUser uint) (types.DeliveryDriver, error)
+	GetDeliveryDriverByID(ctx context.Context, idUser uint) (types.DeliveryDriver, error)
 	// UpdateDeliveryDriverByID updates a delivery driver by their user id.
 	// The delivery driver struct inputted must include the new data, but it does not need the user id.
-	UpdateDeliveryDriverByID(idUser uint, deliveryDriver *types.DeliveryDriver) error
+	UpdateDeliveryDriverByID(ctx context.Context, idUser uint, deliveryDriver *types.DeliveryDriver) error
 	// DeleteDeliveryDriverByID deletes a delivery driver by their user id.
-	DeleteDeliveryDriverByID(idUser uint) error
+	DeleteDeliveryDriverByID(ctx context.Context, idUser uint) error
 	// GetVehiclesByDeliveryDriverID obtains all vehicles from a delivery driver by their id.
-	GetVehiclesByDeliveryDriverID(idUser uint) ([]string, error)
-	// AssignDeliveryDriverToOrder assigns a delivery driver id to an order.
-	AssignDeliveryDriverToOrder(orderID uint, deliveryDriverID uint) error
-	// DeleteDeliveryDriverFromOrder deletes the delivery driver id from an order.
+	GetVehiclesByDeliveryDriverID(ctx context.Context, idUser uint) ([]string, error)
+	// AssignDeliveryDriverToOrder assigns a delivery driver id to an order, on behalf of the user with the actor id.
+	AssignDeliveryDriverToOrder(ctx context.Context, orderID uint, deliveryDriverID uint, actorID uint) error
+	// DeleteDeliveryDriverFromOrder deletes the delivery driver id from an order, on behalf of the user with the actor id.
 	// No delivery driver id assigned is represented by zero.
-	DeleteDeliveryDriverFromOrder(idOrder uint) error
+	DeleteDeliveryDriverFromOrder(ctx context.Context, idOrder uint, actorID uint) error
 	// GetDeliveryDriverFromOrder obtains the delivery driver id assigned to an order.
-	GetDeliveryDriverFromOrder(idOrder uint) (uint, error)
+	GetDeliveryDriverFromOrder(ctx context.Context, idOrder uint) (uint, error)
 
 	// SignUpUser signs up a new user.
-	SignUpUser(newUser *types.User) error
+	SignUpUser(ctx context.Context, newUser *types.User) error
 	// LogInUser logs in an user by inputting their email and password.
 	// If successful, error will be nil.
-	LogInUser(email string, password string) error
+	LogInUser(ctx context.Context, email string, password string) error
 	// GetUserByEmail obtains an user by its email.
-	GetUserByEmail(email string) (types.User, error)
+	GetUserByEmail(ctx context.Context, email string) (types.User, error)
 	// GetAllUsers obtains all users.
-	GetAllUsers() []types.User
+	GetAllUsers(ctx context.Context) []types.User
 	// GetUserByID obtains an user by its id.
-	GetUserByID(userID uint) (types.User, error)
+	GetUserByID(ctx context.Context, userID uint) (types.User, error)
 	// DeleteUserByID delete an user by its id.
-	DeleteUserByID(userID uint) error
+	DeleteUserByID(ctx context.Context, userID uint) error
 	// UpdateUser updates an user.
 	// The user struct inputted must include the user id to change.
-	UpdateUser(updatedUser types.User) (types.User, error)
+	UpdateUser(ctx context.Context, updatedUser types.User) (types.User, error)
+	// UpdateUserPassword replaces the password of an user by its id. The password is saved hashed.
+	UpdateUserPassword(ctx context.Context, userID uint, password string) error
 	// PromoteUserToAdmin promotes an user to admin by its id.
-	PromoteUserToAdmin(idUser uint) error
+	PromoteUserToAdmin(ctx context.Context, idUser uint) error
+	// PromoteUserToStaff gives an user by its id the staff permission, so they can prepare orders.
+	PromoteUserToStaff(ctx context.Context, idUser uint) error
 
+	// WithinTransaction runs several operations as a single unit of work, using the storage fn receives.
+	// If fn returns an error, none of the changes made through that storage are kept and the error is returned.
+	// Compound operations, like adding a tub and updating the order totals, already run as a unit of work each,
+	// and lock the order they change until they finish, so concurrent changes to an order are applied one after another.
+	WithinTransaction(ctx context.Context, fn func(store Storage) error) error
 	// Close closes db connection if needed.
 	Close() error
 	// CleanDB cleans db data completely, only for testing.
-	CleanDB() error
+	CleanDB(ctx context.Context) error
 }
@@ -0,0 +1,2270 @@
+// Package storagetest checks that an implementation of storage.Storage behaves as the interface describes.
+// Every backend runs the same suite, so differences between them show up as failing tests instead of bugs in the api.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Factory builds the storage to check, for a store with the given settings and filled with the given data.
+// It is called once for every test, and the storage is cleaned and closed when the test ends.
+type Factory func(settings types.StoreSettings, categories []types.FlavorCategory, flavors []types.Flavor, users []types.User, prices []types.IceCreamTubPrice) storage.Storage
+
+// Run checks every method of the storages built by factory, each test as a subtest named after the method it checks.
+func Run(t *testing.T, factory Factory) {
+	s := suite{factory: factory}
+	t.Run("Flavors", s.flavors)
+	t.Run("FlavorCategories", s.flavorCategories)
+	t.Run("Prices", s.prices)
+	t.Run("PromoCodes", s.promoCodes)
+	t.Run("Orders", s.orders)
+	t.Run("OrderStatus", s.orderStatus)
+	t.Run("IceCreamTubs", s.iceCreamTubs)
+	t.Run("DeliveryDrivers", s.deliveryDrivers)
+	t.Run("Users", s.users)
+	t.Run("Transactions", s.transactions)
+}
+
+// ctx is the context of every storage call made by the suite.
+var ctx = context.Background()
+
+// storeSettings are the settings of the store of every storage, whose prices are in ARS.
+var storeSettings = types.DefaultStoreSettings()
+
+// Ids of the users the storages are filled with.
+const (
+	adminID   uint = 1
+	genericID uint = 2
+	missingID uint = 100000
+)
+
+// adminPassword is the password of both users the storages are filled with.
+const adminPassword = "admin123"
+
+type suite struct {
+	factory Factory
+}
+
+// run runs a test with a new storage, filled with the fixtures of the suite.
+func (s suite) run(t *testing.T, name string, test func(t *testing.T, store storage.Storage)) {
+	t.Run(name, func(t *testing.T) {
+		categories, flavors, users, prices := fixtures()
+		store := s.factory(storeSettings, categories, flavors, users, prices)
+		t.Cleanup(func() {
+			assert.NoError(t, store.CleanDB(ctx))
+			assert.NoError(t, store.Close())
+		})
+		test(t, store)
+	})
+}
+
+// fixtures builds the data every storage is filled with. Slices are new on every call, since storages may change them.
+func fixtures() ([]types.FlavorCategory, []types.Flavor, []types.User, []types.IceCreamTubPrice) {
+	categories := []types.FlavorCategory{
+		{ID: "dulce-de-leches", Name: "Dulce de leches", SortOrder: 1},
+		{ID: "chocolates", Name: "Chocolates", SortOrder: 2},
+		{ID: "cremas", Name: "Cremas", SortOrder: 3},
+		{ID: "al-agua", Name: "Al agua", SortOrder: 4},
+	}
+	flavors := []types.Flavor{
+		{ID: "ddl", Name: "Dulce de leche", CategoryID: "dulce-de-leches", Stock: 10000},
+		{ID: "mrc", Name: "Chocolate marroc", CategoryID: "chocolates", Stock: 10000},
+		{ID: "trm", Name: "Tramontana", CategoryID: "cremas", Stock: 10000},
+		{ID: "frt", Name: "Frutilla al agua", CategoryID: "al-agua", Stock: 10000},
+	}
+	users := []types.User{
+		{
+			ID:          adminID,
+			Email:       "abcde@gmail.com",
+			Name:        "abcde",
+			LastName:    "xyz",
+			Password:    "$2a$10$xQy8YTOUh6GST9zO1cfmZeV4iPi1I5TLEr5WnTE7Y/XNHgLbqEeFO", //hash for "admin123"
+			Orders:      []types.Order{},
+			Permissions: []string{"admin"},
+		},
+		{
+			ID:          genericID,
+			Email:       "zzzzz@gmail.com",
+			Name:        "hello",
+			LastName:    "world",
+			Password:    "$2a$10$xQy8YTOUh6GST9zO1cfmZeV4iPi1I5TLEr5WnTE7Y/XNHgLbqEeFO", //hash for "admin123"
+			Orders:      []types.Order{},
+			Permissions: []string{},
+		},
+	}
+	prices := []types.IceCreamTubPrice{
+		{Weight: 250, Price: ars(300), MaxFlavors: 3},
+		{Weight: 500, Price: ars(500), MaxFlavors: 3},
+		{Weight: 1000, Price: ars(1000), MaxFlavors: 4},
+	}
+	return categories, flavors, users, prices
+}
+
+// Tubs that can be added to any order of the storages filled with the fixtures.
+var (
+	halfKiloTub    = types.IceCreamTub{Weight: 500, Flavors: []string{"ddl", "frt"}}
+	quarterKiloTub = types.IceCreamTub{Weight: 250, Flavors: []string{"ddl"}}
+)
+
+var genericDeliveryDriver = types.DeliveryDriver{
+	UserID:   genericID,
+	Cuil:     "0123456789",
+	Age:      24,
+	Vehicles: []string{"ABC123"},
+}
+
+func ars(amount int64) types.Money {
+	return types.NewMoney(amount, "ARS")
+}
+
+/*******************/
+/***** FLAVORS *****/
+/*******************/
+
+func (s suite) flavors(t *testing.T) {
+	s.run(t, "GetFlavors", func(t *testing.T, store storage.Storage) {
+		assert.ElementsMatch(t, []string{"ddl", "mrc", "trm", "frt"}, flavorIDs(store.GetFlavors(ctx)))
+	})
+	s.run(t, "GetFlavors/LeavesRetiredFlavorsOut", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.RetireFlavor(ctx, "mrc"))
+
+		assert.ElementsMatch(t, []string{"ddl", "trm", "frt"}, flavorIDs(store.GetFlavors(ctx)))
+	})
+	s.run(t, "GetFlavorsByType", func(t *testing.T, store storage.Storage) {
+		assert.Equal(t, []string{"mrc"}, flavorIDs(store.GetFlavorsByType(ctx, "chocolates")))
+		assert.Empty(t, store.GetFlavorsByType(ctx, "non-existing-category"))
+	})
+	s.run(t, "GetFlavorsByType/LeavesRetiredFlavorsOut", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.RetireFlavor(ctx, "mrc"))
+
+		assert.Empty(t, store.GetFlavorsByType(ctx, "chocolates"))
+	})
+	s.run(t, "GetFlavorByID", func(t *testing.T, store storage.Storage) {
+		flavor, err := store.GetFlavorByID(ctx, "mrc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Chocolate marroc", flavor.Name)
+		assert.Equal(t, "chocolates", flavor.CategoryID)
+		assert.Equal(t, uint(10000), flavor.Stock)
+	})
+	s.run(t, "GetFlavorByID/FindsRetiredFlavors", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.RetireFlavor(ctx, "mrc"))
+
+		flavor, err := store.GetFlavorByID(ctx, "mrc")
+
+		assert.NoError(t, err)
+		assert.True(t, flavor.Retired)
+	})
+	s.run(t, "GetFlavorByID/NonExistingFlavor", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetFlavorByID(ctx, "non-existing-flavor")
+
+		assert.EqualError(t, err, messageErrors.FlavorNotFound)
+	})
+	s.run(t, "AddFlavor", func(t *testing.T, store storage.Storage) {
+		newFlavor := types.Flavor{ID: "alm", Name: "Almendrado", CategoryID: "cremas", Stock: 500, Allergens: []string{"lactose", "nuts"}, Diets: []string{"gluten-free"}}
+
+		err := store.AddFlavor(ctx, newFlavor)
+		flavor, _ := store.GetFlavorByID(ctx, "alm")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Almendrado", flavor.Name)
+		assert.Equal(t, uint(500), flavor.Stock)
+		assert.Equal(t, []string{"lactose", "nuts"}, flavor.Allergens)
+		assert.Equal(t, []string{"gluten-free"}, flavor.Diets)
+		assert.Len(t, store.GetFlavors(ctx), 5)
+	})
+	s.run(t, "AddFlavor/ExistingFlavor", func(t *testing.T, store storage.Storage) {
+		err := store.AddFlavor(ctx, types.Flavor{ID: "ddl", Name: "Dulce de leche", CategoryID: "dulce-de-leches"})
+
+		assert.EqualError(t, err, messageErrors.AlreadyExistingFlavor)
+		assert.Len(t, store.GetFlavors(ctx), 4)
+	})
+	s.run(t, "AddFlavor/NonExistingCategory", func(t *testing.T, store storage.Storage) {
+		err := store.AddFlavor(ctx, types.Flavor{ID: "alm", Name: "Almendrado", CategoryID: "non-existing-category"})
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryNotFound)
+		assert.Len(t, store.GetFlavors(ctx), 4)
+	})
+	s.run(t, "UpdateFlavor", func(t *testing.T, store storage.Storage) {
+		update := types.Flavor{
+			Name:              "Dulce de leche granizado",
+			CategoryID:        "cremas",
+			AvailableFrom:     "2024-01-01",
+			AvailableUntil:    "2024-03-01",
+			AvailableWeekdays: []string{"friday"},
+			Allergens:         []string{"lactose"},
+			Diets:             []string{"gluten-free"},
+			Stock:             1,
+		}
+
+		updated, err := store.UpdateFlavor(ctx, "ddl", update)
+		flavor, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.NoError(t, err)
+		for _, actual := range []types.Flavor{updated, flavor} {
+			assert.Equal(t, "Dulce de leche granizado", actual.Name)
+			assert.Equal(t, "cremas", actual.CategoryID)
+			assert.Equal(t, "2024-01-01", actual.AvailableFrom)
+			assert.Equal(t, "2024-03-01", actual.AvailableUntil)
+			assert.Equal(t, []string{"friday"}, actual.AvailableWeekdays)
+			assert.Equal(t, []string{"lactose"}, actual.Allergens)
+			assert.Equal(t, []string{"gluten-free"}, actual.Diets)
+			assert.Equal(t, uint(10000), actual.Stock, "the stock is only changed through UpdateFlavorStock")
+		}
+	})
+	s.run(t, "UpdateFlavor/NonExistingFlavor", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateFlavor(ctx, "non-existing-flavor", types.Flavor{Name: "Nothing", CategoryID: "cremas"})
+
+		assert.EqualError(t, err, messageErrors.FlavorNotFound)
+	})
+	s.run(t, "UpdateFlavor/NonExistingCategory", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateFlavor(ctx, "ddl", types.Flavor{Name: "Dulce de leche", CategoryID: "non-existing-category"})
+		flavor, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryNotFound)
+		assert.Equal(t, "dulce-de-leches", flavor.CategoryID)
+	})
+	s.run(t, "RetireFlavor", func(t *testing.T, store storage.Storage) {
+		err := store.RetireFlavor(ctx, "ddl")
+		flavor, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.NoError(t, err)
+		assert.True(t, flavor.Retired)
+	})
+	s.run(t, "RetireFlavor/NonExistingFlavor", func(t *testing.T, store storage.Storage) {
+		err := store.RetireFlavor(ctx, "non-existing-flavor")
+
+		assert.EqualError(t, err, messageErrors.FlavorNotFound)
+	})
+	s.run(t, "RetireFlavor/AlreadyRetiredFlavor", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.RetireFlavor(ctx, "ddl"))
+
+		err := store.RetireFlavor(ctx, "ddl")
+
+		assert.EqualError(t, err, messageErrors.FlavorIsAlreadyRetired)
+	})
+	s.run(t, "UpdateFlavorStock", func(t *testing.T, store storage.Storage) {
+		updated, err := store.UpdateFlavorStock(ctx, "mrc", 750)
+		flavor, _ := store.GetFlavorByID(ctx, "mrc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(750), updated.Stock)
+		assert.Equal(t, uint(750), flavor.Stock)
+	})
+	s.run(t, "UpdateFlavorStock/NonExistingFlavor", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateFlavorStock(ctx, "non-existing-flavor", 750)
+
+		assert.EqualError(t, err, messageErrors.FlavorNotFound)
+	})
+	s.run(t, "GetLowStockFlavors", func(t *testing.T, store storage.Storage) {
+		_, _ = store.UpdateFlavorStock(ctx, "mrc", 300)
+		_, _ = store.UpdateFlavorStock(ctx, "frt", 0)
+		_, _ = store.UpdateFlavorStock(ctx, "trm", 1000)
+
+		assert.ElementsMatch(t, []string{"mrc", "frt"}, flavorIDs(store.GetLowStockFlavors(ctx, 1000)))
+		assert.Empty(t, store.GetLowStockFlavors(ctx, 0))
+	})
+	s.run(t, "GetLowStockFlavors/LeavesRetiredFlavorsOut", func(t *testing.T, store storage.Storage) {
+		_, _ = store.UpdateFlavorStock(ctx, "mrc", 300)
+		require.NoError(t, store.RetireFlavor(ctx, "mrc"))
+
+		assert.Empty(t, store.GetLowStockFlavors(ctx, 1000))
+	})
+}
+
+/*****************************/
+/***** FLAVOR CATEGORIES *****/
+/*****************************/
+
+func (s suite) flavorCategories(t *testing.T) {
+	s.run(t, "GetFlavorCategories/SortedBySortOrderAndName", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.AddFlavorCategory(ctx, types.FlavorCategory{ID: "frutales", Name: "Frutales", SortOrder: 0}))
+		require.NoError(t, store.AddFlavorCategory(ctx, types.FlavorCategory{ID: "bombones", Name: "Bombones", SortOrder: 2}))
+
+		categories := store.GetFlavorCategories(ctx)
+
+		ids := []string{}
+		for _, category := range categories {
+			ids = append(ids, category.ID)
+		}
+		assert.Equal(t, []string{"frutales", "dulce-de-leches", "bombones", "chocolates", "cremas", "al-agua"}, ids)
+	})
+	s.run(t, "GetFlavorCategoryByID", func(t *testing.T, store storage.Storage) {
+		category, err := store.GetFlavorCategoryByID(ctx, "chocolates")
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.FlavorCategory{ID: "chocolates", Name: "Chocolates", SortOrder: 2}, category)
+	})
+	s.run(t, "GetFlavorCategoryByID/NonExistingCategory", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetFlavorCategoryByID(ctx, "non-existing-category")
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryNotFound)
+	})
+	s.run(t, "AddFlavorCategory", func(t *testing.T, store storage.Storage) {
+		newCategory := types.FlavorCategory{ID: "frutales", Name: "Frutales", SortOrder: 5}
+
+		err := store.AddFlavorCategory(ctx, newCategory)
+		category, _ := store.GetFlavorCategoryByID(ctx, "frutales")
+
+		assert.NoError(t, err)
+		assert.Equal(t, newCategory, category)
+		assert.Len(t, store.GetFlavorCategories(ctx), 5)
+	})
+	s.run(t, "AddFlavorCategory/ExistingCategory", func(t *testing.T, store storage.Storage) {
+		err := store.AddFlavorCategory(ctx, types.FlavorCategory{ID: "cremas", Name: "Otras cremas"})
+		category, _ := store.GetFlavorCategoryByID(ctx, "cremas")
+
+		assert.EqualError(t, err, messageErrors.AlreadyExistingFlavorCategory)
+		assert.Equal(t, "Cremas", category.Name)
+	})
+	s.run(t, "UpdateFlavorCategory", func(t *testing.T, store storage.Storage) {
+		updated, err := store.UpdateFlavorCategory(ctx, "cremas", types.FlavorCategory{ID: "ignored", Name: "Cremas heladas", SortOrder: 9})
+		category, _ := store.GetFlavorCategoryByID(ctx, "cremas")
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.FlavorCategory{ID: "cremas", Name: "Cremas heladas", SortOrder: 9}, updated)
+		assert.Equal(t, updated, category)
+	})
+	s.run(t, "UpdateFlavorCategory/NonExistingCategory", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateFlavorCategory(ctx, "non-existing-category", types.FlavorCategory{Name: "Nothing"})
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryNotFound)
+	})
+	s.run(t, "DeleteFlavorCategory", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.AddFlavorCategory(ctx, types.FlavorCategory{ID: "frutales", Name: "Frutales"}))
+
+		err := store.DeleteFlavorCategory(ctx, "frutales")
+		_, errGetting := store.GetFlavorCategoryByID(ctx, "frutales")
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.FlavorCategoryNotFound)
+	})
+	s.run(t, "DeleteFlavorCategory/CategoryWithFlavors", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteFlavorCategory(ctx, "chocolates")
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryIsInUse)
+		assert.Len(t, store.GetFlavorCategories(ctx), 4)
+	})
+	s.run(t, "DeleteFlavorCategory/CategoryWithRetiredFlavors", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.RetireFlavor(ctx, "mrc"))
+
+		err := store.DeleteFlavorCategory(ctx, "chocolates")
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryIsInUse)
+	})
+	s.run(t, "DeleteFlavorCategory/NonExistingCategory", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteFlavorCategory(ctx, "non-existing-category")
+
+		assert.EqualError(t, err, messageErrors.FlavorCategoryNotFound)
+	})
+}
+
+/******************/
+/***** PRICES *****/
+/******************/
+
+func (s suite) prices(t *testing.T) {
+	s.run(t, "GetPrices/SortedByWeight", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.AddPrice(ctx, types.IceCreamTubPrice{Weight: 100, Price: ars(150), MaxFlavors: 1}))
+
+		prices := store.GetPrices(ctx)
+
+		weights := []uint{}
+		for _, price := range prices {
+			weights = append(weights, price.Weight)
+		}
+		assert.Equal(t, []uint{100, 250, 500, 1000}, weights)
+	})
+	s.run(t, "GetPriceByWeight", func(t *testing.T, store storage.Storage) {
+		price, err := store.GetPriceByWeight(ctx, 500)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.IceCreamTubPrice{Weight: 500, Price: ars(500), MaxFlavors: 3}, price)
+	})
+	s.run(t, "GetPriceByWeight/NonExistingWeight", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetPriceByWeight(ctx, 123)
+
+		assert.EqualError(t, err, messageErrors.WeightNotAvailable)
+	})
+	s.run(t, "AddPrice", func(t *testing.T, store storage.Storage) {
+		newPrice := types.IceCreamTubPrice{Weight: 2000, Price: ars(1800), MaxFlavors: 5}
+
+		err := store.AddPrice(ctx, newPrice)
+		price, _ := store.GetPriceByWeight(ctx, 2000)
+
+		assert.NoError(t, err)
+		assert.Equal(t, newPrice, price)
+	})
+	s.run(t, "AddPrice/ExistingWeight", func(t *testing.T, store storage.Storage) {
+		err := store.AddPrice(ctx, types.IceCreamTubPrice{Weight: 500, Price: ars(1), MaxFlavors: 1})
+		price, _ := store.GetPriceByWeight(ctx, 500)
+
+		assert.EqualError(t, err, messageErrors.AlreadyExistingPrice)
+		assert.Equal(t, ars(500), price.Price)
+	})
+	s.run(t, "UpdatePrice", func(t *testing.T, store storage.Storage) {
+		updated, err := store.UpdatePrice(ctx, 500, types.IceCreamTubPrice{Weight: 1, Price: ars(650), MaxFlavors: 2})
+		price, _ := store.GetPriceByWeight(ctx, 500)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.IceCreamTubPrice{Weight: 500, Price: ars(650), MaxFlavors: 2}, updated)
+		assert.Equal(t, updated, price)
+	})
+	s.run(t, "UpdatePrice/ChangesTheMaxFlavorsOfNewTubs", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		_, err := store.UpdatePrice(ctx, 250, types.IceCreamTubPrice{Price: ars(300), MaxFlavors: 4})
+		require.NoError(t, err)
+
+		err = store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 250, Flavors: []string{"ddl", "mrc", "trm", "frt"}})
+
+		assert.NoError(t, err)
+	})
+	s.run(t, "UpdatePrice/NonExistingWeight", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdatePrice(ctx, 123, types.IceCreamTubPrice{Price: ars(650), MaxFlavors: 2})
+
+		assert.EqualError(t, err, messageErrors.WeightNotAvailable)
+	})
+	s.run(t, "DeletePrice", func(t *testing.T, store storage.Storage) {
+		err := store.DeletePrice(ctx, 500)
+		_, errGetting := store.GetPriceByWeight(ctx, 500)
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.WeightNotAvailable)
+		assert.Len(t, store.GetPrices(ctx), 2)
+	})
+	s.run(t, "DeletePrice/NonExistingWeight", func(t *testing.T, store storage.Storage) {
+		err := store.DeletePrice(ctx, 123)
+
+		assert.EqualError(t, err, messageErrors.WeightNotAvailable)
+		assert.Len(t, store.GetPrices(ctx), 3)
+	})
+}
+
+/***********************/
+/***** PROMO CODES *****/
+/***********************/
+
+func (s suite) promoCodes(t *testing.T) {
+	tenOff := types.PromoCode{Code: "TENOFF", Kind: types.PercentageDiscount, Percentage: 10}
+
+	s.run(t, "GetPromoCodes", func(t *testing.T, store storage.Storage) {
+		assert.Empty(t, store.GetPromoCodes(ctx))
+
+		addPromoCode(t, store, tenOff)
+		addPromoCode(t, store, types.PromoCode{Code: "MINUS200", Kind: types.FixedAmountDiscount, Amount: ars(200)})
+
+		assert.Len(t, store.GetPromoCodes(ctx), 2)
+	})
+	s.run(t, "GetPromoCodeByCode", func(t *testing.T, store storage.Storage) {
+		promo := addPromoCode(t, store, tenOff)
+
+		actual, err := store.GetPromoCodeByCode(ctx, "TENOFF")
+
+		assert.NoError(t, err)
+		assert.True(t, promo.IsEqualTo(actual))
+	})
+	s.run(t, "GetPromoCodeByCode/NonExistingCode", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetPromoCodeByCode(ctx, "NOPE")
+
+		assert.EqualError(t, err, messageErrors.PromoCodeNotFound)
+	})
+	s.run(t, "AddPromoCode/ExistingCode", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		other := types.PromoCode{Code: "TENOFF", Kind: types.PercentageDiscount, Percentage: 50}
+		_ = other.Validate(storeSettings.Currency)
+
+		err := store.AddPromoCode(ctx, other)
+		actual, _ := store.GetPromoCodeByCode(ctx, "TENOFF")
+
+		assert.EqualError(t, err, messageErrors.AlreadyExistingPromoCode)
+		assert.Equal(t, uint(10), actual.Percentage)
+	})
+	s.run(t, "UpdatePromoCode/RecomputesUnpaidOrders", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		require.NoError(t, err)
+		update := types.PromoCode{Code: "IGNORED", Kind: types.PercentageDiscount, Percentage: 20}
+		_ = update.Validate(storeSettings.Currency)
+
+		updated, err := store.UpdatePromoCode(ctx, "TENOFF", update)
+		actualOrder, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TENOFF", updated.Code)
+		assert.Equal(t, uint(20), updated.Percentage)
+		assert.Equal(t, ars(100), actualOrder.Discount)
+		assert.Equal(t, ars(400), actualOrder.TotalCost)
+	})
+	s.run(t, "UpdatePromoCode/KeepsPaidOrders", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		require.NoError(t, err)
+		payOrder(t, store, order.ID, "payment-1")
+		update := types.PromoCode{Kind: types.PercentageDiscount, Percentage: 20}
+		_ = update.Validate(storeSettings.Currency)
+
+		_, err = store.UpdatePromoCode(ctx, "TENOFF", update)
+		actualOrder, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(450), actualOrder.TotalCost)
+	})
+	s.run(t, "UpdatePromoCode/KeepsRefundedOrders", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		require.NoError(t, err)
+		payOrder(t, store, order.ID, "payment-1")
+		_, _, err = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+		update := types.PromoCode{Kind: types.PercentageDiscount, Percentage: 20}
+		_ = update.Validate(storeSettings.Currency)
+
+		_, err = store.UpdatePromoCode(ctx, "TENOFF", update)
+		actualOrder, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(450), actualOrder.TotalCost, "the refund pending is the amount that was charged")
+	})
+	s.run(t, "UpdatePromoCode/KeepsCancelledOrders", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		require.NoError(t, err)
+		_, _, err = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+		update := types.PromoCode{Kind: types.PercentageDiscount, Percentage: 20}
+		_ = update.Validate(storeSettings.Currency)
+
+		_, err = store.UpdatePromoCode(ctx, "TENOFF", update)
+		actualOrder, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(450), actualOrder.TotalCost)
+	})
+	s.run(t, "UpdatePromoCode/KeepsOrdersBeingPaid", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		require.NoError(t, err)
+		_, err = store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+		update := types.PromoCode{Kind: types.PercentageDiscount, Percentage: 20}
+		_ = update.Validate(storeSettings.Currency)
+
+		_, err = store.UpdatePromoCode(ctx, "TENOFF", update)
+		actualOrder, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(450), actualOrder.TotalCost, "the total being charged does not change")
+	})
+	s.run(t, "UpdatePromoCode/NonExistingCode", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdatePromoCode(ctx, "NOPE", tenOff)
+
+		assert.EqualError(t, err, messageErrors.PromoCodeNotFound)
+		assert.Empty(t, store.GetPromoCodes(ctx))
+	})
+	s.run(t, "DeletePromoCode", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+
+		err := store.DeletePromoCode(ctx, "TENOFF")
+		_, errGetting := store.GetPromoCodeByCode(ctx, "TENOFF")
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.PromoCodeNotFound)
+	})
+	s.run(t, "DeletePromoCode/CodeInUse", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, _ = store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+
+		err := store.DeletePromoCode(ctx, "TENOFF")
+
+		assert.EqualError(t, err, messageErrors.PromoCodeIsInUse)
+		assert.Len(t, store.GetPromoCodes(ctx), 1)
+	})
+	s.run(t, "DeletePromoCode/NonExistingCode", func(t *testing.T, store storage.Storage) {
+		err := store.DeletePromoCode(ctx, "NOPE")
+
+		assert.EqualError(t, err, messageErrors.PromoCodeNotFound)
+	})
+	s.run(t, "ApplyPromoCodeToOrder", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+
+		applied, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		for _, order := range []types.Order{applied, actual} {
+			assert.Equal(t, "TENOFF", order.PromoCode)
+			assert.Equal(t, ars(800), order.Subtotal)
+			assert.Equal(t, ars(80), order.Discount)
+			assert.Equal(t, ars(720), order.TotalCost)
+		}
+	})
+	s.run(t, "ApplyPromoCodeToOrder/FixedAmountOverTheSubtotal", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "MINUS5000", Kind: types.FixedAmountDiscount, Amount: ars(5000)})
+		order := newOrder(t, store, genericID, quarterKiloTub)
+
+		applied, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "MINUS5000", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(300), applied.Discount, "the discount never exceeds the subtotal")
+		assert.Equal(t, ars(0), applied.TotalCost)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/FreeTub", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "FREEHALF", Kind: types.FreeTubDiscount, FreeTubWeight: 500})
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+
+		applied, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "FREEHALF", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(500), applied.Discount)
+		assert.Equal(t, ars(300), applied.TotalCost)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/NonExistingCode", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "NOPE", time.Now())
+
+		assert.EqualError(t, err, messageErrors.PromoCodeNotFound)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, missingID, "TENOFF", time.Now())
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/PaidOrder", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyPaid)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/CancelledOrder", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		_, err = store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyCancelled)
+		assert.Empty(t, actual.PromoCode, "cancelled orders do not count against the usage limits")
+	})
+	s.run(t, "ApplyPromoCodeToOrder/OrderWithACode", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, tenOff)
+		addPromoCode(t, store, types.PromoCode{Code: "MINUS200", Kind: types.FixedAmountDiscount, Amount: ars(200)})
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, _ = store.ApplyPromoCodeToOrder(ctx, order.ID, "TENOFF", time.Now())
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "MINUS200", time.Now())
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.PromoCodeAlreadyApplied)
+		assert.Equal(t, "TENOFF", actual.PromoCode)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/OutsideItsValidity", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "OLD", Kind: types.PercentageDiscount, Percentage: 10, ValidUntil: "2020-12-31"})
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "OLD", time.Now())
+
+		assert.EqualError(t, err, messageErrors.PromoCodeIsNotValidNow)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/BelowItsMinimumTotal", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "BIG", Kind: types.PercentageDiscount, Percentage: 10, MinOrderTotal: ars(5000)})
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "BIG", time.Now())
+
+		assert.EqualError(t, err, messageErrors.OrderTotalBelowPromoMinimum)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/NoDiscount", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "FREEKILO", Kind: types.FreeTubDiscount, FreeTubWeight: 1000})
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "FREEKILO", time.Now())
+
+		assert.EqualError(t, err, messageErrors.PromoCodeNotApplicable)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/OverItsGlobalUsageLimit", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "ONCE", Kind: types.PercentageDiscount, Percentage: 10, MaxUses: 1})
+		first := newOrder(t, store, adminID, halfKiloTub)
+		second := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, first.ID, "ONCE", time.Now())
+		require.NoError(t, err)
+
+		_, err = store.ApplyPromoCodeToOrder(ctx, second.ID, "ONCE", time.Now())
+
+		assert.EqualError(t, err, messageErrors.PromoCodeUsageLimitReached)
+	})
+	s.run(t, "ApplyPromoCodeToOrder/OverItsPerUserUsageLimit", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "ONCEEACH", Kind: types.PercentageDiscount, Percentage: 10, MaxUsesPerUser: 1})
+		first := newOrder(t, store, genericID, halfKiloTub)
+		second := newOrder(t, store, genericID, halfKiloTub)
+		other := newOrder(t, store, adminID, halfKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, first.ID, "ONCEEACH", time.Now())
+		require.NoError(t, err)
+
+		_, err = store.ApplyPromoCodeToOrder(ctx, second.ID, "ONCEEACH", time.Now())
+		_, errOther := store.ApplyPromoCodeToOrder(ctx, other.ID, "ONCEEACH", time.Now())
+
+		assert.EqualError(t, err, messageErrors.PromoCodeUserLimitReached)
+		assert.NoError(t, errOther)
+	})
+}
+
+/******************/
+/***** ORDERS *****/
+/******************/
+
+func (s suite) orders(t *testing.T) {
+	s.run(t, "CreateOrder", func(t *testing.T, store storage.Storage) {
+		order := types.Order{Address: "Calle 123", UserID: genericID, IceCreamTubs: []types.IceCreamTub{halfKiloTub, quarterKiloTub}}
+
+		err := store.CreateOrder(ctx, &order)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.NoError(t, err)
+		assert.NotZero(t, order.ID)
+		for _, order := range []types.Order{order, actual} {
+			assert.Equal(t, "Calle 123", order.Address)
+			assert.Equal(t, genericID, order.UserID)
+			assert.Equal(t, types.OrderDraft, order.Status)
+			assert.Equal(t, types.PaymentPending, order.PaymentState)
+			assert.Equal(t, ars(800), order.Subtotal)
+			assert.Equal(t, ars(800), order.TotalCost)
+			require.Len(t, order.IceCreamTubs, 2)
+		}
+		assert.ElementsMatch(t, []types.Money{ars(500), ars(300)}, []types.Money{actual.IceCreamTubs[0].UnitPrice, actual.IceCreamTubs[1].UnitPrice})
+		assert.NotEqual(t, actual.IceCreamTubs[0].ID, actual.IceCreamTubs[1].ID)
+		assert.Equal(t, uint(10000-250-250), ddl.Stock)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+		assert.Equal(t, []string{types.OrderCreatedEvent, types.TubAddedEvent, types.TubAddedEvent}, eventKinds(events))
+	})
+	s.run(t, "CreateOrder/IgnoresServerControlledFields", func(t *testing.T, store storage.Storage) {
+		order := types.Order{Address: "Calle 123", UserID: genericID, PaymentState: types.PaymentPaid, Status: types.OrderDelivered, PromoCode: "FREE", TotalCost: ars(1)}
+
+		err := store.CreateOrder(ctx, &order)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPending, actual.PaymentState)
+		assert.Equal(t, types.OrderDraft, actual.Status)
+		assert.Empty(t, actual.PromoCode)
+		assert.Equal(t, ars(0), actual.TotalCost)
+	})
+	s.run(t, "CreateOrder/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		order := types.Order{Address: "Calle 123", UserID: missingID}
+
+		err := store.CreateOrder(ctx, &order)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+		assert.Empty(t, store.GetAllOrders(ctx))
+	})
+	s.run(t, "CreateOrder/RejectedTubsSaveNothing", func(t *testing.T, store storage.Storage) {
+		order := types.Order{Address: "Calle 123", UserID: genericID, IceCreamTubs: []types.IceCreamTub{
+			halfKiloTub,
+			{Weight: 250, Flavors: []string{"non-existing-flavor"}},
+			{Weight: 250, Flavors: []string{"ddl", "mrc", "trm", "frt"}},
+			{Weight: 123, Flavors: []string{"ddl"}},
+			{Weight: 0, Flavors: []string{"ddl"}},
+		}}
+
+		err := store.CreateOrder(ctx, &order)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		var invalidTubs *types.InvalidTubsError
+		require.True(t, errors.As(err, &invalidTubs))
+		assert.EqualError(t, err, messageErrors.InvalidIceCreamTubs)
+		assert.Equal(t, []types.TubError{
+			{Index: 1, Error: messageErrors.NonExistingFlavors},
+			{Index: 2, Error: messageErrors.InvalidAmountOfFlavors},
+			{Index: 3, Error: messageErrors.WeightNotAvailable},
+			{Index: 4, Error: messageErrors.WeightCannotBeZero},
+		}, invalidTubs.Tubs)
+		assert.Empty(t, store.GetAllOrders(ctx))
+		assert.Equal(t, uint(10000), ddl.Stock)
+	})
+	s.run(t, "CreateOrder/MoreTubsThanTheStockSavesNothing", func(t *testing.T, store storage.Storage) {
+		_, _ = store.UpdateFlavorStock(ctx, "ddl", 400)
+		order := types.Order{Address: "Calle 123", UserID: genericID, IceCreamTubs: []types.IceCreamTub{quarterKiloTub, quarterKiloTub}}
+
+		err := store.CreateOrder(ctx, &order)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		var invalidTubs *types.InvalidTubsError
+		require.True(t, errors.As(err, &invalidTubs))
+		assert.Equal(t, []types.TubError{{Index: 1, Error: messageErrors.OutOfStockFlavors}}, invalidTubs.Tubs)
+		assert.Empty(t, store.GetAllOrders(ctx))
+		assert.Equal(t, uint(400), ddl.Stock)
+	})
+	s.run(t, "GetOrderByID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetOrderByID(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "GetAllOrders", func(t *testing.T, store storage.Storage) {
+		first := newOrder(t, store, genericID)
+		second := newOrder(t, store, adminID)
+
+		assert.ElementsMatch(t, []uint{first.ID, second.ID}, orderIDs(store.GetAllOrders(ctx)))
+	})
+	s.run(t, "GetAllOrders/WithTheirTubs", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+
+		orders := store.GetAllOrders(ctx)
+
+		require.Len(t, orders, 1)
+		assert.Equal(t, order.ID, orders[0].ID)
+		assert.Len(t, orders[0].IceCreamTubs, 2)
+	})
+	s.run(t, "GetOrdersByStatus", func(t *testing.T, store storage.Storage) {
+		draft := newOrder(t, store, genericID)
+		placed := placedOrder(t, store, genericID)
+
+		assert.Equal(t, []uint{placed.ID}, orderIDs(store.GetOrdersByStatus(ctx, types.OrderPlaced)))
+		assert.ElementsMatch(t, []uint{draft.ID, placed.ID}, orderIDs(store.GetOrdersByStatus(ctx, types.OrderDraft, types.OrderPlaced)))
+		assert.Empty(t, store.GetOrdersByStatus(ctx, types.OrderDelivered))
+	})
+	s.run(t, "GetOrdersByDeliveryDriverID", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		assigned := placedOrder(t, store, adminID)
+		_ = placedOrder(t, store, adminID)
+		require.NoError(t, store.AssignDeliveryDriverToOrder(ctx, assigned.ID, genericID, adminID))
+
+		assert.Equal(t, []uint{assigned.ID}, orderIDs(store.GetOrdersByDeliveryDriverID(ctx, genericID)))
+		assert.Empty(t, store.GetOrdersByDeliveryDriverID(ctx, adminID))
+	})
+	s.run(t, "GetOrdersScheduledFor", func(t *testing.T, store storage.Storage) {
+		day := time.Now().AddDate(0, 0, 3)
+		evening := scheduledOrder(t, store, day, 20)
+		afternoon := scheduledOrder(t, store, day, 15)
+		_ = scheduledOrder(t, store, day.AddDate(0, 0, 1), 15)
+		_ = newOrder(t, store, genericID)
+		cancelled := scheduledOrder(t, store, day, 16)
+		_, _, err := store.CancelOrder(ctx, cancelled.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		scheduled := store.GetOrdersScheduledFor(ctx, day.Format(types.DateLayout))
+
+		assert.Equal(t, []uint{afternoon.ID, evening.ID}, orderIDs(scheduled))
+		assert.True(t, scheduled[0].DeliveryWindow.Start.Equal(afternoon.DeliveryWindow.Start))
+		assert.Empty(t, store.GetOrdersScheduledFor(ctx, day.AddDate(0, 0, 7).Format(types.DateLayout)))
+	})
+	s.run(t, "GetAssignableOrders", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		_ = newOrder(t, store, adminID, halfKiloTub)
+		placed := placedOrder(t, store, adminID)
+		assigned := placedOrder(t, store, adminID)
+		require.NoError(t, store.AssignDeliveryDriverToOrder(ctx, assigned.ID, genericID, adminID))
+		scheduled := scheduledOrder(t, store, time.Now().AddDate(0, 0, 3), 15)
+		_, err := store.UpdateOrderStatus(ctx, scheduled.ID, types.OrderPlaced, genericID)
+		require.NoError(t, err)
+
+		assert.Equal(t, []uint{placed.ID}, orderIDs(store.GetAssignableOrders(ctx, time.Now())))
+		noticed := scheduled.DeliveryWindow.Start.Add(-storeSettings.DriverNotice)
+		assert.ElementsMatch(t, []uint{placed.ID, scheduled.ID}, orderIDs(store.GetAssignableOrders(ctx, noticed)))
+	})
+	s.run(t, "GetAllOrdersByUserEmail", func(t *testing.T, store storage.Storage) {
+		first := newOrder(t, store, genericID)
+		second := newOrder(t, store, genericID, halfKiloTub)
+		_ = newOrder(t, store, adminID)
+		tub := quarterKiloTub
+		require.NoError(t, store.AddIceCreamTubByOrderID(ctx, first.ID, &tub))
+		payOrder(t, store, first.ID, "payment-1")
+		_, _, err := store.CancelOrder(ctx, second.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		orders := store.GetAllOrdersByUserEmail(ctx, "zzzzz@gmail.com")
+		sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+
+		require.Equal(t, []uint{first.ID, second.ID}, orderIDs(orders))
+		assert.Equal(t, types.PaymentPaid, orders[0].PaymentState, "the orders are read after they change")
+		assert.Len(t, orders[0].IceCreamTubs, 1)
+		assert.Equal(t, ars(300), orders[0].TotalCost)
+		assert.Equal(t, types.OrderCancelled, orders[1].Status)
+		assert.Equal(t, ars(500), orders[1].TotalCost)
+		assert.Empty(t, store.GetAllOrdersByUserEmail(ctx, "nobody@gmail.com"))
+	})
+	s.run(t, "GetUserOrderByID", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		actual, err := store.GetUserOrderByID(ctx, order.ID, genericID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.ID, actual.ID)
+		assert.Len(t, actual.IceCreamTubs, 1)
+	})
+	s.run(t, "GetUserOrderByID/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		_, err := store.GetUserOrderByID(ctx, order.ID, missingID)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+	s.run(t, "GetUserOrderByID/OrderOfAnotherUser", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, adminID)
+
+		_, err := store.GetUserOrderByID(ctx, order.ID, genericID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "GetUserOrderByID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetUserOrderByID(ctx, missingID, genericID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "UpdateOrderByID", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		start := time.Now().AddDate(0, 0, 2).Truncate(time.Hour)
+		window := &types.DeliveryWindow{Start: start, End: start.Add(time.Hour)}
+
+		updated, err := store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: genericID, PaymentState: types.PaymentPaid, DeliveryWindow: window})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		for _, order := range []types.Order{updated, actual} {
+			assert.Equal(t, "Calle 789", order.Address)
+			assert.Equal(t, types.PaymentPending, order.PaymentState)
+			require.NotNil(t, order.DeliveryWindow)
+			assert.True(t, types.SameDeliveryWindow(window, order.DeliveryWindow))
+		}
+		assert.Len(t, actual.IceCreamTubs, 1, "the tubs are not saved again")
+		assert.Equal(t, []string{types.OrderCreatedEvent, types.TubAddedEvent, types.AddressChangedEvent, types.WindowChangedEvent}, eventKinds(events))
+	})
+	s.run(t, "UpdateOrderByID/RemovesTheWindow", func(t *testing.T, store storage.Storage) {
+		order := scheduledOrder(t, store, time.Now().AddDate(0, 0, 2), 15)
+
+		updated, err := store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: genericID})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Nil(t, updated.DeliveryWindow)
+		assert.Nil(t, actual.DeliveryWindow)
+	})
+	s.run(t, "UpdateOrderByID/CancelledOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+		start := time.Now().AddDate(0, 0, 2).Truncate(time.Hour)
+
+		_, err = store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: genericID, DeliveryWindow: &types.DeliveryWindow{Start: start, End: start.Add(time.Hour)}})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderDeliveryCannotBeChanged)
+		assert.Equal(t, "Calle 123", actual.Address)
+		assert.Nil(t, actual.DeliveryWindow)
+		assert.NotContains(t, eventKinds(events), types.AddressChangedEvent)
+	})
+	s.run(t, "UpdateOrderByID/DeliveredOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		for _, status := range []string{types.OrderPreparing, types.OrderReady, types.OrderOutForDelivery, types.OrderDelivered} {
+			_, err := store.UpdateOrderStatus(ctx, order.ID, status, adminID)
+			require.NoError(t, err)
+		}
+
+		_, err := store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: genericID})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderDeliveryCannotBeChanged)
+		assert.Equal(t, "Calle 123", actual.Address)
+	})
+	s.run(t, "UpdateOrderByID/OrderOfAnotherUser", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		_, err := store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: adminID})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+		assert.Equal(t, "Calle 123", actual.Address)
+	})
+	s.run(t, "UpdateOrderByID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateOrderByID(ctx, missingID, &types.Order{Address: "Calle 789", UserID: genericID})
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "StartOrderPayment", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		started, err := store.StartOrderPayment(ctx, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(500), started.TotalCost)
+		assert.Equal(t, types.PaymentProcessing, actual.PaymentState)
+	})
+	s.run(t, "StartOrderPayment/OrderBeingPaid", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+
+		_, err = store.StartOrderPayment(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsBeingPaid)
+	})
+	s.run(t, "StartOrderPayment/PaidOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+
+		_, err := store.StartOrderPayment(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyPaid)
+	})
+	s.run(t, "StartOrderPayment/OrderWithoutTubs", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		_, err := store.StartOrderPayment(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderHasNoIceCreamTubs)
+	})
+	s.run(t, "StartOrderPayment/CancelledOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		_, err = store.StartOrderPayment(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyCancelled)
+	})
+	s.run(t, "StartOrderPayment/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.StartOrderPayment(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "StartOrderPayment/Concurrently", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		errs := make([]error, 10)
+
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = store.StartOrderPayment(ctx, order.ID)
+			}()
+		}
+		wg.Wait()
+
+		started := 0
+		for _, err := range errs {
+			if err == nil {
+				started++
+				continue
+			}
+			assert.EqualError(t, err, messageErrors.OrderIsBeingPaid)
+		}
+		assert.Equal(t, 1, started, "only one payment starts, so the customer is charged once")
+	})
+	s.run(t, "AbortOrderPayment", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+
+		err = store.AbortOrderPayment(ctx, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPending, actual.PaymentState)
+	})
+	s.run(t, "AbortOrderPayment/PaidOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+
+		err := store.AbortOrderPayment(ctx, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPaid, actual.PaymentState)
+	})
+	s.run(t, "AbortOrderPayment/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		err := store.AbortOrderPayment(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "MarkOrderAsPaid", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		started, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+
+		err = store.MarkOrderAsPaid(ctx, order.ID, "payment-1", started.TotalCost)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPaid, actual.PaymentState)
+		assert.Equal(t, "payment-1", actual.PaymentReference)
+	})
+	s.run(t, "MarkOrderAsPaid/PaidOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+
+		err := store.MarkOrderAsPaid(ctx, order.ID, "payment-2", ars(500))
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyPaid)
+		assert.Equal(t, "payment-1", actual.PaymentReference)
+	})
+	s.run(t, "MarkOrderAsPaid/PaymentNotStarted", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		err := store.MarkOrderAsPaid(ctx, order.ID, "payment-1", ars(500))
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderPaymentNotStarted)
+		assert.Equal(t, types.PaymentPending, actual.PaymentState)
+	})
+	s.run(t, "MarkOrderAsPaid/AmountDifferentFromTotal", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+
+		err = store.MarkOrderAsPaid(ctx, order.ID, "payment-1", ars(300))
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.PaidAmountMismatch)
+		assert.Equal(t, types.PaymentProcessing, actual.PaymentState)
+	})
+	s.run(t, "MarkOrderAsPaid/OrderCancelledWhileBeingPaid", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+		_, previousPaymentState, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		err = store.MarkOrderAsPaid(ctx, order.ID, "payment-1", ars(500))
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyCancelled)
+		assert.Equal(t, types.PaymentProcessing, previousPaymentState, "the payment in progress is not refunded by the cancellation")
+		assert.Equal(t, types.PaymentPending, actual.PaymentState)
+	})
+	s.run(t, "MarkOrderAsPaid/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		err := store.MarkOrderAsPaid(ctx, missingID, "payment-1", ars(500))
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "GetOrderHistory", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		tub := quarterKiloTub
+		require.NoError(t, store.AddIceCreamTubByOrderID(ctx, order.ID, &tub))
+		require.NoError(t, store.DeleteIceCreamTubByOrderID(ctx, tub.ID, order.ID))
+		_, err := store.UpdateOrderByID(ctx, order.ID, &types.Order{Address: "Calle 789", UserID: genericID})
+		require.NoError(t, err)
+		payOrder(t, store, order.ID, "payment-1")
+		_, err = store.UpdateOrderStatus(ctx, order.ID, types.OrderPlaced, genericID)
+		require.NoError(t, err)
+		other := newOrder(t, store, genericID)
+
+		events, err := store.GetOrderHistory(ctx, order.ID)
+		otherEvents, _ := store.GetOrderHistory(ctx, other.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			types.OrderCreatedEvent, types.TubAddedEvent, types.TubAddedEvent, types.TubRemovedEvent,
+			types.AddressChangedEvent, types.OrderPaidEvent, types.StatusChangedEvent,
+		}, eventKinds(events))
+		for _, event := range events {
+			assert.Equal(t, order.ID, event.OrderID)
+			assert.Equal(t, genericID, event.ActorID)
+		}
+		assert.Equal(t, "Calle 789", events[4].Payload["to"])
+		assert.Equal(t, []string{types.OrderCreatedEvent}, eventKinds(otherEvents), "the history is not shared between orders")
+	})
+	s.run(t, "GetOrderHistory/StatusChangesAndCancellation", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPreparing, adminID)
+		require.NoError(t, err)
+		_, _, err = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: adminID, ByAdmin: true, Reason: "out of cones"})
+		require.NoError(t, err)
+
+		events, err := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		require.Equal(t, []string{
+			types.OrderCreatedEvent, types.TubAddedEvent, types.StatusChangedEvent, types.StatusChangedEvent, types.OrderCancelledEvent,
+		}, eventKinds(events))
+		assert.Equal(t, genericID, events[2].ActorID)
+		assert.Equal(t, adminID, events[3].ActorID)
+		assert.Equal(t, adminID, events[4].ActorID)
+		assert.Equal(t, types.OrderPlaced, events[3].Payload["from"])
+		assert.Equal(t, types.OrderPreparing, events[3].Payload["to"])
+		assert.Equal(t, "out of cones", events[4].Payload["reason"])
+	})
+	s.run(t, "GetOrderHistory/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetOrderHistory(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+}
+
+/************************/
+/***** ORDER STATUS *****/
+/************************/
+
+func (s suite) orderStatus(t *testing.T) {
+	s.run(t, "UpdateOrderStatus", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		for _, status := range []string{types.OrderPlaced, types.OrderPreparing, types.OrderReady, types.OrderOutForDelivery, types.OrderDelivered} {
+			updated, err := store.UpdateOrderStatus(ctx, order.ID, status, adminID)
+			actual, _ := store.GetOrderByID(ctx, order.ID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, status, updated.Status)
+			assert.Equal(t, status, actual.Status)
+		}
+	})
+	s.run(t, "UpdateOrderStatus/OrderWithoutTubs", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPlaced, genericID)
+
+		assert.EqualError(t, err, messageErrors.OrderHasNoIceCreamTubs)
+	})
+	s.run(t, "UpdateOrderStatus/SkippingAStatus", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderReady, adminID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.IllegalOrderStatusTransition)
+		assert.Equal(t, types.OrderDraft, actual.Status)
+	})
+	s.run(t, "UpdateOrderStatus/DeliveredOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		for _, status := range []string{types.OrderPreparing, types.OrderReady, types.OrderOutForDelivery, types.OrderDelivered} {
+			_, err := store.UpdateOrderStatus(ctx, order.ID, status, adminID)
+			require.NoError(t, err)
+		}
+
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPlaced, adminID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.IllegalOrderStatusTransition)
+		assert.Equal(t, types.OrderDelivered, actual.Status)
+	})
+	s.run(t, "UpdateOrderStatus/InvalidStatus", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.UpdateOrderStatus(ctx, order.ID, "eaten", adminID)
+
+		assert.EqualError(t, err, messageErrors.InvalidOrderStatus)
+	})
+	s.run(t, "UpdateOrderStatus/Cancelling", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderCancelled, adminID)
+
+		assert.EqualError(t, err, messageErrors.CancelOrderInstead)
+	})
+	s.run(t, "UpdateOrderStatus/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateOrderStatus(ctx, missingID, types.OrderPlaced, adminID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "CancelOrder/ByItsCustomer", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		order := placedOrder(t, store, adminID)
+		require.NoError(t, store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID))
+
+		cancelled, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: adminID, Reason: "changed my mind"})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.NoError(t, err)
+		for _, order := range []types.Order{cancelled, actual} {
+			assert.Equal(t, types.OrderCancelled, order.Status)
+			assert.Equal(t, adminID, order.CancelledBy)
+			assert.Equal(t, "changed my mind", order.CancellationReason)
+			assert.Zero(t, order.DeliveryDriverID)
+		}
+		assert.Equal(t, uint(10000), ddl.Stock)
+	})
+	s.run(t, "CancelOrder/APaidOrderByAnAdmin", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		payOrder(t, store, order.ID, "payment-1")
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPreparing, adminID)
+		require.NoError(t, err)
+
+		cancelled, previousPaymentState, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: adminID, ByAdmin: true, Reason: " no milk "})
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPaid, previousPaymentState)
+		assert.Equal(t, types.OrderCancelled, cancelled.Status)
+		assert.Equal(t, "no milk", cancelled.CancellationReason)
+		assert.Equal(t, types.PaymentRefundPending, cancelled.PaymentState)
+		assert.Equal(t, "payment-1", cancelled.PaymentReference, "the reference is returned to refund the order")
+		assert.Equal(t, uint(10000-250), ddl.Stock, "the stock of orders in preparation is not released")
+	})
+	s.run(t, "CancelOrder/AnUnpaidOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+
+		cancelled, previousPaymentState, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentPending, previousPaymentState)
+		assert.Equal(t, types.PaymentPending, cancelled.PaymentState)
+	})
+	s.run(t, "CancelOrder/Concurrently", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		payOrder(t, store, order.ID, "payment-1")
+		previousPaymentStates := make([]string, 10)
+		errs := make([]error, 10)
+
+		var wg sync.WaitGroup
+		for i := range previousPaymentStates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, previousPaymentStates[i], errs[i] = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+			}()
+		}
+		wg.Wait()
+
+		cancellations := 0
+		for i, err := range errs {
+			if err == nil {
+				cancellations++
+				assert.Equal(t, types.PaymentPaid, previousPaymentStates[i])
+				continue
+			}
+			assert.EqualError(t, err, messageErrors.OrderIsAlreadyCancelled)
+		}
+		assert.Equal(t, 1, cancellations, "only one cancellation gets the order as paid, so it is refunded once")
+	})
+	s.run(t, "MarkOrderAsRefunded", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		payOrder(t, store, order.ID, "payment-1")
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		refunded, err := store.MarkOrderAsRefunded(ctx, order.ID, "refund-1", genericID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.PaymentRefunded, refunded.PaymentState)
+		assert.Equal(t, types.PaymentRefunded, actual.PaymentState)
+		assert.Equal(t, types.OrderRefundedEvent, events[len(events)-1].Kind)
+		assert.Equal(t, "refund-1", events[len(events)-1].Payload["refundReference"])
+	})
+	s.run(t, "MarkOrderAsRefunded/WithoutAPendingRefund", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		payOrder(t, store, order.ID, "payment-1")
+
+		_, err := store.MarkOrderAsRefunded(ctx, order.ID, "refund-1", genericID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderHasNoPendingRefund)
+		assert.Equal(t, types.PaymentPaid, actual.PaymentState)
+	})
+	s.run(t, "MarkOrderAsRefunded/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.MarkOrderAsRefunded(ctx, missingID, "refund-1", genericID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "CancelOrder/InPreparationByItsCustomer", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		_, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPreparing, adminID)
+		require.NoError(t, err)
+
+		_, _, err = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderCannotBeCancelled)
+		assert.Equal(t, types.OrderPreparing, actual.Status)
+	})
+	s.run(t, "CancelOrder/ByAnAdminWithoutAReason", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: adminID, ByAdmin: true, Reason: " "})
+
+		assert.EqualError(t, err, messageErrors.CancellationReasonIsRequired)
+	})
+	s.run(t, "CancelOrder/CancelledOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		require.NoError(t, err)
+
+		_, _, err = store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericID})
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyCancelled)
+		assert.Equal(t, uint(10000), ddl.Stock)
+	})
+	s.run(t, "CancelOrder/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, _, err := store.CancelOrder(ctx, missingID, types.OrderCancellation{CancelledBy: genericID})
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+}
+
+/**************************/
+/***** ICE CREAM TUBS *****/
+/**************************/
+
+func (s suite) iceCreamTubs(t *testing.T) {
+	s.run(t, "AddIceCreamTubByOrderID", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, quarterKiloTub)
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+		frt, _ := store.GetFlavorByID(ctx, "frt")
+
+		assert.NoError(t, err)
+		assert.NotZero(t, tub.ID)
+		assert.Equal(t, ars(500), tub.UnitPrice)
+		assert.Len(t, actual.IceCreamTubs, 2)
+		assert.Equal(t, ars(800), actual.Subtotal)
+		assert.Equal(t, ars(800), actual.TotalCost)
+		assert.Equal(t, uint(10000-250-250), ddl.Stock)
+		assert.Equal(t, uint(10000-250), frt.Stock)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/Concurrently", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		errs := make([]error, 10)
+
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tub := halfKiloTub
+				tub.Flavors = slices.Clone(halfKiloTub.Flavors)
+				errs[i] = store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+			}()
+		}
+		wg.Wait()
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Len(t, actual.IceCreamTubs, 10)
+		assert.Equal(t, ars(5000), actual.Subtotal, "every tub is added to the total")
+		assert.Equal(t, ars(5000), actual.TotalCost)
+		assert.Equal(t, uint(10000-10*250), ddl.Stock)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/KeepsThePriceItWasChargedAt", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.UpdatePrice(ctx, 500, types.IceCreamTubPrice{Price: ars(900), MaxFlavors: 3})
+		require.NoError(t, err)
+		tub := halfKiloTub
+
+		require.NoError(t, store.AddIceCreamTubByOrderID(ctx, order.ID, &tub))
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.Equal(t, ars(1400), actual.TotalCost)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, missingID, &tub)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+		assert.Equal(t, uint(10000), ddl.Stock)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/NonExistingFlavors", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 500, Flavors: []string{"ddl", "non-existing-flavor"}})
+
+		assert.EqualError(t, err, messageErrors.NonExistingFlavors)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/RetiredFlavors", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		require.NoError(t, store.RetireFlavor(ctx, "frt"))
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+
+		assert.EqualError(t, err, messageErrors.NonExistingFlavors)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/FlavorsOutOfSeason", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		yesterday := time.Now().AddDate(0, 0, -1).Format(types.DateLayout)
+		require.NoError(t, store.AddFlavor(ctx, types.Flavor{ID: "mng", Name: "Mango", CategoryID: "al-agua", Stock: 10000, AvailableUntil: yesterday}))
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 250, Flavors: []string{"mng"}})
+
+		assert.EqualError(t, err, messageErrors.UnavailableFlavors)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/FlavorsNotAvailableToday", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		tomorrow := strings.ToLower(time.Now().AddDate(0, 0, 1).Weekday().String())
+		require.NoError(t, store.AddFlavor(ctx, types.Flavor{ID: "ore", Name: "Oreo", CategoryID: "cremas", Stock: 10000, AvailableWeekdays: []string{tomorrow}}))
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 250, Flavors: []string{"ore"}})
+
+		assert.EqualError(t, err, messageErrors.UnavailableFlavors)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/NonExistingWeight", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 123, Flavors: []string{"ddl"}})
+
+		assert.EqualError(t, err, messageErrors.WeightNotAvailable)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/MoreFlavorsThanAllowed", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 250, Flavors: []string{"ddl", "mrc", "trm", "frt"}})
+
+		assert.EqualError(t, err, messageErrors.InvalidAmountOfFlavors)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/SplitsTheStockBetweenItsFlavors", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &types.IceCreamTub{Weight: 1000, Flavors: []string{"ddl", "frt", "mrc"}})
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+		frt, _ := store.GetFlavorByID(ctx, "frt")
+		mrc, _ := store.GetFlavorByID(ctx, "mrc")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(10000-334), ddl.Stock, "the first flavor takes the grams left over")
+		assert.Equal(t, uint(10000-333), frt.Stock)
+		assert.Equal(t, uint(10000-333), mrc.Stock)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/OutOfStockFlavors", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		_, _ = store.UpdateFlavorStock(ctx, "frt", 100)
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+		frt, _ := store.GetFlavorByID(ctx, "frt")
+
+		assert.EqualError(t, err, messageErrors.OutOfStockFlavors)
+		assert.Equal(t, uint(10000), ddl.Stock)
+		assert.Equal(t, uint(100), frt.Stock)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/PricedInAnotherCurrency", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+		_, err := store.UpdatePrice(ctx, 500, types.IceCreamTubPrice{Price: types.NewMoney(500, "USD"), MaxFlavors: 3})
+		require.NoError(t, err)
+		tub := halfKiloTub
+
+		err = store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.CurrencyMismatch)
+		assert.Empty(t, actual.IceCreamTubs)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/PaidOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyPaid)
+	})
+	s.run(t, "AddIceCreamTubByOrderID/OrderBeingPaid", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		_, err := store.StartOrderPayment(ctx, order.ID)
+		require.NoError(t, err)
+		tub := halfKiloTub
+
+		err = store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsBeingPaid)
+		assert.Equal(t, ars(500), actual.TotalCost, "the total being charged does not change")
+	})
+	s.run(t, "AddIceCreamTubByOrderID/PlacedOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+		tub := halfKiloTub
+
+		err := store.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+
+		assert.EqualError(t, err, messageErrors.OrderIsNotADraft)
+	})
+	s.run(t, "GetIceCreamTubsByOrderID", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+		_ = newOrder(t, store, genericID, halfKiloTub)
+
+		tubs, err := store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		require.Len(t, tubs, 2)
+		for _, tub := range tubs {
+			assert.Equal(t, order.ID, tub.OrderID)
+		}
+		assert.ElementsMatch(t, []uint{500, 250}, []uint{tubs[0].Weight, tubs[1].Weight})
+	})
+	s.run(t, "GetIceCreamTubsByOrderID/KeepsRetiredFlavors", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		require.NoError(t, store.RetireFlavor(ctx, "frt"))
+
+		tubs, err := store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		require.Len(t, tubs, 1)
+		assert.Equal(t, []string{"ddl", "frt"}, tubs[0].Flavors)
+	})
+	s.run(t, "GetIceCreamTubsByOrderID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetIceCreamTubsByOrderID(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[0].ID, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+		frt, _ := store.GetFlavorByID(ctx, "frt")
+
+		assert.NoError(t, err)
+		require.Len(t, actual.IceCreamTubs, 1)
+		assert.Equal(t, order.IceCreamTubs[1].ID, actual.IceCreamTubs[0].ID)
+		assert.Equal(t, ars(300), actual.TotalCost)
+		assert.Equal(t, uint(10000-250), ddl.Stock)
+		assert.Equal(t, uint(10000), frt.Stock)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/AfterAPriceChange", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+		_, err := store.UpdatePrice(ctx, 500, types.IceCreamTubPrice{Price: ars(900), MaxFlavors: 3})
+		require.NoError(t, err)
+
+		err = store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[1].ID, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(500), actual.TotalCost, "the tubs left keep the price they were charged at")
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/RecomputesTheDiscount", func(t *testing.T, store storage.Storage) {
+		addPromoCode(t, store, types.PromoCode{Code: "FREEHALF", Kind: types.FreeTubDiscount, FreeTubWeight: 500})
+		order := newOrder(t, store, genericID, halfKiloTub, quarterKiloTub)
+		_, err := store.ApplyPromoCodeToOrder(ctx, order.ID, "FREEHALF", time.Now())
+		require.NoError(t, err)
+
+		err = store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[0].ID, order.ID)
+		actual, _ := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ars(0), actual.Discount)
+		assert.Equal(t, ars(300), actual.TotalCost)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/NonExistingTub", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, missingID, order.ID)
+
+		assert.EqualError(t, err, messageErrors.IceCreamTubNotFound)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/TubOfAnotherOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		other := newOrder(t, store, genericID, halfKiloTub)
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, other.IceCreamTubs[0].ID, order.ID)
+		actual, _ := store.GetOrderByID(ctx, other.ID)
+
+		assert.EqualError(t, err, messageErrors.IceCreamTubNotFound)
+		assert.Len(t, actual.IceCreamTubs, 1)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[0].ID, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/PaidOrder", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID, halfKiloTub)
+		payOrder(t, store, order.ID, "payment-1")
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[0].ID, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsAlreadyPaid)
+	})
+	s.run(t, "DeleteIceCreamTubByOrderID/PlacedOrder", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, genericID)
+
+		err := store.DeleteIceCreamTubByOrderID(ctx, order.IceCreamTubs[0].ID, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderIsNotADraft)
+	})
+}
+
+/****************************/
+/***** DELIVERY DRIVERS *****/
+/****************************/
+
+func (s suite) deliveryDrivers(t *testing.T) {
+	s.run(t, "GetDeliveryDrivers", func(t *testing.T, store storage.Storage) {
+		assert.Empty(t, store.GetDeliveryDrivers(ctx))
+
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+
+		deliveryDrivers := store.GetDeliveryDrivers(ctx)
+		require.Len(t, deliveryDrivers, 1)
+		assert.Equal(t, genericID, deliveryDrivers[0].UserID)
+	})
+	s.run(t, "AddDeliveryDriver", func(t *testing.T, store storage.Storage) {
+		deliveryDriver := genericDeliveryDriver
+
+		err := store.AddDeliveryDriver(ctx, &deliveryDriver)
+		actual, _ := store.GetDeliveryDriverByID(ctx, genericID)
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0123456789", actual.Cuil)
+		assert.Equal(t, uint(24), actual.Age)
+		assert.Equal(t, []string{"ABC123"}, actual.Vehicles)
+		assert.True(t, user.IsDeliveryDriver())
+	})
+	s.run(t, "AddDeliveryDriver/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		deliveryDriver := genericDeliveryDriver
+		deliveryDriver.UserID = missingID
+
+		err := store.AddDeliveryDriver(ctx, &deliveryDriver)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+		assert.Empty(t, store.GetDeliveryDrivers(ctx))
+	})
+	s.run(t, "AddDeliveryDriver/UserIsAlreadyADriver", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		deliveryDriver := genericDeliveryDriver
+		deliveryDriver.Cuil = "9876543210"
+
+		err := store.AddDeliveryDriver(ctx, &deliveryDriver)
+
+		assert.EqualError(t, err, messageErrors.UserIsAlreadyADriver)
+		assert.Len(t, store.GetDeliveryDrivers(ctx), 1)
+	})
+	s.run(t, "GetDeliveryDriverByID/NonExistingDriver", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetDeliveryDriverByID(ctx, genericID)
+
+		assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	})
+	s.run(t, "UpdateDeliveryDriverByID", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		update := types.DeliveryDriver{Cuil: "9876543210", Age: 30, Vehicles: []string{"FFF111", "XYZ987"}}
+
+		err := store.UpdateDeliveryDriverByID(ctx, genericID, &update)
+		actual, _ := store.GetDeliveryDriverByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, genericID, update.UserID)
+		assert.Equal(t, "9876543210", actual.Cuil)
+		assert.Equal(t, uint(30), actual.Age)
+		assert.Equal(t, []string{"FFF111", "XYZ987"}, actual.Vehicles)
+	})
+	s.run(t, "UpdateDeliveryDriverByID/NonExistingDriver", func(t *testing.T, store storage.Storage) {
+		update := types.DeliveryDriver{Cuil: "9876543210", Age: 30, Vehicles: []string{"FFF111"}}
+
+		err := store.UpdateDeliveryDriverByID(ctx, genericID, &update)
+
+		assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+		assert.Empty(t, store.GetDeliveryDrivers(ctx))
+	})
+	s.run(t, "DeleteDeliveryDriverByID", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+
+		err := store.DeleteDeliveryDriverByID(ctx, genericID)
+		_, errGetting := store.GetDeliveryDriverByID(ctx, genericID)
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.DeliveryDriverNotFound)
+		assert.False(t, user.IsDeliveryDriver())
+	})
+	s.run(t, "DeleteDeliveryDriverByID/NonExistingDriver", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteDeliveryDriverByID(ctx, genericID)
+
+		assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	})
+	s.run(t, "GetVehiclesByDeliveryDriverID", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+
+		vehicles, err := store.GetVehiclesByDeliveryDriverID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ABC123"}, vehicles)
+	})
+	s.run(t, "GetVehiclesByDeliveryDriverID/NonExistingDriver", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetVehiclesByDeliveryDriverID(ctx, genericID)
+
+		assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	})
+	s.run(t, "AssignDeliveryDriverToOrder", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		order := placedOrder(t, store, adminID)
+
+		err := store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID)
+		deliveryDriverID, _ := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, genericID, deliveryDriverID)
+		assert.Equal(t, types.DriverAssignedEvent, events[len(events)-1].Kind)
+		assert.Equal(t, adminID, events[len(events)-1].ActorID)
+	})
+	s.run(t, "AssignDeliveryDriverToOrder/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+
+		err := store.AssignDeliveryDriverToOrder(ctx, missingID, genericID, adminID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "AssignDeliveryDriverToOrder/NonExistingDriver", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, adminID)
+
+		err := store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID)
+		deliveryDriverID, _ := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+		assert.Zero(t, deliveryDriverID)
+	})
+	s.run(t, "AssignDeliveryDriverToOrder/NonExistingOrderAndDriver", func(t *testing.T, store storage.Storage) {
+		err := store.AssignDeliveryDriverToOrder(ctx, missingID, genericID, adminID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound, "the order is looked up before the driver")
+	})
+	s.run(t, "AssignDeliveryDriverToOrder/CancelledOrder", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		order := placedOrder(t, store, adminID)
+		_, _, err := store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: adminID})
+		require.NoError(t, err)
+
+		err = store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID)
+		deliveryDriverID, _ := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderCannotGetADriver)
+		assert.Zero(t, deliveryDriverID)
+		assert.NotContains(t, eventKinds(events), types.DriverAssignedEvent)
+	})
+	s.run(t, "AssignDeliveryDriverToOrder/DeliveredOrder", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		order := placedOrder(t, store, adminID)
+		for _, status := range []string{types.OrderPreparing, types.OrderReady, types.OrderOutForDelivery, types.OrderDelivered} {
+			_, err := store.UpdateOrderStatus(ctx, order.ID, status, adminID)
+			require.NoError(t, err)
+		}
+
+		err := store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID)
+		deliveryDriverID, _ := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+
+		assert.EqualError(t, err, messageErrors.OrderCannotGetADriver)
+		assert.Zero(t, deliveryDriverID)
+	})
+	s.run(t, "DeleteDeliveryDriverFromOrder", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+		order := placedOrder(t, store, adminID)
+		require.NoError(t, store.AssignDeliveryDriverToOrder(ctx, order.ID, genericID, adminID))
+
+		err := store.DeleteDeliveryDriverFromOrder(ctx, order.ID, adminID)
+		deliveryDriverID, _ := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+		events, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Zero(t, deliveryDriverID)
+		assert.Equal(t, types.DriverUnassignedEvent, events[len(events)-1].Kind)
+	})
+	s.run(t, "DeleteDeliveryDriverFromOrder/OrderWithoutDriver", func(t *testing.T, store storage.Storage) {
+		order := placedOrder(t, store, adminID)
+		before, _ := store.GetOrderHistory(ctx, order.ID)
+
+		err := store.DeleteDeliveryDriverFromOrder(ctx, order.ID, adminID)
+		after, _ := store.GetOrderHistory(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, eventKinds(before), eventKinds(after))
+	})
+	s.run(t, "DeleteDeliveryDriverFromOrder/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteDeliveryDriverFromOrder(ctx, missingID, adminID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+	s.run(t, "GetDeliveryDriverFromOrder/OrderWithoutDriver", func(t *testing.T, store storage.Storage) {
+		order := newOrder(t, store, genericID)
+
+		deliveryDriverID, err := store.GetDeliveryDriverFromOrder(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.Zero(t, deliveryDriverID)
+	})
+	s.run(t, "GetDeliveryDriverFromOrder/NonExistingOrder", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetDeliveryDriverFromOrder(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.OrderNotFound)
+	})
+}
+
+/*****************/
+/***** USERS *****/
+/*****************/
+
+func (s suite) users(t *testing.T) {
+	s.run(t, "GetAllUsers", func(t *testing.T, store storage.Storage) {
+		users := store.GetAllUsers(ctx)
+
+		ids := []uint{}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		assert.ElementsMatch(t, []uint{adminID, genericID}, ids)
+	})
+	s.run(t, "SignUpUser", func(t *testing.T, store storage.Storage) {
+		newUser := types.User{Email: "new@gmail.com", Name: "new", LastName: "user", Password: "valid-password"}
+
+		err := store.SignUpUser(ctx, &newUser)
+		actual, errGetting := store.GetUserByEmail(ctx, "new@gmail.com")
+
+		assert.NoError(t, err)
+		assert.NoError(t, errGetting)
+		assert.NotContains(t, []uint{0, adminID, genericID}, newUser.ID)
+		assert.Equal(t, newUser.ID, actual.ID)
+		assert.NotEqual(t, "valid-password", actual.Password, "passwords are saved hashed")
+		assert.Empty(t, actual.Permissions)
+		assert.Empty(t, newUser.Orders)
+		assert.NoError(t, store.LogInUser(ctx, "new@gmail.com", "valid-password"))
+		assert.Len(t, store.GetAllUsers(ctx), 3)
+	})
+	s.run(t, "SignUpUser/ExistingEmail", func(t *testing.T, store storage.Storage) {
+		newUser := types.User{Email: "zzzzz@gmail.com", Name: "new", LastName: "user", Password: "valid-password"}
+
+		err := store.SignUpUser(ctx, &newUser)
+
+		assert.EqualError(t, err, messageErrors.EmailAlreadyExists)
+		assert.Len(t, store.GetAllUsers(ctx), 2)
+	})
+	s.run(t, "LogInUser", func(t *testing.T, store storage.Storage) {
+		err := store.LogInUser(ctx, "zzzzz@gmail.com", adminPassword)
+
+		assert.NoError(t, err)
+	})
+	s.run(t, "LogInUser/NonExistingEmail", func(t *testing.T, store storage.Storage) {
+		err := store.LogInUser(ctx, "nobody@gmail.com", adminPassword)
+
+		assert.EqualError(t, err, messageErrors.InvalidEmailOrPassword)
+	})
+	s.run(t, "LogInUser/IncorrectPassword", func(t *testing.T, store storage.Storage) {
+		err := store.LogInUser(ctx, "zzzzz@gmail.com", "incorrect-password")
+
+		assert.EqualError(t, err, messageErrors.InvalidEmailOrPassword)
+	})
+	s.run(t, "GetUserByEmail", func(t *testing.T, store storage.Storage) {
+		user, err := store.GetUserByEmail(ctx, "abcde@gmail.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, adminID, user.ID)
+		assert.Equal(t, "abcde", user.Name)
+		assert.True(t, user.IsAdmin())
+	})
+	s.run(t, "GetUserByEmail/NonExistingEmail", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetUserByEmail(ctx, "nobody@gmail.com")
+
+		assert.EqualError(t, err, messageErrors.UserEmailNotFound)
+	})
+	s.run(t, "GetUserByID", func(t *testing.T, store storage.Storage) {
+		user, err := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "zzzzz@gmail.com", user.Email)
+		assert.Equal(t, "world", user.LastName)
+		assert.False(t, user.IsAdmin())
+	})
+	s.run(t, "GetUserByID/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		_, err := store.GetUserByID(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+	s.run(t, "DeleteUserByID", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteUserByID(ctx, genericID)
+		_, errGetting := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.UserIDNotFound)
+		assert.Len(t, store.GetAllUsers(ctx), 1)
+	})
+	s.run(t, "DeleteUserByID/DeletesTheirDeliveryDriver", func(t *testing.T, store storage.Storage) {
+		addDeliveryDriver(t, store, genericDeliveryDriver)
+
+		err := store.DeleteUserByID(ctx, genericID)
+		_, errGetting := store.GetDeliveryDriverByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.EqualError(t, errGetting, messageErrors.DeliveryDriverNotFound)
+	})
+	s.run(t, "DeleteUserByID/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		err := store.DeleteUserByID(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+		assert.Len(t, store.GetAllUsers(ctx), 2)
+	})
+	s.run(t, "UpdateUser", func(t *testing.T, store storage.Storage) {
+		update := types.User{ID: genericID, Email: "updated@gmail.com", Name: "updated", LastName: "user", Password: "ignored", Permissions: []string{"admin"}}
+
+		updated, err := store.UpdateUser(ctx, update)
+		actual, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		for _, user := range []types.User{updated, actual} {
+			assert.Equal(t, "updated@gmail.com", user.Email)
+			assert.Equal(t, "updated", user.Name)
+			assert.Equal(t, "user", user.LastName)
+			assert.False(t, user.IsAdmin())
+		}
+		assert.NoError(t, store.LogInUser(ctx, "updated@gmail.com", adminPassword))
+	})
+	s.run(t, "UpdateUser/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		_, err := store.UpdateUser(ctx, types.User{ID: missingID, Email: "updated@gmail.com", Name: "updated", LastName: "user"})
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+	s.run(t, "UpdateUserPassword", func(t *testing.T, store storage.Storage) {
+		err := store.UpdateUserPassword(ctx, genericID, "new-password")
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "new-password", user.Password, "passwords are saved hashed")
+		assert.NoError(t, store.LogInUser(ctx, "zzzzz@gmail.com", "new-password"))
+		assert.EqualError(t, store.LogInUser(ctx, "zzzzz@gmail.com", adminPassword), messageErrors.InvalidEmailOrPassword)
+		assert.NoError(t, store.LogInUser(ctx, "abcde@gmail.com", adminPassword), "other users keep their password")
+	})
+	s.run(t, "UpdateUserPassword/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		err := store.UpdateUserPassword(ctx, missingID, "new-password")
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+	s.run(t, "PromoteUserToAdmin", func(t *testing.T, store storage.Storage) {
+		err := store.PromoteUserToAdmin(ctx, genericID)
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.True(t, user.IsAdmin())
+	})
+	s.run(t, "PromoteUserToAdmin/Admin", func(t *testing.T, store storage.Storage) {
+		err := store.PromoteUserToAdmin(ctx, adminID)
+		user, _ := store.GetUserByID(ctx, adminID)
+
+		assert.EqualError(t, err, messageErrors.UserIsAlreadyAnAdmin)
+		assert.Equal(t, []string{"admin"}, user.Permissions)
+	})
+	s.run(t, "PromoteUserToAdmin/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		err := store.PromoteUserToAdmin(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+	s.run(t, "PromoteUserToStaff", func(t *testing.T, store storage.Storage) {
+		err := store.PromoteUserToStaff(ctx, genericID)
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.NoError(t, err)
+		assert.True(t, user.IsStaff())
+		assert.False(t, user.IsAdmin())
+	})
+	s.run(t, "PromoteUserToStaff/Staff", func(t *testing.T, store storage.Storage) {
+		require.NoError(t, store.PromoteUserToStaff(ctx, genericID))
+
+		err := store.PromoteUserToStaff(ctx, genericID)
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.EqualError(t, err, messageErrors.UserIsAlreadyStaff)
+		assert.Equal(t, []string{"staff"}, user.Permissions)
+	})
+	s.run(t, "PromoteUserToStaff/NonExistingUser", func(t *testing.T, store storage.Storage) {
+		err := store.PromoteUserToStaff(ctx, missingID)
+
+		assert.EqualError(t, err, messageErrors.UserIDNotFound)
+	})
+}
+
+/************************/
+/***** TRANSACTIONS *****/
+/************************/
+
+func (s suite) transactions(t *testing.T) {
+	s.run(t, "WithinTransaction/KeepsItsChangesWhenItSucceeds", func(t *testing.T, store storage.Storage) {
+		var order types.Order
+
+		err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+			order = types.Order{Address: "Calle 123", UserID: genericID}
+			if err := tx.CreateOrder(ctx, &order); err != nil {
+				return err
+			}
+			tub := halfKiloTub
+			return tx.AddIceCreamTubByOrderID(ctx, order.ID, &tub)
+		})
+		actual, errGetting := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, errGetting)
+		assert.Len(t, actual.IceCreamTubs, 1)
+		assert.Equal(t, ars(500), actual.TotalCost)
+	})
+	s.run(t, "WithinTransaction/DiscardsItsChangesWhenItFails", func(t *testing.T, store storage.Storage) {
+		failure := errors.New("the unit of work failed")
+
+		err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+			order := types.Order{Address: "Calle 123", UserID: genericID, IceCreamTubs: []types.IceCreamTub{halfKiloTub}}
+			if err := tx.CreateOrder(ctx, &order); err != nil {
+				return err
+			}
+			if err := tx.AddFlavorCategory(ctx, types.FlavorCategory{ID: "frutales", Name: "Frutales"}); err != nil {
+				return err
+			}
+			if _, err := tx.UpdateFlavorStock(ctx, "mrc", 1); err != nil {
+				return err
+			}
+			deliveryDriver := genericDeliveryDriver
+			if err := tx.AddDeliveryDriver(ctx, &deliveryDriver); err != nil {
+				return err
+			}
+			return failure
+		})
+		ddl, _ := store.GetFlavorByID(ctx, "ddl")
+		mrc, _ := store.GetFlavorByID(ctx, "mrc")
+		_, errGetting := store.GetFlavorCategoryByID(ctx, "frutales")
+		user, _ := store.GetUserByID(ctx, genericID)
+
+		assert.ErrorIs(t, err, failure)
+		assert.Empty(t, store.GetAllOrders(ctx))
+		assert.Equal(t, uint(10000), ddl.Stock)
+		assert.Equal(t, uint(10000), mrc.Stock)
+		assert.EqualError(t, errGetting, messageErrors.FlavorCategoryNotFound)
+		assert.Empty(t, store.GetDeliveryDrivers(ctx))
+		assert.False(t, user.IsDeliveryDriver())
+	})
+	s.run(t, "WithinTransaction/ReturnsTheErrorOfAFailedOperation", func(t *testing.T, store storage.Storage) {
+		err := store.WithinTransaction(ctx, func(tx storage.Storage) error {
+			if err := tx.AddFlavorCategory(ctx, types.FlavorCategory{ID: "frutales", Name: "Frutales"}); err != nil {
+				return err
+			}
+			return tx.AddFlavorCategory(ctx, types.FlavorCategory{ID: "frutales", Name: "Frutales"})
+		})
+		_, errGetting := store.GetFlavorCategoryByID(ctx, "frutales")
+
+		assert.EqualError(t, err, messageErrors.AlreadyExistingFlavorCategory)
+		assert.EqualError(t, errGetting, messageErrors.FlavorCategoryNotFound)
+	})
+	s.run(t, "WithinTransaction/OrdersCreatedAfterADiscardedOneAreSaved", func(t *testing.T, store storage.Storage) {
+		_ = store.WithinTransaction(ctx, func(tx storage.Storage) error {
+			order := types.Order{Address: "Calle 123", UserID: genericID, IceCreamTubs: []types.IceCreamTub{halfKiloTub}}
+			_ = tx.CreateOrder(ctx, &order)
+			return errors.New("the unit of work failed")
+		})
+
+		order := newOrder(t, store, genericID, quarterKiloTub)
+		actual, err := store.GetOrderByID(ctx, order.ID)
+
+		assert.NoError(t, err)
+		require.Len(t, actual.IceCreamTubs, 1)
+		assert.Equal(t, order.IceCreamTubs[0].ID, actual.IceCreamTubs[0].ID)
+		assert.Len(t, store.GetAllOrders(ctx), 1)
+	})
+}
+
+/*******************/
+/***** HELPERS *****/
+/*******************/
+
+// newOrder creates a draft order for a user with some tubs, and fails the test if it cannot be created.
+func newOrder(t *testing.T, store storage.Storage, userID uint, tubs ...types.IceCreamTub) types.Order {
+	t.Helper()
+	order := types.Order{Address: "Calle 123", UserID: userID, IceCreamTubs: slices.Clone(tubs)}
+	require.NoError(t, store.CreateOrder(ctx, &order))
+	return order
+}
+
+// payOrder starts the payment of an order and marks it as paid with its total, like the api does once the customer is charged.
+func payOrder(t *testing.T, store storage.Storage, idOrder uint, paymentReference string) {
+	t.Helper()
+	started, err := store.StartOrderPayment(ctx, idOrder)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOrderAsPaid(ctx, idOrder, paymentReference, started.TotalCost))
+}
+
+// placedOrder creates an order for a user with a tub and places it.
+func placedOrder(t *testing.T, store storage.Storage, userID uint) types.Order {
+	t.Helper()
+	order := newOrder(t, store, userID, quarterKiloTub)
+	placed, err := store.UpdateOrderStatus(ctx, order.ID, types.OrderPlaced, userID)
+	require.NoError(t, err)
+	return placed
+}
+
+// scheduledOrder creates an order with a tub, to be delivered on a day in a window of an hour starting at the given hour.
+func scheduledOrder(t *testing.T, store storage.Storage, day time.Time, hour int) types.Order {
+	t.Helper()
+	start := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.Local)
+	order := types.Order{
+		Address:        "Calle 123",
+		UserID:         genericID,
+		IceCreamTubs:   []types.IceCreamTub{quarterKiloTub},
+		DeliveryWindow: &types.DeliveryWindow{Start: start, End: start.Add(time.Hour)},
+	}
+	require.NoError(t, store.CreateOrder(ctx, &order))
+	return order
+}
+
+// addPromoCode validates a promo code, like the api does, and adds it.
+func addPromoCode(t *testing.T, store storage.Storage, promo types.PromoCode) types.PromoCode {
+	t.Helper()
+	require.NoError(t, promo.Validate(storeSettings.Currency))
+	require.NoError(t, store.AddPromoCode(ctx, promo))
+	return promo
+}
+
+func addDeliveryDriver(t *testing.T, store storage.Storage, deliveryDriver types.DeliveryDriver) {
+	t.Helper()
+	deliveryDriver.Vehicles = slices.Clone(deliveryDriver.Vehicles)
+	require.NoError(t, store.AddDeliveryDriver(ctx, &deliveryDriver))
+}
+
+func flavorIDs(flavors []types.Flavor) []string {
+	ids := []string{}
+	for _, flavor := range flavors {
+		ids = append(ids, flavor.ID)
+	}
+	return ids
+}
+
+func orderIDs(orders []types.Order) []uint {
+	ids := []uint{}
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
+
+func eventKinds(events []types.OrderEvent) []string {
+	kinds := []string{}
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+	}
+	return kinds
+}
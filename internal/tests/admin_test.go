@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"icecreamshop/internal/admin"
+	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/***********************/
+/***** ADMIN TESTS *****/
+/***********************/
+
+func TestCreatingAnAdminFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+	newUser := types.User{Email: "owner@gmail.com", Name: "shop", LastName: "owner", Password: "owner1234"}
+
+	user, err := admin.CreateUser(ctx, store, newUser, true)
+	errLogIn := store.LogInUser(ctx, newUser.Email, newUser.Password)
+
+	assert.NoError(t, err)
+	assert.NotZero(t, user.ID)
+	assert.Contains(t, user.Permissions, admin.AdminRole)
+	assert.NoError(t, errLogIn)
+}
+
+func TestCannotCreateAUserWithAnExistingEmailFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+	newUser := types.User{Email: genericUser.Email, Name: "hello", LastName: "again", Password: "admin1234"}
+
+	_, err := admin.CreateUser(ctx, store, newUser, true)
+
+	assert.EqualError(t, err, messageErrors.EmailAlreadyExists)
+	assert.Len(t, store.GetAllUsers(ctx), len(users))
+}
+
+func TestPromotingAUserFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+
+	errAdmin := admin.PromoteUser(ctx, store, genericUser.Email, admin.AdminRole)
+	errUnknown := admin.PromoteUser(ctx, store, "nobody@gmail.com", admin.AdminRole)
+	user, _ := store.GetUserByID(ctx, genericUser.ID)
+
+	assert.NoError(t, errAdmin)
+	assert.Contains(t, user.Permissions, admin.AdminRole)
+	assert.EqualError(t, errUnknown, messageErrors.UserEmailNotFound)
+}
+
+func TestResettingAPasswordFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+
+	err := admin.ResetPassword(ctx, store, genericUser.Email, "brand-new-password")
+	errOldPassword := store.LogInUser(ctx, genericUser.Email, "admin123")
+	errNewPassword := store.LogInUser(ctx, genericUser.Email, "brand-new-password")
+
+	assert.NoError(t, err)
+	assert.Error(t, errOldPassword)
+	assert.NoError(t, errNewPassword)
+}
+
+func TestCannotResetAPasswordToAShortOneFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+
+	err := admin.ResetPassword(ctx, store, genericUser.Email, "short")
+	errLogIn := store.LogInUser(ctx, genericUser.Email, "admin123")
+
+	assert.EqualError(t, err, messageErrors.PasswordIsTooShort)
+	assert.NoError(t, errLogIn)
+}
+
+func TestImportingFlavorsAddsTheNewOnesAndReplacesTheExistingOnes(t *testing.T) {
+	store := newStorage(flavors, []types.User{}, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+	file := `[
+		{"id": "ddl", "name": "Dulce de leche granizado", "categoryID": "dulce-de-leches", "stock": 500},
+		{"id": "lmn", "name": "Limon", "categoryID": "al-agua", "stock": 2000}
+	]`
+
+	result, err := admin.ImportFlavors(ctx, store, strings.NewReader(file))
+	updated, _ := store.GetFlavorByID(ctx, "ddl")
+	added, errAdded := store.GetFlavorByID(ctx, "lmn")
+
+	assert.NoError(t, err)
+	assert.Equal(t, admin.ImportResult{Added: []string{"lmn"}, Updated: []string{"ddl"}}, result)
+	assert.Equal(t, "Dulce de leche granizado", updated.Name)
+	assert.Equal(t, uint(500), updated.Stock)
+	assert.NoError(t, errAdded)
+	assert.Equal(t, uint(2000), added.Stock)
+}
+
+func TestImportingAnInvalidFlavorImportsNothing(t *testing.T) {
+	store := newStorage(flavors, []types.User{}, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+	file := `[
+		{"id": "lmn", "name": "Limon", "categoryID": "al-agua", "stock": 2000},
+		{"id": "", "name": "Without id", "categoryID": "al-agua"}
+	]`
+
+	_, err := admin.ImportFlavors(ctx, store, strings.NewReader(file))
+	_, errAdded := store.GetFlavorByID(ctx, "lmn")
+
+	assert.ErrorContains(t, err, messageErrors.FlavorIdIsRequired)
+	assert.EqualError(t, errAdded, messageErrors.FlavorNotFound)
+	assert.Len(t, store.GetFlavors(ctx), len(flavors))
+}
+
+func TestImportingFlavorsWithAnInvalidJsonFormat(t *testing.T) {
+	store := newStorage(flavors, []types.User{}, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+
+	_, err := admin.ImportFlavors(ctx, store, strings.NewReader(`{"id": "lmn"}`))
+
+	assert.EqualError(t, err, messageErrors.InvalidJsonFormat)
+}
+
+func TestSettingPricesFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, []types.User{}, prices)
+	defer clearAndCloseConnection(t, store)
+	newSize := types.IceCreamTubPrice{Weight: 2000, Price: types.NewMoney(1800, "ARS"), MaxFlavors: 5}
+	newPrice := types.IceCreamTubPrice{Weight: 250, Price: types.NewMoney(350, "ARS"), MaxFlavors: 2}
+
+	addedNewSize, errNewSize := admin.SetPrice(ctx, store, testConfig.Store, newSize)
+	addedNewPrice, errNewPrice := admin.SetPrice(ctx, store, testConfig.Store, newPrice)
+	size, _ := store.GetPriceByWeight(ctx, 2000)
+	price, _ := store.GetPriceByWeight(ctx, 250)
+
+	assert.NoError(t, errNewSize)
+	assert.True(t, addedNewSize)
+	assert.Equal(t, newSize.Price, size.Price)
+	assert.NoError(t, errNewPrice)
+	assert.False(t, addedNewPrice)
+	assert.Equal(t, newPrice.Price, price.Price)
+	assert.Equal(t, uint(2), price.MaxFlavors)
+}
+
+func TestCannotSetAPriceForAZeroWeightFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, []types.User{}, prices)
+	defer clearAndCloseConnection(t, store)
+
+	_, err := admin.SetPrice(ctx, store, testConfig.Store, types.IceCreamTubPrice{Price: types.NewMoney(100, "ARS"), MaxFlavors: 2})
+
+	assert.EqualError(t, err, messageErrors.WeightCannotBeZero)
+	assert.Len(t, store.GetPrices(ctx), len(prices))
+}
+
+func TestListingOrdersFromTheAdminCommands(t *testing.T) {
+	store := newStorage(flavors, users, prices)
+	defer clearAndCloseConnection(t, store)
+	adminOrder := types.Order{Address: "Calle 123", UserID: adminUser.ID, IceCreamTubs: []types.IceCreamTub{newValidIceCreamTub}}
+	require.NoError(t, store.CreateOrder(ctx, &adminOrder))
+	_, err := store.UpdateOrderStatus(ctx, adminOrder.ID, types.OrderPlaced, adminUser.ID)
+	require.NoError(t, err)
+	genericOrder := types.Order{Address: "Calle 789", UserID: genericUser.ID}
+	require.NoError(t, store.CreateOrder(ctx, &genericOrder))
+
+	all, errAll := admin.ListOrders(ctx, store, admin.OrderFilter{})
+	placed, errPlaced := admin.ListOrders(ctx, store, admin.OrderFilter{Statuses: []string{types.OrderPlaced}})
+	byUser, errByUser := admin.ListOrders(ctx, store, admin.OrderFilter{UserEmail: genericUser.Email})
+	byUserAndStatus, _ := admin.ListOrders(ctx, store, admin.OrderFilter{Statuses: []string{types.OrderPlaced}, UserEmail: genericUser.Email})
+
+	assert.NoError(t, errAll)
+	assert.Equal(t, []uint{adminOrder.ID, genericOrder.ID}, []uint{all[0].ID, all[1].ID})
+	assert.NoError(t, errPlaced)
+	assert.Len(t, placed, 1)
+	assert.Equal(t, adminOrder.ID, placed[0].ID)
+	assert.NoError(t, errByUser)
+	assert.Len(t, byUser, 1)
+	assert.Equal(t, genericOrder.ID, byUser[0].ID)
+	assert.Empty(t, byUserAndStatus)
+}
+
+func TestListingOrdersFromTheAdminCommandsWithADatabase(t *testing.T) {
+	config := testConfig
+	config.Database.SQLitePath = filepath.Join(t.TempDir(), "admin.db")
+	store := seeded(storage.NewSQLiteStorage(config), categories, flavors, users, prices)
+	defer clearAndCloseConnection(t, store)
+	genericOrder := types.Order{Address: "Calle 789", UserID: genericUser.ID, IceCreamTubs: []types.IceCreamTub{newValidIceCreamTub}}
+	require.NoError(t, store.CreateOrder(ctx, &genericOrder))
+	adminOrder := types.Order{Address: "Calle 123", UserID: adminUser.ID, IceCreamTubs: []types.IceCreamTub{newValidIceCreamTub, newValidIceCreamTub}}
+	require.NoError(t, store.CreateOrder(ctx, &adminOrder))
+
+	all, err := admin.ListOrders(ctx, store, admin.OrderFilter{})
+
+	assert.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, []uint{genericOrder.ID, adminOrder.ID}, []uint{all[0].ID, all[1].ID})
+	assert.Len(t, all[0].IceCreamTubs, 1)
+	assert.Len(t, all[1].IceCreamTubs, 2)
+}
+
+func TestCannotListOrdersWithAnInvalidStatusOrUserFromTheAdminCommands(t *testing.T) {
+	store := newStorage([]types.Flavor{}, users, []types.IceCreamTubPrice{})
+	defer clearAndCloseConnection(t, store)
+
+	_, errStatus := admin.ListOrders(ctx, store, admin.OrderFilter{Statuses: []string{"lost"}})
+	_, errUser := admin.ListOrders(ctx, store, admin.OrderFilter{UserEmail: "nobody@gmail.com"})
+
+	assert.EqualError(t, errStatus, messageErrors.InvalidOrderStatus)
+	assert.EqualError(t, errUser, messageErrors.UserEmailNotFound)
+}
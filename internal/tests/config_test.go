@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"icecreamshop/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/************************/
+/***** CONFIG TESTS *****/
+/************************/
+
+func TestTheConfigIsReadFromTheEnv(t *testing.T) {
+	t.Setenv("API_HOST", "0.0.0.0")
+	t.Setenv("API_PORT", "9090")
+	t.Setenv("READ_TIMEOUT", "5s")
+	t.Setenv("WRITE_TIMEOUT", "")
+	t.Setenv("DB_DRIVER", "SQLite")
+	t.Setenv("STORE_CURRENCY", "usd")
+
+	settings, args, err := config.Load(nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, "0.0.0.0:9090", settings.Server.Address())
+	assert.Equal(t, 5*time.Second, settings.Server.ReadTimeout)
+	assert.Equal(t, config.Default().Server.WriteTimeout, settings.Server.WriteTimeout, "empty settings keep their default")
+	assert.Equal(t, config.SQLiteDriver, settings.Database.Driver)
+	assert.Equal(t, "USD", settings.Store.Currency)
+}
+
+func TestTheConfigFileOverridesTheEnvAndTheFlagsOverrideTheFile(t *testing.T) {
+	t.Setenv("API_HOST", "env-host")
+	t.Setenv("API_PORT", "9090")
+	t.Setenv("READ_TIMEOUT", "5s")
+	path := writeConfigFile(t, "shop.yaml", `
+server:
+  port: 9091
+  read_timeout: 7s
+store:
+  opening_time: "10:00"
+`)
+
+	settings, args, err := config.Load([]string{"--config", path, "--port", "9092", "migrate", "status"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "status"}, args, "the command and its args are returned")
+	assert.Equal(t, "env-host", settings.Server.Host)
+	assert.Equal(t, "9092", settings.Server.Port)
+	assert.Equal(t, 7*time.Second, settings.Server.ReadTimeout)
+	assert.Equal(t, "10:00", settings.Store.OpeningTime)
+}
+
+func TestTheConfigCanBeReadFromATOMLFileSetInTheEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "shop.toml", `
+mode = "production"
+
+[server]
+port = 9093
+
+[database]
+driver = "sqlite"
+sqlite_path = "/var/lib/icecreamshop/shop.db"
+`))
+
+	settings, _, err := config.Load(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, config.Production, settings.Mode)
+	assert.Equal(t, "9093", settings.Server.Port)
+	assert.Equal(t, "/var/lib/icecreamshop/shop.db", settings.Database.SQLitePath)
+}
+
+func TestCannotLoadAConfigFileWithAnUnknownSetting(t *testing.T) {
+	path := writeConfigFile(t, "shop.yaml", "server:\n  prot: 9091\n")
+
+	_, _, err := config.Load([]string{"--config", path})
+
+	assert.EqualError(t, err, "server.prot in "+path+" is not a setting")
+}
+
+func TestCannotLoadAConfigFileWithAnUnknownFormat(t *testing.T) {
+	path := writeConfigFile(t, "shop.json", "{}")
+
+	_, _, err := config.Load([]string{"--config", path})
+
+	assert.Error(t, err)
+}
+
+func TestCannotLoadAConfigWithAnInvalidDuration(t *testing.T) {
+	t.Setenv("READ_TIMEOUT", "soon")
+
+	_, _, errEnv := config.Load(nil)
+	t.Setenv("READ_TIMEOUT", "")
+	_, _, errFlag := config.Load([]string{"--shutdown-timeout", "-1s"})
+
+	assert.EqualError(t, errEnv, "READ_TIMEOUT env must be a duration, like 10s")
+	assert.EqualError(t, errFlag, "--shutdown-timeout flag must be a duration, like 10s")
+}
+
+func TestAskingForHelpWhenLoadingTheConfig(t *testing.T) {
+	_, _, err := config.Load([]string{"-h"})
+
+	assert.ErrorIs(t, err, flag.ErrHelp)
+}
+
+func TestTheTestingModeDoesNotNeedADatabaseNorPayments(t *testing.T) {
+	settings := config.Default()
+	settings.Mode = config.Testing
+	settings.Auth.JWTSecret = "secret"
+
+	err := settings.Validate()
+
+	assert.NoError(t, err)
+}
+
+func TestTheProductionModeNeedsThePostgresSettings(t *testing.T) {
+	settings := productionConfig()
+	settings.Database.User = ""
+
+	err := settings.Validate()
+
+	assert.EqualError(t, err, "database.user is needed, set the DB_USER env, database.user in the config file or the --db-user flag")
+}
+
+func TestTheProductionModeWithSQLiteDoesNotNeedThePostgresSettings(t *testing.T) {
+	settings := productionConfig()
+	settings.Database.Driver = config.SQLiteDriver
+	settings.Database.Postgres = config.Postgres{}
+
+	err := settings.Validate()
+
+	assert.NoError(t, err)
+}
+
+func TestTheProductionModeNeedsThePaymentSettings(t *testing.T) {
+	settings := productionConfig()
+	settings.Payment.MPAccessToken = ""
+
+	err := settings.Validate()
+
+	assert.EqualError(t, err, "payment.mp_access_token is needed, set the MP_ACCESS_TOKEN env or payment.mp_access_token in the config file")
+}
+
+func TestTheTestingModeUsesTheTestDatabase(t *testing.T) {
+	settings := productionConfig()
+	settings.Mode = config.Testing
+	settings.TestDatabase = config.Postgres{Host: "localhost", Port: "5433", User: "test_user", Password: "test_password", Name: "app_test"}
+
+	errWithTestDatabase := settings.ValidateDatabase()
+	settings.TestDatabase.Name = ""
+	errWithoutTestDatabase := settings.ValidateDatabase()
+
+	assert.NoError(t, errWithTestDatabase)
+	assert.Equal(t, settings.TestDatabase, settings.PostgresDatabase())
+	assert.EqualError(t, errWithoutTestDatabase, "test_database.name is needed, set the TEST_DB_NAME env or test_database.name in the config file")
+}
+
+func TestCannotValidateAConfigWithInvalidSettings(t *testing.T) {
+	invalidMode := productionConfig()
+	invalidMode.Mode = "staging"
+	invalidPort := productionConfig()
+	invalidPort.Server.Port = "http"
+	halfTLS := productionConfig()
+	halfTLS.Server.TLSCertFile = "cert.pem"
+	invalidDriver := productionConfig()
+	invalidDriver.Database.Driver = "mysql"
+	invalidCurrency := productionConfig()
+	invalidCurrency.Store.Currency = "PESOS"
+	missingSecret := productionConfig()
+	missingSecret.Auth.JWTSecret = ""
+
+	assert.EqualError(t, invalidMode.Validate(), "mode must be development, production or testing")
+	assert.EqualError(t, invalidPort.Validate(), "server.port must be a port number")
+	assert.EqualError(t, halfTLS.Validate(), "server.tls_cert_file must be set together with server.tls_key_file")
+	assert.EqualError(t, invalidDriver.Validate(), "database.driver must be postgres or sqlite")
+	assert.EqualError(t, invalidCurrency.Validate(), "store.currency must be a three letters ISO 4217 code")
+	assert.EqualError(t, missingSecret.Validate(), "auth.jwt_secret is needed, set the JWT_SECRET env or auth.jwt_secret in the config file")
+}
+
+// productionConfig is a valid config of the production mode, with Postgres.
+func productionConfig() config.Config {
+	settings := config.Default()
+	settings.Mode = config.Production
+	settings.Auth.JWTSecret = "secret"
+	settings.Payment.MPAccessToken = "token"
+	settings.Database.Postgres = config.Postgres{Host: "localhost", Port: "5432", User: "postgres", Password: "postgres", Name: "postgres"}
+	return settings
+}
+
+// writeConfigFile writes a config file with a name in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
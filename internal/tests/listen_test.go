@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"icecreamshop/internal/api"
+	"icecreamshop/internal/config"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/types"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/************************/
+/***** LISTEN TESTS *****/
+/************************/
+
+func TestStoppingTheServerAnswersTheRequestsInProgressAndClosesTheStorage(t *testing.T) {
+	store := &slowStore{Storage: newStorage(flavors, users, prices), delay: 300 * time.Millisecond}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- api.NewServer(store, serverConfig(config.Server{ShutdownTimeout: 5 * time.Second})).Serve(ctx, listener)
+	}()
+
+	answered := make(chan int, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String() + "/flavors")
+		if err != nil {
+			answered <- 0
+			return
+		}
+		response.Body.Close()
+		answered <- response.StatusCode
+	}()
+	require.Eventually(t, func() bool { return store.requests.Load() == 1 }, time.Second, 10*time.Millisecond)
+	stop()
+	err = <-served
+	_, errNewRequest := http.Get("http://" + listener.Addr().String() + "/flavors")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, <-answered, "the request in progress is answered")
+	assert.True(t, store.closed.Load(), "the storage is closed")
+	assert.Error(t, errNewRequest, "new connections are refused")
+}
+
+func TestTheServerIsServedOverTLSWithACertificate(t *testing.T) {
+	store := &slowStore{Storage: newStorage(flavors, users, prices)}
+	certFile, keyFile, pool := selfSignedCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- api.NewServer(store, serverConfig(config.Server{TLSCertFile: certFile, TLSKeyFile: keyFile})).Serve(ctx, listener)
+	}()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	response, err := client.Get("https://" + listener.Addr().String() + "/flavors")
+	require.NoError(t, err)
+	response.Body.Close()
+	plainResponse, err := http.Get("http://" + listener.Addr().String() + "/flavors")
+	require.NoError(t, err)
+	plainResponse.Body.Close()
+	stop()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotNil(t, response.TLS)
+	assert.Equal(t, http.StatusBadRequest, plainResponse.StatusCode, "plain http requests are rejected")
+	assert.NoError(t, <-served)
+	assert.True(t, store.closed.Load())
+}
+
+func TestServingFailsWithAMissingCertificateAndClosesTheStorage(t *testing.T) {
+	store := &slowStore{Storage: newStorage(flavors, users, prices)}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+
+	err = api.NewServer(store, serverConfig(config.Server{TLSCertFile: missing, TLSKeyFile: missing})).Serve(context.Background(), listener)
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.True(t, store.closed.Load())
+}
+
+// serverConfig is the config of the tests with other settings for the http server.
+func serverConfig(server config.Server) config.Config {
+	settings := testConfig
+	settings.Server = server
+	return settings
+}
+
+// slowStore is a storage that takes a delay to get the flavors and records when it is closed,
+// so tests can stop the server while a request is in progress.
+type slowStore struct {
+	storage.Storage
+	delay    time.Duration
+	requests atomic.Int32
+	closed   atomic.Bool
+}
+
+func (store *slowStore) GetFlavors(ctx context.Context) []types.Flavor {
+	store.requests.Add(1)
+	time.Sleep(store.delay)
+	return store.Storage.GetFlavors(ctx)
+}
+
+func (store *slowStore) Close() error {
+	store.closed.Store(true)
+	return store.Storage.Close()
+}
+
+// selfSignedCertificate writes a certificate for 127.0.0.1 and its key to PEM files,
+// returning their paths and a pool that trusts the certificate.
+func selfSignedCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "icecreamshop"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return certFile, keyFile, pool
+}
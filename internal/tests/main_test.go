@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"icecreamshop/internal/api"
+	"icecreamshop/internal/auth"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/storage"
 	"icecreamshop/internal/types"
 	"log"
@@ -16,42 +18,46 @@ func TestMain(m *testing.M) {
 	if err := godotenv.Load(envPath); err != nil {
 		log.Fatal("Error loading .env:", err)
 	}
-	testMode := os.Getenv("TEST_MODE")
-	apiEnv := os.Getenv("API_ENV")
-
-	if apiEnv != "testing" {
+	var err error
+	testConfig, _, err = config.Load(nil)
+	if err != nil {
+		log.Fatal("Error loading the config:", err)
+	}
+	if testConfig.Mode != config.Testing {
 		panic("env var API_ENV must be set to testing")
 	}
-
-	code := m.Run()
-	if testMode == "integration" {
-		println("Integration tests have finished")
-	} else {
-		println("Mocking tests have finished")
+	if err := testConfig.Validate(); err != nil {
+		log.Fatal("Invalid config:", err)
 	}
-	os.Exit(code)
+	tokens = auth.NewTokens(testConfig.Auth)
+
+	os.Exit(m.Run())
+}
 
+// newStorage builds the storage the api tests run against. The storages behave the same, as the conformance tests check,
+// so the api is tested with the one in memory.
+func newStorage(flavors []types.Flavor, users []types.User, prices []types.IceCreamTubPrice) storage.Storage {
+	return storage.NewMemoryStorage(testConfig.Store, categories, flavors, users, prices)
 }
 
-func newStorage(flavors []types.Flavor, users []types.User, prices map[uint]uint) storage.Storage {
-	testMode := os.Getenv("TEST_MODE")
-	if testMode == "integration" {
-		return storage.NewDBStorage(flavors, users, prices)
-	} else {
-		return storage.NewMemoryStorage(flavors, users, prices)
+// seeded fills a database storage with the initial data of a test.
+func seeded(store *storage.DbStorage, categories []types.FlavorCategory, flavors []types.Flavor, users []types.User, prices []types.IceCreamTubPrice) *storage.DbStorage {
+	if err := storage.Seed(store.DB, categories, flavors, users, prices); err != nil {
+		panic("failed to seed the database: " + err.Error())
 	}
+	return store
 }
 
 var sv *api.Server
 var router *gin.Engine
 
 func setup() {
-	sv = api.NewServer(newStorage(flavors, users, prices))
+	sv = api.NewServer(newStorage(flavors, users, prices), testConfig)
 	router = sv.SetupRouter()
 }
 
 func clearAndCloseConnection(t *testing.T, store storage.Storage) {
-	err := store.CleanDB()
+	err := store.CleanDB(ctx)
 	if err != nil {
 		t.Error(err)
 	}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"icecreamshop/internal/storage"
+	"icecreamshop/internal/storage/migrations"
+	"icecreamshop/internal/types"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+/****************************/
+/***** MIGRATIONS TESTS *****/
+/****************************/
+
+func TestMigratingANewDatabaseAppliesEveryMigration(t *testing.T) {
+	db := openSQLite(t)
+	all, _ := migrations.All(db)
+
+	applied, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	statuses, _ := migrations.Statuses(db)
+	pending, _ := migrations.Pending(db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, all, applied)
+	assert.Empty(t, pending)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt)
+	}
+	assert.True(t, db.Migrator().HasTable(&types.Order{}))
+}
+
+func TestMigratingAnUpToDateDatabaseAppliesNothing(t *testing.T) {
+	db := openSQLite(t)
+	_, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+
+	applied, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestRevertingTheLastMigrationMakesItPending(t *testing.T) {
+	db := openSQLite(t)
+	applied, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+	last := applied[len(applied)-1]
+
+	reverted, err := migrations.Down(db, 1)
+	pending, _ := migrations.Pending(db)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []migrations.Migration{last}, reverted)
+	assert.Equal(t, []migrations.Migration{last}, pending)
+}
+
+func TestRevertingEveryMigrationDropsAllTheTables(t *testing.T) {
+	db := openSQLite(t)
+	applied, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+
+	reverted, err := migrations.Down(db, len(applied)+1)
+
+	assert.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+	assert.Equal(t, applied[0], reverted[len(reverted)-1], "migrations are reverted newest first")
+	assert.False(t, db.Migrator().HasTable(&types.User{}))
+	assert.False(t, db.Migrator().HasTable(&types.Order{}))
+}
+
+func TestRevertingNoMigrationsIsAnError(t *testing.T) {
+	db := openSQLite(t)
+
+	_, err := migrations.Down(db, 0)
+
+	assert.Error(t, err)
+}
+
+func TestMigrationsCanBeAppliedAgainAfterBeingReverted(t *testing.T) {
+	db := openSQLite(t)
+	_, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+	_, err = migrations.Down(db, 1)
+	require.NoError(t, err)
+
+	applied, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+}
+
+func TestADatabaseCreatedWithAutoMigrateIsAdoptedByTheMigrations(t *testing.T) {
+	db := openSQLite(t)
+	err := db.AutoMigrate(&types.User{}, &types.DeliveryDriver{}, &types.Order{}, &types.FlavorCategory{}, &types.Flavor{}, &types.IceCreamTub{}, &types.IceCreamTubPrice{}, &types.PromoCode{}, &types.OrderEvent{})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&types.User{Email: "old@gmail.com", Name: "old", LastName: "user", Password: "hash"}).Error)
+
+	_, err = storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	first, _ := migrations.Statuses(db)
+	var count int64
+	db.Model(&types.User{}).Count(&count)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, first[0].AppliedAt)
+	assert.Equal(t, int64(1), count, "the data of adopted databases is kept")
+}
+
+func TestTheMigratedSchemaRejectsFlavorsOfMissingCategories(t *testing.T) {
+	db := openSQLite(t)
+	_, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+
+	err = db.Create(&types.Flavor{ID: "orphan", Name: "Orphan", CategoryID: "missing"}).Error
+
+	assert.Error(t, err)
+}
+
+/*************************/
+/***** SEEDING TESTS *****/
+/*************************/
+
+func TestSeedingTwiceAddsTheInitialDataOnce(t *testing.T) {
+	db := openSQLite(t)
+	_, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+
+	errFirst := storage.Seed(db, slices.Clone(categories), slices.Clone(flavors), slices.Clone(users), slices.Clone(prices))
+	errSecond := storage.Seed(db, slices.Clone(categories), slices.Clone(flavors), slices.Clone(users), slices.Clone(prices))
+	store := &storage.DbStorage{DB: db}
+
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.Len(t, store.GetFlavorCategories(ctx), len(categories))
+	assert.Len(t, store.GetFlavors(ctx), len(flavors))
+	assert.Len(t, store.GetAllUsers(ctx), len(users))
+	assert.Len(t, store.GetPrices(ctx), len(prices))
+}
+
+func TestSeedingKeepsTheChangesMadeToTheInitialData(t *testing.T) {
+	db := openSQLite(t)
+	_, err := storage.Migrate(db, testConfig.Store.DefaultCurrency())
+	require.NoError(t, err)
+	require.NoError(t, storage.Seed(db, slices.Clone(categories), slices.Clone(flavors), slices.Clone(users), slices.Clone(prices)))
+	store := &storage.DbStorage{DB: db}
+	_, err = store.UpdateFlavorStock(ctx, flavorDDL.ID, 15)
+	require.NoError(t, err)
+
+	err = storage.Seed(db, slices.Clone(categories), slices.Clone(flavors), slices.Clone(users), slices.Clone(prices))
+	flavor, _ := store.GetFlavorByID(ctx, flavorDDL.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(15), flavor.Stock)
+}
+
+// openSQLite opens an empty SQLite database, closed when the test ends.
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "migrations.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
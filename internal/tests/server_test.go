@@ -1,15 +1,21 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"icecreamshop/internal/auth"
+	"icecreamshop/internal/api"
 	"icecreamshop/internal/messageErrors"
+	"icecreamshop/internal/middleware"
 	"icecreamshop/internal/types"
 	"icecreamshop/internal/utils"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 )
 
 /*********************************/
@@ -41,7 +47,7 @@ func TestSigningUpANewUser(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, w.Code)
 	assert.Equal(t, expectedUser, createdUser)
 
-	obtainedUser, err := sv.Store.GetUserByID(3)
+	obtainedUser, err := sv.Store.GetUserByID(ctx, 3)
 	obtainedUser.Password = ""
 	assert.NoError(t, err)
 	assert.True(t, expectedUser.IsEqualTo(obtainedUser))
@@ -191,7 +197,7 @@ func TestCannotLoginIfAlreadyLoggedIn(t *testing.T) {
 		Password: "admin123",
 	}
 
-	token := auth.GenerateTokenFromUserEmail(credentials.Email)
+	token := tokens.GenerateTokenFromUserEmail(credentials.Email)
 
 	w := requestWithCookie("POST", "/login", credentials, "Authorization", token)
 
@@ -203,14 +209,14 @@ func TestCannotLoginIfAlreadyLoggedIn(t *testing.T) {
 
 func TestGettingAllUsersWhenAnAdminIsLoggedIn(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("GET", "/users", nil, "Authorization", token)
 
 	var obtainedUsers []types.User
 	err := json.Unmarshal(w.Body.Bytes(), &obtainedUsers)
 
 	var expectedUsers []types.User
-	expectedUsers = sv.Store.GetAllUsers()
+	expectedUsers = sv.Store.GetAllUsers(ctx)
 	for i := range expectedUsers {
 		expectedUsers[i].Password = ""
 	}
@@ -224,7 +230,7 @@ func TestGettingAllUsersWhenAnAdminIsLoggedIn(t *testing.T) {
 
 func TestCannotGetAllUsersWhenAnAdminIsNotLoggedIn(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email) //not an admin
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email) //not an admin
 	w := requestWithCookie("GET", "/users", nil, "Authorization", token)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -234,7 +240,7 @@ func TestCannotGetAllUsersWhenAnAdminIsNotLoggedIn(t *testing.T) {
 
 func TestGettingAnUserByIDWhenAnAdminIsLoggedIn(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("GET", "/users/2", nil, "Authorization", token)
 
 	var obtainedUser types.User
@@ -250,7 +256,7 @@ func TestGettingAnUserByIDWhenAnAdminIsLoggedIn(t *testing.T) {
 
 func TestCannotGetAnUserByIDWhenAnAdminIsNotLoggedIn(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email) //not an admin
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email) //not an admin
 	w := requestWithCookie("GET", "/users/1", nil, "Authorization", token)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
@@ -260,7 +266,7 @@ func TestCannotGetAnUserByIDWhenAnAdminIsNotLoggedIn(t *testing.T) {
 
 func TestCannotGetUserByIDWhenIDIsNotAnInteger(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("GET", "/users/not-an-integer", nil, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
@@ -270,7 +276,7 @@ func TestCannotGetUserByIDWhenIDIsNotAnInteger(t *testing.T) {
 
 func TestCannotGetUserByIDWhenIDDoesNotExist(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email) //an admin
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email) //an admin
 	w := requestWithCookie("GET", "/users/100", nil, "Authorization", token)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.UserIDNotFound), w.Body.String())
@@ -279,7 +285,7 @@ func TestCannotGetUserByIDWhenIDDoesNotExist(t *testing.T) {
 
 func TestAnAdminCanDeleteAnUserByID(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("DELETE", "/users/1", nil, "Authorization", token)
 	assert.Equal(t, http.StatusNoContent, w.Code)
 	assert.Empty(t, w.Body.String())
@@ -288,7 +294,7 @@ func TestAnAdminCanDeleteAnUserByID(t *testing.T) {
 
 func TestCannotDeleteAnUserByIDWhenUserPermissionIsNotAdmin(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
 	w := requestWithCookie("DELETE", "/users/1", nil, "Authorization", token)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 	assert.Empty(t, w.Body.String())
@@ -297,7 +303,7 @@ func TestCannotDeleteAnUserByIDWhenUserPermissionIsNotAdmin(t *testing.T) {
 
 func TestCannotDeleteAnUserByIDWhenIDIsNotAnInteger(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("DELETE", "/users/hola", nil, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
@@ -306,7 +312,7 @@ func TestCannotDeleteAnUserByIDWhenIDIsNotAnInteger(t *testing.T) {
 
 func TestCannotDeleteAnUserByIDWhenIDDoesNotExist(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("DELETE", "/users/100", nil, "Authorization", token)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.UserIDNotFound), w.Body.String())
@@ -315,12 +321,12 @@ func TestCannotDeleteAnUserByIDWhenIDDoesNotExist(t *testing.T) {
 
 func TestAnAdminCanPromoteAnUserToAdmin(t *testing.T) {
 	setup()
-	userInDB, _ := sv.Store.GetUserByID(2)
+	userInDB, _ := sv.Store.GetUserByID(ctx, 2)
 	assert.False(t, userInDB.IsAdmin())
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/users/2/admin", nil, "Authorization", token)
-	userInDB, _ = sv.Store.GetUserByID(2)
+	userInDB, _ = sv.Store.GetUserByID(ctx, 2)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.True(t, userInDB.IsAdmin())
@@ -329,7 +335,7 @@ func TestAnAdminCanPromoteAnUserToAdmin(t *testing.T) {
 
 func TestAnAdminCannotPromoteANonExistingUserToAdmin(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/users/1000/admin", nil, "Authorization", token)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.UserIDNotFound), w.Body.String())
@@ -338,7 +344,7 @@ func TestAnAdminCannotPromoteANonExistingUserToAdmin(t *testing.T) {
 
 func TestAnUserIDMustBeAnIntegerToPromoteThem(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/users/not-integer/admin", nil, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
@@ -347,10 +353,10 @@ func TestAnUserIDMustBeAnIntegerToPromoteThem(t *testing.T) {
 
 func TestAnAdminCannotPromoteAnAdminToAdmin(t *testing.T) {
 	setup()
-	userInDB, _ := sv.Store.GetUserByID(1)
+	userInDB, _ := sv.Store.GetUserByID(ctx, 1)
 	assert.True(t, userInDB.IsAdmin())
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/users/1/admin", nil, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
@@ -360,7 +366,7 @@ func TestAnAdminCannotPromoteAnAdminToAdmin(t *testing.T) {
 
 func TestAnUserCanGetTheirAccount(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
 	w := requestWithCookie("GET", "/my-account", nil, "Authorization", token)
 
 	var expectedUser types.User
@@ -386,11 +392,11 @@ func TestCannotGetAnAccountIfNotLoggedIn(t *testing.T) {
 
 func TestAnUserCanDeleteTheirAccount(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("DELETE", "/my-account", nil, "Authorization", token)
 	assert.Equal(t, http.StatusNoContent, w.Code)
 	assert.Empty(t, w.Body.String())
-	_, err := sv.Store.GetUserByEmail("abcde@gmail.com")
+	_, err := sv.Store.GetUserByEmail(ctx, "abcde@gmail.com")
 	assert.EqualError(t, err, messageErrors.UserEmailNotFound)
 	clearAndCloseConnection(t, sv.Store)
 }
@@ -410,9 +416,9 @@ func TestAnUserCanUpdateTheirAccount(t *testing.T) {
 		Name:     "bruce",
 		LastName: "wayne",
 	}
-	userInDb, _ := sv.Store.GetUserByEmail(adminUser.Email)
+	userInDb, _ := sv.Store.GetUserByEmail(ctx, adminUser.Email)
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/my-account", newUserData, "Authorization", token)
 
 	var createdUser types.User
@@ -439,10 +445,10 @@ func TestAnUserCannotUpdateTheirAccountWithInvalidData(t *testing.T) {
 		Name:     "bruce",
 		LastName: "wayne",
 	}
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
 	w := requestWithCookie("PUT", "/my-account", newUserData, "Authorization", token)
 
-	_, err := sv.Store.GetUserByEmail("")
+	_, err := sv.Store.GetUserByEmail(ctx, "")
 
 	assert.EqualError(t, err, messageErrors.UserEmailNotFound)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
@@ -454,7 +460,7 @@ func TestAnUserCannotUpdateTheirAccountWithInvalidJsonFormat(t *testing.T) {
 	setup()
 	invalidUser := "not an user struct"
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("PUT", "/my-account", invalidUser, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
@@ -482,7 +488,7 @@ func TestGettingAllFlavorsFromServer(t *testing.T) {
 
 func TestGettingFlavorsFromServerFilteringByType(t *testing.T) {
 	setup()
-	w := requestWithCookie("GET", "/flavors?type=Chocolates", nil, "", "")
+	w := requestWithCookie("GET", "/flavors?type=chocolates", nil, "", "")
 
 	expectedFlavors := []types.Flavor{flavorMRC}
 
@@ -496,6 +502,107 @@ func TestGettingFlavorsFromServerFilteringByType(t *testing.T) {
 	clearAndCloseConnection(t, sv.Store)
 }
 
+func TestFlavorsOutOfSeasonAreHiddenFromServer(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorOutOfSeason)
+	w := requestWithCookie("GET", "/flavors?include=unavailable", nil, "", "")
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, flavors, actualFlavors)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanGetFlavorsOutOfSeason(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorOutOfSeason)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("GET", "/flavors?include=unavailable", nil, "Authorization", token)
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, len(flavors)+1, len(actualFlavors))
+	assert.True(t, utils.SliceContains(actualFlavors, flavorOutOfSeason))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminCannotGetFlavorsOutOfSeason(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorOutOfSeason)
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("GET", "/flavors?include=unavailable", nil, "Authorization", token)
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, flavors, actualFlavors)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestGettingFlavorsFromServerExcludingAllergens(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorWithNuts)
+	_ = sv.Store.AddFlavor(ctx, flavorVegan)
+	w := requestWithCookie("GET", "/flavors?excludeAllergens=nuts,gluten", nil, "", "")
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, len(flavors)+1, len(actualFlavors))
+	assert.False(t, utils.SliceContains(actualFlavors, flavorWithNuts))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestGettingFlavorsFromServerFilteringByDiet(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorWithNuts)
+	_ = sv.Store.AddFlavor(ctx, flavorVegan)
+	w := requestWithCookie("GET", "/flavors?diet=vegan&excludeAllergens=nuts", nil, "", "")
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []types.Flavor{flavorVegan}, actualFlavors)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotGetFlavorsFromServerExcludingAnUnknownAllergen(t *testing.T) {
+	setup()
+	w := requestWithCookie("GET", "/flavors?excludeAllergens=kryptonite", nil, "", "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidAllergen), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotGetFlavorsFromServerFilteringByAnUnknownDiet(t *testing.T) {
+	setup()
+	w := requestWithCookie("GET", "/flavors?diet=carnivore", nil, "", "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidDiet), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
 func TestGettingAFlavorByIDFromServer(t *testing.T) {
 	setup()
 	w := requestWithCookie("GET", "/flavors/ddl", nil, "", "")
@@ -523,10 +630,10 @@ func TestCannotGetANonExistingFlavorFromServer(t *testing.T) {
 func TestAnAdminCanAddANewFlavor(t *testing.T) {
 	setup()
 	newFlavor := types.Flavor{
-		ID: "ore", Name: "Oreo", Type: "Cremas",
+		ID: "ore", Name: "Oreo", CategoryID: "cremas",
 	}
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
 
 	var obtainedFlavor types.Flavor
@@ -543,7 +650,7 @@ func TestCannotAddANewFlavorWithInvalidJsonFormat(t *testing.T) {
 	setup()
 	newInvalidFlavor := "not a flavor struct"
 
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("POST", "/flavors", newInvalidFlavor, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
@@ -554,1126 +661,2939 @@ func TestCannotAddANewFlavorWithInvalidJsonFormat(t *testing.T) {
 func TestCannotAddANewFlavorWithInvalidData(t *testing.T) {
 	setup()
 	newFlavor := types.Flavor{
-		ID: "", Name: "Oreo", Type: "Cremas",
+		ID: "", Name: "Oreo", CategoryID: "cremas",
 	}
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorIdIsRequired), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotAddANewFlavorWithAnExistingID(t *testing.T) {
+func TestCannotAddANewFlavorWithAnInvalidAvailabilityWindow(t *testing.T) {
 	setup()
 	newFlavor := types.Flavor{
-		ID: "ddl", Name: "Oreo", Type: "Cremas",
+		ID: "ore", Name: "Oreo", CategoryID: "cremas",
+		AvailableFrom: "2026-12-01", AvailableUntil: "2026-01-01",
 	}
-	token := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AlreadyExistingFlavor), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidAvailabilityWindow), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-/*****************************/
-/***** USER ORDERS TESTS *****/
-/*****************************/
-
-func TestAnUserCanMakeAnOrder(t *testing.T) {
+func TestCannotAddANewFlavorWithAnInvalidWeekday(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", token)
-
-	expectedOrder := types.Order{
-		ID:           1,
-		UserID:       genericUser.ID,
-		Address:      "Calle 123",
-		PaymentState: "pending",
+	newFlavor := types.Flavor{
+		ID: "ore", Name: "Oreo", CategoryID: "cremas",
+		AvailableWeekdays: []string{"someday"},
 	}
-
-	var createdOrder types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
-
-	assert.NoError(t, err)
-	assert.Equal(t, http.StatusCreated, w.Code)
-	assert.True(t, expectedOrder.IsEqualTo(createdOrder))
-
-	orderInDB, err := sv.Store.GetOrderByID(1)
-	assert.NoError(t, err)
-	assert.True(t, expectedOrder.IsEqualTo(orderInDB))
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidWeekday), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotMakeAnOrderWithInvalidJsonFormat(t *testing.T) {
+func TestCannotAddANewFlavorWithAnUnknownAllergen(t *testing.T) {
 	setup()
-	ordersBeforeRequest := sv.Store.GetAllOrdersByUserEmail(genericUser.Email)
-	newInvalidOrder := "Not an order struct"
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("POST", "/my-orders", newInvalidOrder, "Authorization", token)
-
-	orders := sv.Store.GetAllOrdersByUserEmail(genericUser.Email)
-	assert.Equal(t, len(ordersBeforeRequest), len(orders))
+	newFlavor := types.Flavor{
+		ID: "ore", Name: "Oreo", CategoryID: "cremas",
+		Allergens: []string{"kryptonite"},
+	}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidAllergen), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotMakeAnOrderWithEmptyAddress(t *testing.T) {
+func TestCannotAddANewFlavorWithAnExistingID(t *testing.T) {
 	setup()
-	newOrder := types.Order{
-		Address: "",
+	newFlavor := types.Flavor{
+		ID: "ddl", Name: "Oreo", CategoryID: "cremas",
 	}
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("POST", "/my-orders", newOrder, "Authorization", token)
-
-	orders := sv.Store.GetAllOrdersByUserEmail(genericUser.Email)
-	assert.Equal(t, 0, len(orders))
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AddressIsRequired), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AlreadyExistingFlavor), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanGetTheirOrderByID(t *testing.T) {
+func TestAnAdminCanUpdateAFlavor(t *testing.T) {
 	setup()
-	//creating order
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", token)
-	var createdOrder types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
+	updatedData := types.Flavor{Name: "Dulce de leche granizado", CategoryID: "dulce-de-leches"}
 
-	//getting order
-	uri := fmt.Sprintf("/my-orders/%v", createdOrder.ID)
-	w = requestWithCookie("GET", uri, nil, "Authorization", token)
-	var obtainedOrder types.Order
-	err = json.Unmarshal(w.Body.Bytes(), &obtainedOrder)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavors/ddl", updatedData, "Authorization", token)
+
+	var obtainedFlavor types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedFlavor)
+	flavorInDB, _ := sv.Store.GetFlavorByID(ctx, "ddl")
 
-	assert.Equal(t, http.StatusOK, w.Code)
 	assert.NoError(t, err)
-	assert.Equal(t, createdOrder, obtainedOrder)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Dulce de leche granizado", obtainedFlavor.Name)
+	assert.Equal(t, flavorInDB, obtainedFlavor)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserMustBeLoggedInToGetTheirOrder(t *testing.T) {
+func TestCannotUpdateAFlavorWithInvalidData(t *testing.T) {
 	setup()
-	w := requestWithCookie("GET", "/my-orders/1", nil, "", "")
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
+	updatedData := types.Flavor{Name: "", CategoryID: "dulce-de-leches"}
+
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavors/ddl", updatedData, "Authorization", token)
+
+	flavorInDB, _ := sv.Store.GetFlavorByID(ctx, "ddl")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorNameIsRequired), w.Body.String())
+	assert.Equal(t, flavorDDL, flavorInDB)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetAnOrderWhenIDIsNotAnInteger(t *testing.T) {
+func TestCannotUpdateANonExistingFlavorFromServer(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("GET", "/my-orders/not-an-integer", nil, "Authorization", token)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	updatedData := types.Flavor{Name: "Oreo", CategoryID: "cremas"}
+
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavors/non-existing-flavor", updatedData, "Authorization", token)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorNotFound), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetAnotherUserOrder(t *testing.T) {
+func TestAnAdminCanPatchTheCategoryOfAFlavor(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	patch := struct {
+		CategoryID string `json:"categoryID"`
+	}{CategoryID: "cremas"}
 
-	//creating order
-	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", tokenAnotherUser)
-	var createdOrder types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PATCH", "/flavors/ddl", patch, "Authorization", token)
 
-	//getting order
-	uri := fmt.Sprintf("/my-orders/%v", createdOrder.ID)
-	w = requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	flavorInDB, _ := sv.Store.GetFlavorByID(ctx, "ddl")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cremas", flavorInDB.CategoryID)
+	assert.Equal(t, flavorDDL.Name, flavorInDB.Name)
 
-	assert.NoError(t, err)
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetANonExistingOrder(t *testing.T) {
+func TestANonAdminCannotPatchAFlavor(t *testing.T) {
 	setup()
-	token := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("GET", "/my-orders/1000", nil, "Authorization", token)
+	patch := struct {
+		CategoryID string `json:"categoryID"`
+	}{CategoryID: "cremas"}
+
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("PATCH", "/flavors/ddl", patch, "Authorization", token)
+
+	flavorInDB, _ := sv.Store.GetFlavorByID(ctx, "ddl")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, flavorDDL, flavorInDB)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanGetAllTheirOrders(t *testing.T) {
+func TestAnAdminCanRetireAFlavor(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	firstOrder := requestToMakeAnOrder(newValidOrder, tokenUser)
-	secondOrder := requestToMakeAnOrder(anotherNewValidOrder, tokenUser)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("DELETE", "/flavors/ddl", nil, "Authorization", token)
 
-	w := requestWithCookie("GET", "/my-orders", nil, "Authorization", tokenUser)
-	var obtainedOrders []types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+	allFlavors := sv.Store.GetFlavors(ctx)
+	flavorInDB, err := sv.Store.GetFlavorByID(ctx, "ddl")
 
 	assert.NoError(t, err)
-	assert.Equal(t, 2, len(obtainedOrders))
-	assert.Equal(t, firstOrder, obtainedOrders[0])
-	assert.Equal(t, secondOrder, obtainedOrders[1])
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, len(flavors)-1, len(allFlavors))
+	assert.True(t, flavorInDB.Retired)
+
+	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserMustBeLoggedInToGetAllTheirOrders(t *testing.T) {
+func TestAnAdminCannotRetireAnAlreadyRetiredFlavor(t *testing.T) {
 	setup()
-	w := requestWithCookie("GET", "/my-orders", nil, "", "")
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestWithCookie("DELETE", "/flavors/ddl", nil, "Authorization", token)
+	w := requestWithCookie("DELETE", "/flavors/ddl", nil, "Authorization", token)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorIsAlreadyRetired), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserDoesNotHaveAnotherUsersOrdersWhenGettingAllOrders(t *testing.T) {
+func TestAnAdminCannotRetireANonExistingFlavor(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	_ = requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("DELETE", "/flavors/non-existing-flavor", nil, "Authorization", token)
 
-	w := requestWithCookie("GET", "/my-orders", nil, "Authorization", tokenUser)
-	var obtainedOrders []types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorNotFound), w.Body.String())
 
-	assert.NoError(t, err)
-	assert.Equal(t, 0, len(obtainedOrders))
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanUpdateTheirOrderByID(t *testing.T) {
+func TestAnAdminCanUpdateTheStockOfAFlavor(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	body := struct {
+		Stock uint `json:"stock"`
+	}{Stock: 1500}
 
-	updatedData := types.Order{
-		Address:      "Calle 1000",
-		PaymentState: "paid",
-	}
-	uri := fmt.Sprintf("/my-orders/%v", order.ID)
-	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
-	var updatedOrder types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &updatedOrder)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavors/ddl/stock", body, "Authorization", token)
 
-	expectedOrder := order
-	expectedOrder.Address = updatedData.Address
-	expectedOrder.PaymentState = updatedData.PaymentState
+	flavorInDB, err := sv.Store.GetFlavorByID(ctx, "ddl")
 
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, updatedOrder, expectedOrder)
+	assert.Equal(t, uint(1500), flavorInDB.Stock)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotUpdateTheirOrderIfNotLoggedIn(t *testing.T) {
+func TestANonAdminCannotUpdateTheStockOfAFlavor(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	body := struct {
+		Stock uint `json:"stock"`
+	}{Stock: 1500}
 
-	updatedData := types.Order{
-		Address:      "Calle 1000",
-		PaymentState: "paid",
-	}
-	uri := fmt.Sprintf("/my-orders/%v", order.ID)
-	w := requestWithCookie("PUT", uri, updatedData, "", "")
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("PUT", "/flavors/ddl/stock", body, "Authorization", token)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
+	flavorInDB, _ := sv.Store.GetFlavorByID(ctx, "ddl")
 
-	orderInDB, _ := sv.Store.GetOrderByID(order.ID)
-	assert.NotEqual(t, orderInDB.Address, updatedData.Address)
-	assert.NotEqual(t, orderInDB.PaymentState, updatedData.PaymentState)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, flavorDDL.Stock, flavorInDB.Stock)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotUpdateAnOrderWhenIDIsNotAnInteger(t *testing.T) {
+func TestCannotUpdateTheStockOfAFlavorWithInvalidJsonFormat(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	updatedData := types.Order{
-		Address:      "Calle 1000",
-		PaymentState: "paid",
-	}
-	w := requestWithCookie("PUT", "/my-orders/not-an-integer", updatedData, "Authorization", tokenUser)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavors/ddl/stock", "not a stock struct", "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotUpdateAnOrderWithInvalidJsonFormat(t *testing.T) {
+func TestAnAdminCanGetLowStockFlavors(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_, _ = sv.Store.UpdateFlavorStock(ctx, "trm", 400)
 
-	updatedData := "not an order struct"
-	uri := fmt.Sprintf("/my-orders/%v", order.ID)
-	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("GET", "/flavors/low-stock?threshold=500", nil, "Authorization", token)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
 
-	orderInDB, _ := sv.Store.GetOrderByID(order.ID)
-	assert.Equal(t, orderInDB.Address, newValidOrder.Address)
-	assert.Equal(t, orderInDB.PaymentState, newValidOrder.PaymentState)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(actualFlavors))
+	assert.Equal(t, "trm", actualFlavors[0].ID)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotUpdateAnOrderWithEmptyData(t *testing.T) {
+func TestAnAdminCannotGetLowStockFlavorsWhenThresholdIsNotAnInteger(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-
-	updatedData := types.Order{
-		Address:      "",
-		PaymentState: "paid",
-	}
-	uri := fmt.Sprintf("/my-orders/%v", order.ID)
-	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("GET", "/flavors/low-stock?threshold=abc", nil, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AddressIsRequired), w.Body.String())
-
-	orderInDB, _ := sv.Store.GetOrderByID(order.ID)
-	assert.Equal(t, orderInDB.Address, newValidOrder.Address)
-	assert.Equal(t, orderInDB.PaymentState, newValidOrder.PaymentState)
-
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotUpdateAnOrderThatDoesNotExist(t *testing.T) {
+func TestAnAdminCanGetOutOfStockFlavors(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	updatedData := types.Order{
-		Address:      "Calle 1000",
-		PaymentState: "paid",
-	}
-	w := requestWithCookie("PUT", "/my-orders/1000", updatedData, "Authorization", tokenUser)
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	_, _ = sv.Store.UpdateFlavorStock(ctx, "frt", 0)
+
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("GET", "/flavors/out-of-stock", nil, "Authorization", token)
+
+	var actualFlavors []types.Flavor
+	err := json.Unmarshal(w.Body.Bytes(), &actualFlavors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(actualFlavors))
+	assert.Equal(t, "frt", actualFlavors[0].ID)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestCannotUpdateAnOrderThatDoesNotBelongToUser(t *testing.T) {
+func TestCannotAddANewFlavorWithANonExistingCategory(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
-	updatedData := types.Order{
-		Address:      "Calle 1000",
-		PaymentState: "paid",
-	}
-	uri := fmt.Sprintf("/my-orders/%v", order.ID)
-	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	newFlavor := types.Flavor{ID: "ore", Name: "Oreo", CategoryID: "galletitas"}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavors", newFlavor, "Authorization", token)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorCategoryNotFound), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-/********************************/
-/***** ICE CREAM TUBS TESTS *****/
-/********************************/
+/***********************************/
+/***** FLAVOR CATEGORIES TESTS *****/
+/***********************************/
 
-func TestAnUserCanAddAnIceCreamTubToTheirOrder(t *testing.T) {
+func TestGettingAllFlavorCategoriesFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	w := requestWithCookie("GET", "/flavor-categories", nil, "", "")
 
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
-
-	orderTubs, err := sv.Store.GetIceCreamTubsByOrderID(order.ID)
-	var tubObtained types.IceCreamTub
-	_ = json.Unmarshal(w.Body.Bytes(), &tubObtained)
+	var actualCategories []types.FlavorCategory
+	err := json.Unmarshal(w.Body.Bytes(), &actualCategories)
 
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusCreated, w.Code)
-	assert.Equal(t, 1, len(orderTubs))
-	assert.Equal(t, orderTubs[0], tubObtained)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, categories, actualCategories)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubToAnotherUsersOrder(t *testing.T) {
+func TestGettingAFlavorCategoryByIDFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
-
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+	w := requestWithCookie("GET", "/flavor-categories/cremas", nil, "", "")
 
-	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	var actualCategory types.FlavorCategory
+	err := json.Unmarshal(w.Body.Bytes(), &actualCategory)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
-	assert.Equal(t, 0, len(tubs))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, categories[2], actualCategory)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubWhenOrderIDIsNotAnInteger(t *testing.T) {
+func TestCannotGetANonExistingFlavorCategoryFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie(
-		"POST",
-		"/my-orders/not-an-integer/tubs",
-		newValidIceCreamTub,
-		"Authorization",
-		tokenUser,
-	)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	w := requestWithCookie("GET", "/flavor-categories/galletitas", nil, "", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorCategoryNotFound), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubWithInvalidJsonFormat(t *testing.T) {
+func TestAnAdminCanAddANewFlavorCategory(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, "not an ice cream tub struct", "Authorization", tokenUser)
+	newCategory := types.FlavorCategory{ID: "granizados", Name: "Granizados", SortOrder: 5}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavor-categories", newCategory, "Authorization", token)
 
-	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	actualCategory, err := sv.Store.GetFlavorCategoryByID(ctx, "granizados")
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
-	assert.Equal(t, 0, len(tubs))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, newCategory, actualCategory)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubWithInvalidData(t *testing.T) {
+func TestANonAdminCannotAddANewFlavorCategory(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, invalidIceCreamTub, "Authorization", tokenUser)
-
-	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	newCategory := types.FlavorCategory{ID: "granizados", Name: "Granizados"}
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/flavor-categories", newCategory, "Authorization", token)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.WeightCannotBeZero), w.Body.String())
-	assert.Equal(t, 0, len(tubs))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, len(categories), len(sv.Store.GetFlavorCategories(ctx)))
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubWithANonExistingOrderID(t *testing.T) {
+func TestCannotAddANewFlavorCategoryWithoutName(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", 1000)
-	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+	newCategory := types.FlavorCategory{ID: "granizados"}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavor-categories", newCategory, "Authorization", token)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorCategoryNameIsRequired), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotAddAnIceCreamTubWithNonAvailableFlavorsOrWeight(t *testing.T) {
+func TestCannotAddAnExistingFlavorCategoryFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, iceCreamTubWithUnavailableFlavorsAndWeight, "Authorization", tokenUser)
-
-	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	newCategory := types.FlavorCategory{ID: "cremas", Name: "Cremas"}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/flavor-categories", newCategory, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, 0, len(tubs))
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AlreadyExistingFlavorCategory), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanGetTheirIceCreamTubFromTheirOrder(t *testing.T) {
+func TestAnAdminCanRenameAndReorderAFlavorCategory(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
-	w = requestWithCookie("POST", uri, anotherNewValidIceCreamTub, "Authorization", tokenUser)
-	w = requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	updatedData := types.FlavorCategory{Name: "Heladas al agua", SortOrder: 0}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavor-categories/al-agua", updatedData, "Authorization", token)
 
-	tubsInDB, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	allCategories := sv.Store.GetFlavorCategories(ctx)
 
-	var obtainedTubs []types.IceCreamTub
-	err := json.Unmarshal(w.Body.Bytes(), &obtainedTubs)
-
-	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, 2, len(obtainedTubs))
-	assert.ElementsMatch(t, tubsInDB, obtainedTubs)
+	assert.Equal(t, types.FlavorCategory{ID: "al-agua", Name: "Heladas al agua", SortOrder: 0}, allCategories[0])
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetTheirIceCreamTubsFromOtherOrder(t *testing.T) {
+func TestCannotUpdateANonExistingFlavorCategoryFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	updatedData := types.FlavorCategory{Name: "Galletitas"}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/flavor-categories/galletitas", updatedData, "Authorization", token)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorCategoryNotFound), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetTheirIceCreamTubsWhenOrderIDIsNotAnInteger(t *testing.T) {
+func TestAnAdminCanDeleteAnEmptyFlavorCategory(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	uri := fmt.Sprintf("/my-orders/%v/tubs", "NOT-AN-INTEGER")
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	_ = sv.Store.AddFlavorCategory(ctx, types.FlavorCategory{ID: "granizados", Name: "Granizados"})
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("DELETE", "/flavor-categories/granizados", nil, "Authorization", token)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, len(categories), len(sv.Store.GetFlavorCategories(ctx)))
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanDeleteAnIceCreamTubFromTheirOrder(t *testing.T) {
+func TestAnAdminCannotDeleteAFlavorCategoryWithFlavors(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	tub := requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
-	tubsInDBBeforeDelete, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("DELETE", "/flavor-categories/cremas", nil, "Authorization", token)
 
-	uri := fmt.Sprintf("/my-orders/%v/tubs/%v", order.ID, tub.ID)
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.FlavorCategoryIsInUse), w.Body.String())
 
-	tubsInDBAfterDelete, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
-
-	assert.Equal(t, http.StatusNoContent, w.Code)
-	assert.Equal(t, 1, len(tubsInDBBeforeDelete))
-	assert.Equal(t, 0, len(tubsInDBAfterDelete))
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotDeleteAnIceCreamTubFromAnotherUsersOrder(t *testing.T) {
+/************************/
+/***** PRICES TESTS *****/
+/************************/
+
+func TestGettingAllPricesFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAnotherUser := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
-	tub := requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenAnotherUser)
-	tubsBeforeDeletion, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	w := requestWithCookie("GET", "/prices", nil, "", "")
 
-	uri := fmt.Sprintf("/my-orders/%v/tubs/%v", order.ID, tub.ID)
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
-	tubsAfterDeletion, _ := sv.Store.GetIceCreamTubsByOrderID(order.ID)
+	var actualPrices []types.IceCreamTubPrice
+	err := json.Unmarshal(w.Body.Bytes(), &actualPrices)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
-	assert.ElementsMatch(t, tubsBeforeDeletion, tubsAfterDeletion)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, prices, actualPrices)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotDeleteAnIceCreamTubWhenOrderIDIsNotAnInteger(t *testing.T) {
+func TestGettingAPriceByWeightFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("DELETE", "/my-orders/NOT-AN-INTEGER/tubs/1", nil, "Authorization", tokenUser)
+	w := requestWithCookie("GET", "/prices/1000", nil, "", "")
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
-}
+	var actualPrice types.IceCreamTubPrice
+	err := json.Unmarshal(w.Body.Bytes(), &actualPrice)
 
-func TestAnUserCannotDeleteAnIceCreamTubWhenTubIDIsNotAnInteger(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("DELETE", "/my-orders/1/tubs/NOT-AN-INTEGER", nil, "Authorization", tokenUser)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, prices[2], actualPrice)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotDeleteAnIceCreamTubWhenTubIDDoesNotExist(t *testing.T) {
+func TestCannotGetAPriceForANonExistingWeightFromServer(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/tubs/1000000", order.ID)
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
+	w := requestWithCookie("GET", "/prices/123", nil, "", "")
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.IceCreamTubNotFound), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.WeightNotAvailable), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-/**********************************/
-/***** DELIVERY-DRIVERS TESTS *****/
-/**********************************/
-
-func TestAnAdminCanAddANewDeliveryDriver(t *testing.T) {
+func TestAnAdminCanAddANewPrice(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForGenericUser, "Authorization", tokenAdmin)
-
-	deliveryDriverInDB, err := sv.Store.GetDeliveryDriverByID(newDeliveryDriverForGenericUser.UserID)
-	assert.Nil(t, err)
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.NewMoney(1400, "ARS"), MaxFlavors: 5}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
-	var deliveryDriverObtained types.DeliveryDriver
-	err = json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+	actualPrice, err := sv.Store.GetPriceByWeight(ctx, 1500)
 
-	assert.Nil(t, err)
+	assert.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, w.Code)
-	assert.True(t, newDeliveryDriverForGenericUser.IsEqualTo(deliveryDriverInDB))
-	assert.Equal(t, newDeliveryDriverForGenericUser, deliveryDriverObtained)
+	assert.Equal(t, newPrice, actualPrice)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestANonAdminCannotAddANewDeliveryDriver(t *testing.T) {
+func TestANonAdminCannotAddANewPrice(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForGenericUser, "Authorization", tokenUser)
-
-	_, err := sv.Store.GetDeliveryDriverByID(newDeliveryDriverForGenericUser.UserID)
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.NewMoney(1400, "ARS"), MaxFlavors: 5}
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
-	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	assert.Equal(t, len(prices), len(sv.Store.GetPrices(ctx)))
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAddANewDeliveryDriverWithInvalidJsonFormat(t *testing.T) {
+func TestCannotAddANewPriceWithoutMaxFlavors(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	w := requestWithCookie("POST", "/delivery-drivers", "NOT A DELIVERY DRIVER STRUCT", "Authorization", tokenAdmin)
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.NewMoney(1400, "ARS")}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MaxFlavorsCannotBeZero), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAddAnInvalidNewDeliveryDriver(t *testing.T) {
+func TestCannotAddAPriceForAnExistingWeightFromServer(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	w := requestWithCookie("POST", "/delivery-drivers", invalidDeliveryDriver, "Authorization", tokenAdmin)
-
-	_, err := sv.Store.GetDeliveryDriverByID(newDeliveryDriverForGenericUser.UserID)
+	newPrice := types.IceCreamTubPrice{Weight: 500, Price: types.NewMoney(700, "ARS"), MaxFlavors: 3}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AgeMustBeGreaterThan18), w.Body.String())
-	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AlreadyExistingPrice), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAddANewDeliveryDriverFromANonExistingUser(t *testing.T) {
+func TestANewPriceWithoutCurrencyUsesTheDefaultCurrency(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForNonExistingUser, "Authorization", tokenAdmin)
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.Money{Amount: 1400}, MaxFlavors: 5}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
-	_, err := sv.Store.GetDeliveryDriverByID(newDeliveryDriverForNonExistingUser.UserID)
+	actualPrice, err := sv.Store.GetPriceByWeight(ctx, 1500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, types.NewMoney(1400, testConfig.Store.DefaultCurrency()), actualPrice.Price)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.UserIDNotFound), w.Body.String())
-	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCanGetAllDeliveryDrivers(t *testing.T) {
-	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	anotherDeliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+func TestANewPriceWithoutCurrencyUsesTheCurrencyOfTheServerSettings(t *testing.T) {
+	settings := testConfig
+	settings.Store.Currency = "USD"
+	sv = api.NewServer(newStorage(flavors, users, prices), settings)
+	router = sv.SetupRouter()
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.Money{Amount: 1400}, MaxFlavors: 5}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
-	w := requestWithCookie("GET", "/delivery-drivers", nil, "Authorization", tokenAdmin)
+	actualPrice, err := sv.Store.GetPriceByWeight(ctx, 1500)
 
-	expectedDeliveryDrivers := []types.DeliveryDriver{deliveryDriver, anotherDeliveryDriver}
-	var deliveryDriversObtained []types.DeliveryDriver
-	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriversObtained)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, types.NewMoney(1400, "USD"), actualPrice.Price)
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.True(t, utils.SlicesAreEqual(expectedDeliveryDrivers, deliveryDriversObtained))
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestANonAdminCannotGetAllDeliveryDrivers(t *testing.T) {
+func TestCannotAddANewPriceWithAnInvalidCurrency(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("GET", "/delivery-drivers", nil, "Authorization", tokenUser)
+	newPrice := types.IceCreamTubPrice{Weight: 1500, Price: types.NewMoney(1400, "PESOS"), MaxFlavors: 5}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/prices", newPrice, "Authorization", token)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidCurrency), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCanGetADeliveryDriverByID(t *testing.T) {
+func TestAnAdminCanUpdateAPrice(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	updatedData := types.IceCreamTubPrice{Price: types.NewMoney(600, "ARS"), MaxFlavors: 2}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/prices/500", updatedData, "Authorization", token)
 
-	uri := fmt.Sprintf("/delivery-drivers/%v", deliveryDriver.UserID)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
-
-	var deliveryDriverObtained types.DeliveryDriver
-	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+	actualPrice, err := sv.Store.GetPriceByWeight(ctx, 500)
 
-	assert.Nil(t, err)
+	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, deliveryDriver, deliveryDriverObtained)
+	assert.Equal(t, types.IceCreamTubPrice{Weight: 500, Price: types.NewMoney(600, "ARS"), MaxFlavors: 2}, actualPrice)
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestANonAdminCannotGetADeliveryDriverByID(t *testing.T) {
+func TestCannotUpdateAPriceWithAZeroPrice(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenNotAnAdmin := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	uri := fmt.Sprintf("/delivery-drivers/%v", deliveryDriver.UserID)
+	updatedData := types.IceCreamTubPrice{Price: types.NewMoney(0, "ARS"), MaxFlavors: 2}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/prices/500", updatedData, "Authorization", token)
 
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenNotAnAdmin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.PriceCannotBeZero), w.Body.String())
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotGetADeliveryDriverWhenIDIsNotAnInteger(t *testing.T) {
+func TestCannotUpdateAPriceForANonExistingWeightFromServer(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	uri := fmt.Sprintf("/delivery-drivers/%v", "NOT-AN-INTEGER")
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	updatedData := types.IceCreamTubPrice{Price: types.NewMoney(600, "ARS"), MaxFlavors: 2}
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("PUT", "/prices/123", updatedData, "Authorization", token)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.WeightNotAvailable), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotGetADeliveryDriverWhenIDDoesNotExist(t *testing.T) {
+func TestAnAdminCanDeleteAPrice(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	uri := fmt.Sprintf("/delivery-drivers/%v", 10000000)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("DELETE", "/prices/250", nil, "Authorization", token)
+
+	_, err := sv.Store.GetPriceByWeight(ctx, 250)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.EqualError(t, err, messageErrors.WeightNotAvailable)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.DeliveryDriverNotFound), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanUpdateTheirDeliveryDriverData(t *testing.T) {
+func TestAnUserCannotAddAnIceCreamTubWithMoreFlavorsThanAllowedForItsWeight(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	updatedDeliveryDriverData := types.DeliveryDriver{
-		Cuil:     newDeliveryDriverForGenericUser.Cuil,
-		Age:      25,
-		Vehicles: []string{"ABC123", "AAA111"},
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	tub := types.IceCreamTub{Weight: 500, Flavors: []string{"ddl", "mrc", "trm", "frt"}}
+	w := requestWithCookie("POST", uri, tub, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidAmountOfFlavors), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/*****************************/
+/***** PROMO CODES TESTS *****/
+/*****************************/
+
+func TestAnAdminCanAddANewPromoCode(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	promo := percentagePromoCode
+	promo.Code = "tenoff"
+	w := requestWithCookie("POST", "/promo-codes", promo, "Authorization", token)
+
+	promoInDB, err := sv.Store.GetPromoCodeByCode(ctx, "TENOFF")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, uint(10), promoInDB.Percentage)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminCannotAddANewPromoCode(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/promo-codes", percentagePromoCode, "Authorization", token)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotAddAPromoCodeWithAnInvalidKind(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	promo := percentagePromoCode
+	promo.Kind = "gift"
+	w := requestWithCookie("POST", "/promo-codes", promo, "Authorization", token)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidPromoCodeKind), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotAddAPromoCodeWithAnInvalidValidity(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	promo := percentagePromoCode
+	promo.ValidFrom = "2024-12-31"
+	promo.ValidUntil = "2024-01-01"
+	w := requestWithCookie("POST", "/promo-codes", promo, "Authorization", token)
+
+	_, err := sv.Store.GetPromoCodeByCode(ctx, promo.Code)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidPromoCodeValidity), w.Body.String())
+	assert.EqualError(t, err, messageErrors.PromoCodeNotFound)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanUpdateAPromoCode(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestWithCookie("POST", "/promo-codes", percentagePromoCode, "Authorization", token)
+	update := percentagePromoCode
+	update.Percentage = 25
+	w := requestWithCookie("PUT", "/promo-codes/tenoff", update, "Authorization", token)
+
+	promoInDB, err := sv.Store.GetPromoCodeByCode(ctx, "TENOFF")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, uint(25), promoInDB.Percentage)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotGetANonExistingPromoCodeFromServer(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("GET", "/promo-codes/NOPE", nil, "Authorization", token)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.PromoCodeNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanApplyAPromoCodeToTheirOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestWithCookie("POST", "/promo-codes", fixedAmountPromoCode, "Authorization", adminToken)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/promo", order.ID)
+	w := requestWithCookie("POST", uri, map[string]string{"code": "minus200"}, "Authorization", tokenUser)
+
+	var actualOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &actualOrder)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MINUS200", actualOrder.PromoCode)
+	assert.Equal(t, priceOf(500), actualOrder.Subtotal)
+	assert.Equal(t, fixedAmountPromoCode.Amount, actualOrder.Discount)
+	assert.Equal(t, priceOf(500).Amount-fixedAmountPromoCode.Amount.Amount, actualOrder.TotalCost.Amount)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotApplyAPromoCodeToAnotherUserOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestWithCookie("POST", "/promo-codes", fixedAmountPromoCode, "Authorization", adminToken)
+	order := requestToMakeAnOrder(newValidOrder, adminToken)
+
+	uri := fmt.Sprintf("/my-orders/%v/promo", order.ID)
+	w := requestWithCookie("POST", uri, map[string]string{"code": "MINUS200"}, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotApplyANonExistingPromoCode(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/promo", order.ID)
+	w := requestWithCookie("POST", uri, map[string]string{"code": "NOPE"}, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.PromoCodeNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotDeleteAPromoCodeAppliedToAnOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestWithCookie("POST", "/promo-codes", fixedAmountPromoCode, "Authorization", adminToken)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/promo", order.ID), map[string]string{"code": "MINUS200"}, "Authorization", tokenUser)
+
+	w := requestWithCookie("DELETE", "/promo-codes/MINUS200", nil, "Authorization", adminToken)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.PromoCodeIsInUse), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/*****************************/
+/***** USER ORDERS TESTS *****/
+/*****************************/
+
+func TestAnUserCanMakeAnOrder(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", token)
+
+	expectedOrder := types.Order{
+		ID:           1,
+		UserID:       genericUser.ID,
+		Address:      "Calle 123",
+		PaymentState: "pending",
+		Status:       types.OrderDraft,
+		Subtotal:     types.NewMoney(0, "ARS"),
+		Discount:     types.NewMoney(0, "ARS"),
+		TotalCost:    types.NewMoney(0, "ARS"),
 	}
-	w := requestWithCookie("PUT", "/my-account/delivery-driver", updatedDeliveryDriverData, "Authorization", tokenUser)
 
-	var deliveryDriverObtained types.DeliveryDriver
-	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+	var createdOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
 
-	expectedDeliveryDriver := types.DeliveryDriver{
-		UserID:   genericUser.ID,
-		Cuil:     updatedDeliveryDriverData.Cuil,
-		Age:      25,
-		Vehicles: []string{"ABC123", "AAA111"},
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, expectedOrder.IsEqualTo(createdOrder))
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.True(t, expectedOrder.IsEqualTo(orderInDB))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanMakeAnOrderWithItsTubsInASingleRequest(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := newValidOrder
+	order.IceCreamTubs = []types.IceCreamTub{newValidIceCreamTub, anotherNewValidIceCreamTub}
+
+	w := requestWithCookie("POST", "/my-orders", order, "Authorization", token)
+	var createdOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, createdOrder.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, createdOrder.IceCreamTubs, 2)
+	assert.Equal(t, types.NewMoney(priceOf(500).Amount+priceOf(1000).Amount, "ARS"), createdOrder.TotalCost)
+	assert.True(t, createdOrder.IsEqualTo(orderInDB))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotMakeAnOrderWithRejectedTubs(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := newValidOrder
+	order.IceCreamTubs = []types.IceCreamTub{newValidIceCreamTub, invalidIceCreamTub, iceCreamTubWithUnavailableFlavorsAndWeight}
+
+	w := requestWithCookie("POST", "/my-orders", order, "Authorization", token)
+	response := struct {
+		Error     string           `json:"error"`
+		TubErrors []types.TubError `json:"tubErrors"`
+	}{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, messageErrors.InvalidIceCreamTubs, response.Error)
+	assert.Equal(t, []types.TubError{
+		{Index: 1, Error: messageErrors.WeightCannotBeZero},
+		{Index: 2, Error: messageErrors.NonExistingFlavors},
+	}, response.TubErrors)
+	assert.Empty(t, sv.Store.GetAllOrdersByUserEmail(ctx, genericUser.Email))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotMakeAnOrderWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	ordersBeforeRequest := sv.Store.GetAllOrdersByUserEmail(ctx, genericUser.Email)
+	newInvalidOrder := "Not an order struct"
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/my-orders", newInvalidOrder, "Authorization", token)
+
+	orders := sv.Store.GetAllOrdersByUserEmail(ctx, genericUser.Email)
+	assert.Equal(t, len(ordersBeforeRequest), len(orders))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotMakeAnOrderWithEmptyAddress(t *testing.T) {
+	setup()
+	newOrder := types.Order{
+		Address: "",
 	}
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/my-orders", newOrder, "Authorization", token)
+
+	orders := sv.Store.GetAllOrdersByUserEmail(ctx, genericUser.Email)
+	assert.Equal(t, 0, len(orders))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AddressIsRequired), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanGetTheirOrderByID(t *testing.T) {
+	setup()
+	//creating order
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", token)
+	var createdOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
+
+	//getting order
+	uri := fmt.Sprintf("/my-orders/%v", createdOrder.ID)
+	w = requestWithCookie("GET", uri, nil, "Authorization", token)
+	var obtainedOrder types.Order
+	err = json.Unmarshal(w.Body.Bytes(), &obtainedOrder)
 
-	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, expectedDeliveryDriver, deliveryDriverObtained)
+	assert.NoError(t, err)
+	assert.Equal(t, createdOrder, obtainedOrder)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotUpdateTheirDeliveryDriverDataWithInvalidJsonFormat(t *testing.T) {
+func TestAnUserMustBeLoggedInToGetTheirOrder(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	w := requestWithCookie("PUT", "/my-account/delivery-driver", "Not a delivery driver struct", "Authorization", tokenUser)
+	w := requestWithCookie("GET", "/my-orders/1", nil, "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetAnOrderWhenIDIsNotAnInteger(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("GET", "/my-orders/not-an-integer", nil, "Authorization", token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetAnotherUserOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+
+	//creating order
+	w := requestWithCookie("POST", "/my-orders", newValidOrder, "Authorization", tokenAnotherUser)
+	var createdOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
+
+	//getting order
+	uri := fmt.Sprintf("/my-orders/%v", createdOrder.ID)
+	w = requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetANonExistingOrder(t *testing.T) {
+	setup()
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("GET", "/my-orders/1000", nil, "Authorization", token)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanGetAllTheirOrders(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	firstOrder := requestToMakeAnOrder(newValidOrder, tokenUser)
+	secondOrder := requestToMakeAnOrder(anotherNewValidOrder, tokenUser)
+
+	w := requestWithCookie("GET", "/my-orders", nil, "Authorization", tokenUser)
+	var obtainedOrders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(obtainedOrders))
+	assert.Equal(t, firstOrder, obtainedOrders[0])
+	assert.Equal(t, secondOrder, obtainedOrders[1])
+}
+
+func TestAnUserMustBeLoggedInToGetAllTheirOrders(t *testing.T) {
+	setup()
+	w := requestWithCookie("GET", "/my-orders", nil, "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserDoesNotHaveAnotherUsersOrdersWhenGettingAllOrders(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+
+	w := requestWithCookie("GET", "/my-orders", nil, "Authorization", tokenUser)
+	var obtainedOrders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(obtainedOrders))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+// The payment state sent by the client is ignored, only the server can mark an order as paid.
+func TestAnUserCanUpdateTheirOrderByID(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	updatedData := types.Order{
+		Address:      "Calle 1000",
+		PaymentState: "paid",
+	}
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+	var updatedOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &updatedOrder)
+
+	expectedOrder := order
+	expectedOrder.Address = updatedData.Address
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, updatedOrder, expectedOrder)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotUpdateTheirOrderIfNotLoggedIn(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	updatedData := types.Order{
+		Address:      "Calle 1000",
+		PaymentState: "paid",
+	}
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, updatedData, "", "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+	assert.NotEqual(t, orderInDB.Address, updatedData.Address)
+	assert.NotEqual(t, orderInDB.PaymentState, updatedData.PaymentState)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateAnOrderWhenIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	updatedData := types.Order{
+		Address:      "Calle 1000",
+		PaymentState: "paid",
+	}
+	w := requestWithCookie("PUT", "/my-orders/not-an-integer", updatedData, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateAnOrderWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	updatedData := "not an order struct"
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+	assert.Equal(t, orderInDB.Address, newValidOrder.Address)
+	assert.Equal(t, orderInDB.PaymentState, newValidOrder.PaymentState)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateAnOrderWithEmptyData(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	updatedData := types.Order{
+		Address:      "",
+		PaymentState: "paid",
+	}
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AddressIsRequired), w.Body.String())
+
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+	assert.Equal(t, orderInDB.Address, newValidOrder.Address)
+	assert.Equal(t, orderInDB.PaymentState, newValidOrder.PaymentState)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateAnOrderThatDoesNotExist(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	updatedData := types.Order{
+		Address:      "Calle 1000",
+		PaymentState: "paid",
+	}
+	w := requestWithCookie("PUT", "/my-orders/1000", updatedData, "Authorization", tokenUser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateAnOrderThatDoesNotBelongToUser(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+	updatedData := types.Order{
+		Address:      "Calle 1000",
+		PaymentState: "paid",
+	}
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, updatedData, "Authorization", tokenUser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestCannotUpdateTheAddressOfACancelledOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v", order.ID)
+	w := requestWithCookie("PUT", uri, types.Order{Address: "Calle 1000"}, "Authorization", tokenUser)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderDeliveryCannotBeChanged), w.Body.String())
+	assert.Equal(t, order.Address, orderInDB.Address)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/********************************/
+/***** ICE CREAM TUBS TESTS *****/
+/********************************/
+
+func TestAnUserCanAddAnIceCreamTubToTheirOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+
+	orderTubs, err := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+	var tubObtained types.IceCreamTub
+	_ = json.Unmarshal(w.Body.Bytes(), &tubObtained)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, len(orderTubs))
+	assert.Equal(t, orderTubs[0], tubObtained)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubToAnotherUsersOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+
+	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	assert.Equal(t, 0, len(tubs))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie(
+		"POST",
+		"/my-orders/not-an-integer/tubs",
+		newValidIceCreamTub,
+		"Authorization",
+		tokenUser,
+	)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, "not an ice cream tub struct", "Authorization", tokenUser)
+
+	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	assert.Equal(t, 0, len(tubs))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWithInvalidData(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, invalidIceCreamTub, "Authorization", tokenUser)
+
+	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.WeightCannotBeZero), w.Body.String())
+	assert.Equal(t, 0, len(tubs))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWithANonExistingOrderID(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", 1000)
+	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWithNonAvailableFlavorsOrWeight(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, iceCreamTubWithUnavailableFlavorsAndWeight, "Authorization", tokenUser)
+
+	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, len(tubs))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddAnIceCreamTubWhenAFlavorIsOutOfStock(t *testing.T) {
+	setup()
+	_, _ = sv.Store.UpdateFlavorStock(ctx, "frt", 0)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+
+	tubs, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OutOfStockFlavors), w.Body.String())
+	assert.Equal(t, 0, len(tubs))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanGetTheirIceCreamTubFromTheirOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, newValidIceCreamTub, "Authorization", tokenUser)
+	w = requestWithCookie("POST", uri, anotherNewValidIceCreamTub, "Authorization", tokenUser)
+	w = requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	tubsInDB, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	var obtainedTubs []types.IceCreamTub
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedTubs)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, len(obtainedTubs))
+	assert.ElementsMatch(t, tubsInDB, obtainedTubs)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestIceCreamTubsFromAnOrderReportTheAllergensOfTheirFlavors(t *testing.T) {
+	setup()
+	_ = sv.Store.AddFlavor(ctx, flavorWithNuts)
+	_ = sv.Store.AddFlavor(ctx, flavorVegan)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(types.IceCreamTub{Weight: 500, Flavors: []string{"alm", "lim"}}, order.ID, tokenUser)
+	_ = requestToAddATubToAnOrder(types.IceCreamTub{Weight: 250, Flavors: []string{"lim"}}, order.ID, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	var obtainedTubs []types.IceCreamTub
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedTubs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, len(obtainedTubs))
+	assert.Equal(t, []string{"lactose", "nuts"}, obtainedTubs[0].Allergens)
+	assert.Empty(t, obtainedTubs[1].Allergens)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetTheirIceCreamTubsFromOtherOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetTheirIceCreamTubsWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	uri := fmt.Sprintf("/my-orders/%v/tubs", "NOT-AN-INTEGER")
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanDeleteAnIceCreamTubFromTheirOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	tub := requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	tubsInDBBeforeDelete, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs/%v", order.ID, tub.ID)
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
+
+	tubsInDBAfterDelete, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 1, len(tubsInDBBeforeDelete))
+	assert.Equal(t, 0, len(tubsInDBAfterDelete))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotDeleteAnIceCreamTubFromAnotherUsersOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAnotherUser := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenAnotherUser)
+	tub := requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenAnotherUser)
+	tubsBeforeDeletion, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs/%v", order.ID, tub.ID)
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
+	tubsAfterDeletion, _ := sv.Store.GetIceCreamTubsByOrderID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	assert.ElementsMatch(t, tubsBeforeDeletion, tubsAfterDeletion)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotDeleteAnIceCreamTubWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("DELETE", "/my-orders/NOT-AN-INTEGER/tubs/1", nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotDeleteAnIceCreamTubWhenTubIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("DELETE", "/my-orders/1/tubs/NOT-AN-INTEGER", nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotDeleteAnIceCreamTubWhenTubIDDoesNotExist(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/tubs/1000000", order.ID)
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.IceCreamTubNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/**********************************/
+/***** DELIVERY-DRIVERS TESTS *****/
+/**********************************/
+
+func TestAnAdminCanAddANewDeliveryDriver(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForGenericUser, "Authorization", tokenAdmin)
+
+	deliveryDriverInDB, err := sv.Store.GetDeliveryDriverByID(ctx, newDeliveryDriverForGenericUser.UserID)
+	assert.Nil(t, err)
+
+	var deliveryDriverObtained types.DeliveryDriver
+	err = json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, newDeliveryDriverForGenericUser.IsEqualTo(deliveryDriverInDB))
+	assert.Equal(t, newDeliveryDriverForGenericUser, deliveryDriverObtained)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminCannotAddANewDeliveryDriver(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForGenericUser, "Authorization", tokenUser)
+
+	_, err := sv.Store.GetDeliveryDriverByID(ctx, newDeliveryDriverForGenericUser.UserID)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAddANewDeliveryDriverWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/delivery-drivers", "NOT A DELIVERY DRIVER STRUCT", "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAddAnInvalidNewDeliveryDriver(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/delivery-drivers", invalidDeliveryDriver, "Authorization", tokenAdmin)
+
+	_, err := sv.Store.GetDeliveryDriverByID(ctx, newDeliveryDriverForGenericUser.UserID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AgeMustBeGreaterThan18), w.Body.String())
+	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAddANewDeliveryDriverFromANonExistingUser(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	w := requestWithCookie("POST", "/delivery-drivers", newDeliveryDriverForNonExistingUser, "Authorization", tokenAdmin)
+
+	_, err := sv.Store.GetDeliveryDriverByID(ctx, newDeliveryDriverForNonExistingUser.UserID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.UserIDNotFound), w.Body.String())
+	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanGetAllDeliveryDrivers(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	anotherDeliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+
+	w := requestWithCookie("GET", "/delivery-drivers", nil, "Authorization", tokenAdmin)
+
+	expectedDeliveryDrivers := []types.DeliveryDriver{deliveryDriver, anotherDeliveryDriver}
+	var deliveryDriversObtained []types.DeliveryDriver
+	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriversObtained)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, utils.SlicesAreEqual(expectedDeliveryDrivers, deliveryDriversObtained))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminCannotGetAllDeliveryDrivers(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("GET", "/delivery-drivers", nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanGetADeliveryDriverByID(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+
+	uri := fmt.Sprintf("/delivery-drivers/%v", deliveryDriver.UserID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+
+	var deliveryDriverObtained types.DeliveryDriver
+	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, deliveryDriver, deliveryDriverObtained)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminCannotGetADeliveryDriverByID(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenNotAnAdmin := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	uri := fmt.Sprintf("/delivery-drivers/%v", deliveryDriver.UserID)
+
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenNotAnAdmin)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotGetADeliveryDriverWhenIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	uri := fmt.Sprintf("/delivery-drivers/%v", "NOT-AN-INTEGER")
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotGetADeliveryDriverWhenIDDoesNotExist(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	uri := fmt.Sprintf("/delivery-drivers/%v", 10000000)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.DeliveryDriverNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanUpdateTheirDeliveryDriverData(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	updatedDeliveryDriverData := types.DeliveryDriver{
+		Cuil:     newDeliveryDriverForGenericUser.Cuil,
+		Age:      25,
+		Vehicles: []string{"ABC123", "AAA111"},
+	}
+	w := requestWithCookie("PUT", "/my-account/delivery-driver", updatedDeliveryDriverData, "Authorization", tokenUser)
+
+	var deliveryDriverObtained types.DeliveryDriver
+	err := json.Unmarshal(w.Body.Bytes(), &deliveryDriverObtained)
+
+	expectedDeliveryDriver := types.DeliveryDriver{
+		UserID:   genericUser.ID,
+		Cuil:     updatedDeliveryDriverData.Cuil,
+		Age:      25,
+		Vehicles: []string{"ABC123", "AAA111"},
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expectedDeliveryDriver, deliveryDriverObtained)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotUpdateTheirDeliveryDriverDataWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	w := requestWithCookie("PUT", "/my-account/delivery-driver", "Not a delivery driver struct", "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotUpdateTheirDeliveryDriverDataWithInvalidData(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	w := requestWithCookie("PUT", "/my-account/delivery-driver", invalidDeliveryDriver, "Authorization", tokenUser)
+
+	deliveryDriverInDB, _ := sv.Store.GetDeliveryDriverByID(ctx, deliveryDriver.UserID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AgeMustBeGreaterThan18), w.Body.String())
+	assert.True(t, deliveryDriver.IsEqualTo(deliveryDriverInDB))
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanDeleteThemselvesAsDeliveryDriver(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
+	w := requestWithCookie("DELETE", "/my-account/delivery-driver", nil, "Authorization", tokenUser)
+
+	_, err := sv.Store.GetDeliveryDriverByID(ctx, genericUser.ID)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotDeleteThemselvesAsDeliveryDriverWhenTheyAreNotDrivers(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("DELETE", "/my-account/delivery-driver", nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/*****************************************/
+/***** ORDERS ADMIN MANAGEMENT TESTS *****/
+/*****************************************/
+
+func TestAnAdminCanGetAllOrders(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToMakeAnOrder(anotherNewValidOrder, tokenAdmin)
+	w := requestWithCookie("GET", "/orders", nil, "Authorization", tokenAdmin)
+
+	ordersInDB := sv.Store.GetAllOrders(ctx)
+
+	var obtainedOrders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.ElementsMatch(t, ordersInDB, obtainedOrders)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminUserCannotGetAllOrders(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	w := requestWithCookie("GET", "/orders", nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanGetAnyOrderByID(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/orders/%v", order.ID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, order.ID, orderInDB.ID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonAdminUserCannotGetAnyOrderByID(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenAdmin)
+	uri := fmt.Sprintf("/orders/%v", order.ID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotGetAnyOrderWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	uri := fmt.Sprintf("/orders/%v", "NOT AN INTEGER")
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotGetAnyOrderWhenOrderIDDoesNotExist(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	uri := fmt.Sprintf("/orders/%v", "1000000")
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanAssignADeliveryDriverToAnOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	deliveryDriverID := struct {
+		ID uint `json:"id"`
+	}{adminUser.ID}
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, deliveryDriverID.ID, orderInDB.ID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAssignADeliveryDriverWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	deliveryDriverID := struct {
+		ID uint `json:"id"`
+	}{adminUser.ID}
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", "NOT AN INTEGER")
+	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAssignADeliveryDriverWithInvalidJsonFormat(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("PUT", uri, "Invalid Json Format", "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAssignANonExistingDeliveryDriverToAnOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	deliveryDriverID := struct {
+		ID uint `json:"id"`
+	}{100000}
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.DeliveryDriverNotFound), w.Body.String())
+	assert.NotEqual(t, 100000, orderInDB.ID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotAssignADeliveryDriverToACancelledOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	deliveryDriverID := struct {
+		ID uint `json:"id"`
+	}{adminUser.ID}
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderCannotGetADriver), w.Body.String())
+	assert.Zero(t, orderInDB.DeliveryDriverID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanDeleteAnAssignedDeliveryDriverFromAnOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, uint(0), orderInDB.DeliveryDriverID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotDeleteAnAssignedDeliveryWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", "NOT AN INTEGER")
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	assert.Equal(t, deliveryDriver.UserID, orderInDB.DeliveryDriverID)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotDeleteAnAssignedDeliveryFromANonExistingOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+
+	uri := fmt.Sprintf("/orders/%v/delivery-driver", 100000)
+	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanGetTheAssignedDeliveryDriverFromTheirOrder(t *testing.T) {
+	setup()
+	tokenAdmin := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+
+	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", order.ID)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	expectedID := fmt.Sprint(deliveryDriver.UserID)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, expectedID, w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetTheAssignedDeliveryDriverFromANonExistingOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+
+	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", 100000)
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotGetTheAssignedDeliveryDriverWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+
+	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", "NOT AN INTEGER")
+	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/********************************/
+/***** ORDERS PAYMENT TESTS *****/
+/********************************/
+
+func TestAnUserCanPayTheirOrderUsingAValidCreditCard(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "paid", orderInDB.PaymentState)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanPayTheirOrderUsingAValidDigitalWallet(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, validDigitalWalletPaymentRequest, "Authorization", tokenUser)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "paid", orderInDB.PaymentState)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayANonExistingOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	uri := fmt.Sprintf("/my-orders/%v/pay", 100000)
+	w := requestWithCookie("POST", uri, nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayWhenOrderIDIsNotAnInteger(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	uri := fmt.Sprintf("/my-orders/%v/pay", "NOT AN INTEGER")
+	w := requestWithCookie("POST", uri, nil, "Authorization", tokenUser)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayWhenPaymentDataHasNotAValidJsonFormat(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+
+	w := requestWithCookie("POST", uri, "NOT A PAYMENT STRUCT", "Authorization", tokenUser)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayWithInvalidPaymentData(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, invalidPaymentRequest, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidPaymentData), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCanPayAgainAfterAFailedPayment(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	_ = requestWithCookie("POST", uri, invalidPaymentRequest, "Authorization", tokenUser)
+
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, types.PaymentPaid, orderInDB.PaymentState)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserPaysTheDiscountedTotalOfTheirOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestWithCookie("POST", "/promo-codes", freeTubPromoCode, "Authorization", adminToken)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/promo", order.ID), map[string]string{"code": "FREEHALF"}, "Authorization", tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "paid", orderInDB.PaymentState)
+	assert.Equal(t, priceOf(1000), orderInDB.TotalCost)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/******************************/
+/***** ORDER STATUS TESTS *****/
+/******************************/
+
+func TestAnUserCanPlaceTheirOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", order.ID), nil, "Authorization", tokenUser)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.OrderPlaced, orderInDB.Status)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPlaceAnOrderWithoutIceCreamTubs(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", order.ID), nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderHasNoIceCreamTubs), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPlaceAnOrderTwice(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", order.ID), nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.IllegalOrderStatusTransition), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotAddIceCreamTubsToAPlacedOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/tubs", order.ID)
+	w := requestWithCookie("POST", uri, anotherNewValidIceCreamTub, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderIsNotADraft), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanAddAnUserToTheStaff(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+
+	w := requestWithCookie("PUT", fmt.Sprintf("/users/%v/staff", genericUser.ID), nil, "Authorization", adminToken)
+	user, err := sv.Store.GetUserByID(ctx, genericUser.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, user.IsStaff())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestStaffCanPrepareAPlacedOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = sv.Store.PromoteUserToStaff(ctx, genericUser.ID)
+	order := requestToPlaceAnOrder(tokenUser)
+	uri := fmt.Sprintf("/kitchen/orders/%v/status", order.ID)
+
+	w := requestToUpdateOrderStatus(uri, types.OrderPreparing, tokenUser)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = requestToUpdateOrderStatus(uri, types.OrderReady, tokenUser)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.OrderReady, orderInDB.Status)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestStaffCanSeeTheOrdersToPrepare(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = sv.Store.PromoteUserToStaff(ctx, genericUser.ID)
+	placedOrder := requestToPlaceAnOrder(tokenUser)
+	_ = requestToMakeAnOrder(anotherNewValidOrder, tokenUser)
+
+	w := requestWithCookie("GET", "/kitchen/orders", nil, "Authorization", tokenUser)
+
+	var actualOrders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &actualOrders)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, len(actualOrders))
+	assert.Equal(t, placedOrder.ID, actualOrders[0].ID)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestStaffCannotDeliverAnOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = sv.Store.PromoteUserToStaff(ctx, genericUser.ID)
+	order := requestToPlaceAnOrder(tokenUser)
+
+	w := requestToUpdateOrderStatus(fmt.Sprintf("/kitchen/orders/%v/status", order.ID), types.OrderDelivered, tokenUser)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderStatusNotAllowedForRole), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestANonStaffUserCannotPrepareOrders(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+
+	w := requestToUpdateOrderStatus(fmt.Sprintf("/kitchen/orders/%v/status", order.ID), types.OrderPreparing, tokenUser)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestADeliveryDriverCanDeliverAnAssignedOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	driverToken := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, adminToken)
+	order := requestToPlaceAnOrder(adminToken)
+	requestToAssignDeliveryDriverToOrder(genericUser.ID, order.ID, adminToken)
+	_ = requestToUpdateOrderStatus(fmt.Sprintf("/orders/%v/status", order.ID), types.OrderPreparing, adminToken)
+	_ = requestToUpdateOrderStatus(fmt.Sprintf("/orders/%v/status", order.ID), types.OrderReady, adminToken)
+	uri := fmt.Sprintf("/my-deliveries/%v/status", order.ID)
+
+	w := requestToUpdateOrderStatus(uri, types.OrderOutForDelivery, driverToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = requestToUpdateOrderStatus(uri, types.OrderDelivered, driverToken)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.OrderDelivered, orderInDB.Status)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestADeliveryDriverCannotUpdateAnOrderNotAssignedToThem(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	driverToken := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, adminToken)
+	order := requestToPlaceAnOrder(adminToken)
+
+	w := requestToUpdateOrderStatus(fmt.Sprintf("/my-deliveries/%v/status", order.ID), types.OrderOutForDelivery, driverToken)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestADeliveryDriverCannotPickUpAnOrderThatIsNotReady(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	driverToken := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, adminToken)
+	order := requestToPlaceAnOrder(adminToken)
+	requestToAssignDeliveryDriverToOrder(genericUser.ID, order.ID, adminToken)
+
+	w := requestToUpdateOrderStatus(fmt.Sprintf("/my-deliveries/%v/status", order.ID), types.OrderOutForDelivery, driverToken)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.IllegalOrderStatusTransition), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCannotMoveAnOrderToAnInvalidStatus(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	order := requestToPlaceAnOrder(adminToken)
+
+	w := requestToUpdateOrderStatus(fmt.Sprintf("/orders/%v/status", order.ID), "eaten", adminToken)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidOrderStatus), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayAnOrderWithoutIceCreamTubs(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderHasNoIceCreamTubs), w.Body.String())
+	assert.Equal(t, types.PaymentPending, orderInDB.PaymentState)
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayAnOrderWithAZeroTotal(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	promo := fixedAmountPromoCode
+	promo.Amount = priceOf(500)
+	_ = requestWithCookie("POST", "/promo-codes", promo, "Authorization", adminToken)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/promo", order.ID), map[string]string{"code": promo.Code}, "Authorization", tokenUser)
+
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderTotalIsZero), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotPayAnOrderTwice(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	_ = requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderIsAlreadyPaid), w.Body.String())
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotChangeTheIceCreamTubsOfAPaidOrder(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	tub := requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	addResponse := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/tubs", order.ID), anotherNewValidIceCreamTub, "Authorization", tokenUser)
+	deleteResponse := requestWithCookie("DELETE", fmt.Sprintf("/my-orders/%v/tubs/%v", order.ID, tub.ID), nil, "Authorization", tokenUser)
+	orderInDB, _ := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, addResponse.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderIsAlreadyPaid), addResponse.Body.String())
+	assert.Equal(t, http.StatusBadRequest, deleteResponse.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderIsAlreadyPaid), deleteResponse.Body.String())
+	assert.Equal(t, 1, len(orderInDB.IceCreamTubs))
+	clearAndCloseConnection(t, sv.Store)
+}
+
+/******************************/
+/***** CANCELLATION TESTS *****/
+/******************************/
+
+func TestAnUserCanCancelTheirOrderBeforePreparation(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.OrderCancelled, orderInDB.Status)
+	assert.Equal(t, genericUser.ID, orderInDB.CancelledBy)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAPaidOrderIsRefundedWhenCancelled(t *testing.T) {
+	setup()
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), map[string]string{"reason": "Wrong address"}, "Authorization", tokenUser)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.PaymentRefunded, orderInDB.PaymentState)
+	assert.Equal(t, "Wrong address", orderInDB.CancellationReason)
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotCancelTheirOrderOncePreparationStarted(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestToUpdateOrderStatus(fmt.Sprintf("/orders/%v/status", order.ID), types.OrderPreparing, adminToken)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderCannotBeCancelled), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnUserCannotCancelAnotherUserOrder(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(adminToken)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
+	clearAndCloseConnection(t, sv.Store)
+}
+
+func TestAnAdminCanCancelAnOrderAtAnyTime(t *testing.T) {
+	setup()
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", tokenUser)
+	_ = requestToUpdateOrderStatus(fmt.Sprintf("/orders/%v/status", order.ID), types.OrderPreparing, adminToken)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/orders/%v/cancel", order.ID), map[string]string{"reason": "Out of cones"}, "Authorization", adminToken)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.OrderCancelled, orderInDB.Status)
+	assert.Equal(t, types.PaymentRefunded, orderInDB.PaymentState)
+	assert.Equal(t, adminUser.ID, orderInDB.CancelledBy)
+	assert.Equal(t, "Out of cones", orderInDB.CancellationReason)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotUpdateTheirDeliveryDriverDataWithInvalidData(t *testing.T) {
+func TestAnAdminCannotCancelAnOrderWithoutAReason(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	w := requestWithCookie("PUT", "/my-account/delivery-driver", invalidDeliveryDriver, "Authorization", tokenUser)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
 
-	deliveryDriverInDB, _ := sv.Store.GetDeliveryDriverByID(deliveryDriver.UserID)
+	w := requestWithCookie("POST", fmt.Sprintf("/orders/%v/cancel", order.ID), map[string]string{}, "Authorization", adminToken)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.AgeMustBeGreaterThan18), w.Body.String())
-	assert.True(t, deliveryDriver.IsEqualTo(deliveryDriverInDB))
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.CancellationReasonIsRequired), w.Body.String())
 
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanDeleteThemselvesAsDeliveryDriver(t *testing.T) {
+func TestAnAdminCanRetryAPendingRefund(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, tokenAdmin)
-	w := requestWithCookie("DELETE", "/my-account/delivery-driver", nil, "Authorization", tokenUser)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", tokenUser)
+	// Cancelled without refunding it, as if the provider had failed when it was cancelled.
+	_, _, _ = sv.Store.CancelOrder(ctx, order.ID, types.OrderCancellation{CancelledBy: genericUser.ID, Reason: "Wrong address"})
 
-	_, err := sv.Store.GetDeliveryDriverByID(genericUser.ID)
+	w := requestWithCookie("POST", fmt.Sprintf("/orders/%v/refund", order.ID), nil, "Authorization", adminToken)
+	orderInDB, err := sv.Store.GetOrderByID(ctx, order.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.PaymentRefunded, orderInDB.PaymentState)
 
-	assert.Equal(t, http.StatusNoContent, w.Code)
-	assert.Empty(t, w.Body.String())
-	assert.EqualError(t, err, messageErrors.DeliveryDriverNotFound)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotDeleteThemselvesAsDeliveryDriverWhenTheyAreNotDrivers(t *testing.T) {
+func TestAnAdminCannotRetryTheRefundOfAnOrderWithoutAPendingRefund(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("DELETE", "/my-account/delivery-driver", nil, "Authorization", tokenUser)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", tokenUser)
+
+	w := requestWithCookie("POST", fmt.Sprintf("/orders/%v/refund", order.ID), nil, "Authorization", adminToken)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderHasNoPendingRefund), w.Body.String())
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-/*****************************************/
-/***** ORDERS ADMIN MANAGEMENT TESTS *****/
-/*****************************************/
+/*****/
+/***** ORDER HISTORY TESTS *****/
+/*****/
 
-func TestAnAdminCanGetAllOrders(t *testing.T) {
+func TestAnAdminCanGetTheFullHistoryOfAnOrder(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	_ = requestToMakeAnOrder(newValidOrder, tokenUser)
-	_ = requestToMakeAnOrder(anotherNewValidOrder, tokenAdmin)
-	w := requestWithCookie("GET", "/orders", nil, "Authorization", tokenAdmin)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, adminToken)
+	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, adminToken)
+	_ = requestWithCookie("DELETE", fmt.Sprintf("/orders/%v/delivery-driver", order.ID), nil, "Authorization", adminToken)
 
-	ordersInDB := sv.Store.GetAllOrders()
-
-	var obtainedOrders []types.Order
-	err := json.Unmarshal(w.Body.Bytes(), &obtainedOrders)
+	w := requestWithCookie("GET", fmt.Sprintf("/orders/%v/history", order.ID), nil, "Authorization", adminToken)
+	var events []types.OrderEvent
+	err := json.Unmarshal(w.Body.Bytes(), &events)
 
-	assert.Nil(t, err)
+	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.ElementsMatch(t, ordersInDB, obtainedOrders)
-	clearAndCloseConnection(t, sv.Store)
-}
-
-func TestANonAdminUserCannotGetAllOrders(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	w := requestWithCookie("GET", "/orders", nil, "Authorization", tokenUser)
+	assert.Equal(t, []string{
+		types.OrderCreatedEvent, types.TubAddedEvent, types.StatusChangedEvent, types.DriverAssignedEvent, types.DriverUnassignedEvent,
+	}, kindsOf(events))
+	assert.Equal(t, adminUser.ID, events[3].ActorID)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCanGetAnyOrderByID(t *testing.T) {
+func TestAnUserGetsTheHistoryOfTheirOrderWithoutInternalEvents(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/orders/%v", order.ID)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(tokenUser)
+	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, adminToken)
+	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, adminToken)
+	_ = requestWithCookie("DELETE", fmt.Sprintf("/orders/%v/delivery-driver", order.ID), nil, "Authorization", adminToken)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	w := requestWithCookie("GET", fmt.Sprintf("/my-orders/%v/history", order.ID), nil, "Authorization", tokenUser)
+	var events []types.OrderEvent
+	err := json.Unmarshal(w.Body.Bytes(), &events)
 
-	assert.Nil(t, err)
+	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, order.ID, orderInDB.ID)
+	assert.Equal(t, []string{
+		types.OrderCreatedEvent, types.TubAddedEvent, types.StatusChangedEvent, types.DriverAssignedEvent,
+	}, kindsOf(events))
+	assert.Equal(t, genericUser.ID, events[0].ActorID)
+	assert.Equal(t, uint(0), events[3].ActorID)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestANonAdminUserCannotGetAnyOrderByID(t *testing.T) {
+func TestAnUserCannotGetTheHistoryOfAnotherUserOrder(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenAdmin)
-	uri := fmt.Sprintf("/orders/%v", order.ID)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, adminToken)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Empty(t, w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
-}
+	w := requestWithCookie("GET", fmt.Sprintf("/my-orders/%v/history", order.ID), nil, "Authorization", tokenUser)
 
-func TestAnAdminCannotGetAnyOrderWhenOrderIDIsNotAnInteger(t *testing.T) {
-	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	uri := fmt.Sprintf("/orders/%v", "NOT AN INTEGER")
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotGetAnyOrderWhenOrderIDDoesNotExist(t *testing.T) {
+func TestAnAdminCannotGetTheHistoryOfANonExistingOrder(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	uri := fmt.Sprintf("/orders/%v", "1000000")
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenAdmin)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+
+	w := requestWithCookie("GET", fmt.Sprintf("/orders/%v/history", 100000), nil, "Authorization", adminToken)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCanAssignADeliveryDriverToAnOrder(t *testing.T) {
+/*****/
+/***** SCHEDULED ORDERS TESTS *****/
+/*****/
+
+func TestAnUserCanScheduleAnOrder(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
-	deliveryDriverID := struct {
-		ID uint `json:"id"`
-	}{adminUser.ID}
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := scheduledOrder(3, "14:00", "15:00")
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
-	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+	w := requestWithCookie("POST", "/my-orders", order, "Authorization", tokenUser)
+	var createdOrder types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &createdOrder)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, types.SameDeliveryWindow(order.DeliveryWindow, createdOrder.DeliveryWindow))
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusNoContent, w.Code)
-	assert.Empty(t, w.Body.String())
-	assert.Equal(t, deliveryDriverID.ID, orderInDB.ID)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAssignADeliveryDriverWhenOrderIDIsNotAnInteger(t *testing.T) {
+func TestAnUserCannotScheduleAnOrderWithAnInvalidWindow(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
-	deliveryDriverID := struct {
-		ID uint `json:"id"`
-	}{adminUser.ID}
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	tooSoon := types.Order{Address: "Calle 123", DeliveryWindow: &types.DeliveryWindow{Start: time.Now().Add(30 * time.Minute), End: time.Now().Add(90 * time.Minute)}}
+	reversed := scheduledOrder(3, "15:00", "14:00")
+	tooEarly := scheduledOrder(3, "08:00", "09:00")
+	tooLate := scheduledOrder(3, "22:30", "23:30")
+	overTwoDays := scheduledOrder(3, "20:00", "21:00")
+	overTwoDays.DeliveryWindow.End = overTwoDays.DeliveryWindow.End.AddDate(0, 0, 1)
+	cases := []struct {
+		order types.Order
+		error string
+	}{
+		{tooSoon, messageErrors.DeliveryWindowIsTooSoon},
+		{reversed, messageErrors.InvalidDeliveryWindow},
+		{tooEarly, messageErrors.DeliveryWindowOutsideOpeningHours},
+		{tooLate, messageErrors.DeliveryWindowOutsideOpeningHours},
+		{overTwoDays, messageErrors.DeliveryWindowOutsideOpeningHours},
+	}
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", "NOT AN INTEGER")
-	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+	for _, tc := range cases {
+		w := requestWithCookie("POST", "/my-orders", tc.order, "Authorization", tokenUser)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, utils.CreateJsonSingletonString("error", tc.error), w.Body.String())
+	}
+	assert.Empty(t, sv.Store.GetAllOrders(ctx))
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAssignADeliveryDriverWithInvalidJsonFormat(t *testing.T) {
+func TestAnAdminCanGetTheOrdersScheduledForADate(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	_ = requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	evening := requestToMakeAnOrder(scheduledOrder(3, "20:00", "21:00"), tokenUser)
+	noon := requestToMakeAnOrder(scheduledOrder(3, "12:00", "13:00"), tokenUser)
+	_ = requestToMakeAnOrder(scheduledOrder(4, "12:00", "13:00"), tokenUser)
+	_ = requestToMakeAnOrder(newValidOrder, tokenUser)
+	date := evening.DeliveryWindow.Start.Format(types.DateLayout)
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
-	w := requestWithCookie("PUT", uri, "Invalid Json Format", "Authorization", tokenAdmin)
+	w := requestWithCookie("GET", "/orders?scheduledFor="+date, nil, "Authorization", adminToken)
+	var orders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &orders)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []uint{noon.ID, evening.ID}, idsOf(orders))
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotAssignANonExistingDeliveryDriverToAnOrder(t *testing.T) {
+func TestAnAdminCannotGetTheOrdersScheduledForAnInvalidDate(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	deliveryDriverID := struct {
-		ID uint `json:"id"`
-	}{100000}
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
-	w := requestWithCookie("PUT", uri, deliveryDriverID, "Authorization", tokenAdmin)
+	w := requestWithCookie("GET", "/orders?scheduledFor=saturday", nil, "Authorization", adminToken)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidScheduledDate), w.Body.String())
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.DeliveryDriverNotFound), w.Body.String())
-	assert.NotEqual(t, 100000, orderInDB.ID)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCanDeleteAnAssignedDeliveryDriverFromAnOrder(t *testing.T) {
+func TestADeliveryDriverOnlySeesScheduledOrdersShortlyBeforeTheirWindow(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
-	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	driverToken := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	_ = requestToAddADeliveryDriver(newDeliveryDriverForGenericUser, adminToken)
+	scheduled := requestToMakeAnOrder(scheduledOrder(3, "12:00", "13:00"), adminToken)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, scheduled.ID, adminToken)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", scheduled.ID), nil, "Authorization", adminToken)
+	unscheduled := requestToPlaceAnOrder(adminToken)
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", order.ID)
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+	w := requestWithCookie("GET", "/my-deliveries/assignable", nil, "Authorization", driverToken)
+	var orders []types.Order
+	err := json.Unmarshal(w.Body.Bytes(), &orders)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []uint{unscheduled.ID}, idsOf(orders))
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusNoContent, w.Code)
-	assert.Empty(t, w.Body.String())
-	assert.Equal(t, uint(0), orderInDB.DeliveryDriverID)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotDeleteAnAssignedDeliveryWhenOrderIDIsNotAnInteger(t *testing.T) {
+/*****/
+/***** REORDER TESTS *****/
+/*****/
+
+func TestAnUserCanReorderAPreviousOrderAtCurrentPrices(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
-	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(anotherNewValidOrder, tokenUser)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", order.ID), nil, "Authorization", tokenUser)
+	_, _ = sv.Store.UpdatePrice(ctx, 500, types.IceCreamTubPrice{Weight: 500, Price: types.NewMoney(700, "ARS"), MaxFlavors: 3})
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", "NOT AN INTEGER")
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/reorder", order.ID), nil, "Authorization", tokenUser)
+	var reorder types.Reorder
+	err := json.Unmarshal(w.Body.Bytes(), &reorder)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEqual(t, order.ID, reorder.Order.ID)
+	assert.Equal(t, anotherNewValidOrder.Address, reorder.Order.Address)
+	assert.Equal(t, types.OrderDraft, reorder.Order.Status)
+	assert.Equal(t, types.PaymentPending, reorder.Order.PaymentState)
+	assert.Len(t, reorder.Order.IceCreamTubs, 2)
+	assert.Equal(t, newValidIceCreamTub.Flavors, reorder.Order.IceCreamTubs[0].Flavors)
+	assert.Equal(t, anotherNewValidIceCreamTub.Flavors, reorder.Order.IceCreamTubs[1].Flavors)
+	assert.Equal(t, types.NewMoney(700+priceOf(1000).Amount, "ARS"), reorder.Order.TotalCost)
+	assert.Empty(t, reorder.Warnings)
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
-	assert.Equal(t, deliveryDriver.UserID, orderInDB.DeliveryDriverID)
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnAdminCannotDeleteAnAssignedDeliveryFromANonExistingOrder(t *testing.T) {
+func TestReorderingLeavesOutRetiredAndUnavailableFlavorsWithWarnings(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(newValidOrder, tokenUser)
+	tub := requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+	_ = sv.Store.RetireFlavor(ctx, "frt")
+	outOfSeason := flavorMRC
+	outOfSeason.AvailableUntil = time.Now().AddDate(0, 0, -1).Format(types.DateLayout)
+	_, _ = sv.Store.UpdateFlavor(ctx, "mrc", outOfSeason)
 
-	uri := fmt.Sprintf("/orders/%v/delivery-driver", 100000)
-	w := requestWithCookie("DELETE", uri, nil, "Authorization", tokenAdmin)
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/reorder", order.ID), nil, "Authorization", tokenUser)
+	var reorder types.Reorder
+	err := json.Unmarshal(w.Body.Bytes(), &reorder)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, reorder.Order.IceCreamTubs, 1)
+	assert.Equal(t, []string{"ddl"}, reorder.Order.IceCreamTubs[0].Flavors)
+	assert.Equal(t, []types.ReorderWarning{
+		{TubID: tub.ID, FlavorID: "frt", Message: messageErrors.RetiredFlavor},
+		{TubID: tub.ID, FlavorID: "mrc", Message: messageErrors.UnavailableFlavor},
+	}, reorder.Warnings)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCanGetTheAssignedDeliveryDriverFromTheirOrder(t *testing.T) {
+func TestReorderingSkipsTubsThatCannotBeOrderedAnymore(t *testing.T) {
 	setup()
-	tokenAdmin := auth.GenerateTokenFromUserEmail(adminUser.Email)
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
 	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	deliveryDriver := requestToAddADeliveryDriver(newDeliveryDriverForAdminUser, tokenAdmin)
-	requestToAssignDeliveryDriverToOrder(deliveryDriver.UserID, order.ID, tokenAdmin)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
+	tubWithRetiredFlavor := requestToAddATubToAnOrder(types.IceCreamTub{Weight: 250, Flavors: []string{"trm"}}, order.ID, tokenUser)
+	tubOfARemovedSize := requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+	_ = sv.Store.RetireFlavor(ctx, "trm")
+	_ = sv.Store.DeletePrice(ctx, 1000)
 
-	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", order.ID)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/reorder", order.ID), nil, "Authorization", tokenUser)
+	var reorder types.Reorder
+	err := json.Unmarshal(w.Body.Bytes(), &reorder)
 
-	expectedID := fmt.Sprint(deliveryDriver.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Len(t, reorder.Order.IceCreamTubs, 1)
+	assert.Equal(t, []types.ReorderWarning{
+		{TubID: tubWithRetiredFlavor.ID, FlavorID: "trm", Message: messageErrors.RetiredFlavor},
+		{TubID: tubWithRetiredFlavor.ID, Message: messageErrors.NoFlavorsLeftInTub},
+		{TubID: tubOfARemovedSize.ID, Message: messageErrors.WeightNotAvailable},
+	}, reorder.Warnings)
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Equal(t, expectedID, w.Body.String())
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetTheAssignedDeliveryDriverFromANonExistingOrder(t *testing.T) {
+func TestAnUserCannotReorderAnotherUserOrder(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	tokenUser := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(adminToken)
 
-	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", 100000)
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+	w := requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/reorder", order.ID), nil, "Authorization", tokenUser)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
+	assert.Len(t, sv.Store.GetAllOrders(ctx), 1)
+
 	clearAndCloseConnection(t, sv.Store)
 }
 
-func TestAnUserCannotGetTheAssignedDeliveryDriverWhenOrderIDIsNotAnInteger(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-
-	uri := fmt.Sprintf("/my-orders/%v/delivery-driver", "NOT AN INTEGER")
-	w := requestWithCookie("GET", uri, nil, "Authorization", tokenUser)
+/*************************/
+/***** TIMEOUT TESTS *****/
+/*************************/
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
+// slowRouter has a single endpoint that waits for its request to be done, like a query that takes too long.
+func slowRouter(timeout time.Duration) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.Timeout(timeout))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": messageErrors.OrderNotFound})
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	return router
 }
 
-/********************************/
-/***** ORDERS PAYMENT TESTS *****/
-/********************************/
+func TestRequestsThatRunOutOfTimeAreAnsweredWithGatewayTimeout(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/slow", nil)
 
-func TestAnUserCanPayTheirOrderUsingAValidCreditCard(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
-	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+	slowRouter(10*time.Millisecond).ServeHTTP(w, req)
 
-	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
-	w := requestWithCookie("POST", uri, validCreditCardPaymentRequest, "Authorization", tokenUser)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"error": %q}`, messageErrors.RequestTimedOut), w.Body.String())
+}
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+func TestRequestsCancelledBeforeTheirTimeoutAreAnsweredWithServiceUnavailable(t *testing.T) {
+	w := httptest.NewRecorder()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(cancelled, "GET", "/slow", nil)
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusAccepted, w.Code)
-	assert.Equal(t, "paid", orderInDB.PaymentState)
+	slowRouter(time.Minute).ServeHTTP(w, req)
 
-	clearAndCloseConnection(t, sv.Store)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"error": %q}`, messageErrors.RequestCancelled), w.Body.String())
 }
 
-func TestAnUserCanPayTheirOrderUsingAValidDigitalWallet(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, tokenUser)
-	_ = requestToAddATubToAnOrder(anotherNewValidIceCreamTub, order.ID, tokenUser)
+func TestRequestsWithinTheirTimeoutKeepTheirResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/fast", nil)
 
-	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
-	w := requestWithCookie("POST", uri, validDigitalWalletPaymentRequest, "Authorization", tokenUser)
+	slowRouter(time.Minute).ServeHTTP(w, req)
 
-	orderInDB, err := sv.Store.GetOrderByID(order.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
 
-	assert.Nil(t, err)
-	assert.Equal(t, http.StatusAccepted, w.Code)
-	assert.Equal(t, "paid", orderInDB.PaymentState)
+func TestATimeoutOfZeroDisablesIt(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/fast", nil)
 
-	clearAndCloseConnection(t, sv.Store)
+	slowRouter(0).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-func TestAnUserCannotPayANonExistingOrder(t *testing.T) {
-	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	uri := fmt.Sprintf("/my-orders/%v/pay", 100000)
-	w := requestWithCookie("POST", uri, nil, "Authorization", tokenUser)
+/*****************************/
+/***** CONCURRENCY TESTS *****/
+/*****************************/
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.OrderNotFound), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
+// concurrently runs a request n times at the same time and waits for all of them to finish.
+func concurrently(n int, request func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request(i)
+		}()
+	}
+	wg.Wait()
 }
 
-func TestAnUserCannotPayWhenOrderIDIsNotAnInteger(t *testing.T) {
+func TestConcurrentSignUpsGetDifferentIDs(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	uri := fmt.Sprintf("/my-orders/%v/pay", "NOT AN INTEGER")
-	w := requestWithCookie("POST", uri, nil, "Authorization", tokenUser)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.MustBeAnInteger), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
+	defer clearAndCloseConnection(t, sv.Store)
+	codes := make([]int, 5)
+	createdUsers := make([]types.User, 5)
+
+	concurrently(5, func(i int) {
+		newUser := types.SignUpInput{Email: fmt.Sprintf("user%v@gmail.com", i), Name: "hello", LastName: "world", Password: "valid-password"}
+		w := requestWithCookie("POST", "/signup", newUser, "", "")
+		codes[i] = w.Code
+		_ = json.Unmarshal(w.Body.Bytes(), &createdUsers[i])
+	})
+
+	ids := map[uint]bool{}
+	for i := range createdUsers {
+		assert.Equal(t, http.StatusCreated, codes[i])
+		ids[createdUsers[i].ID] = true
+	}
+	assert.Len(t, ids, 5)
+	assert.Len(t, sv.Store.GetAllUsers(ctx), len(users)+5)
 }
 
-func TestAnUserCannotPayWhenPaymentDataHasNotAValidJsonFormat(t *testing.T) {
+func TestConcurrentTubAdditionsKeepTheTotalOfTheOrder(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
+	defer clearAndCloseConnection(t, sv.Store)
+	token := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToMakeAnOrder(types.Order{Address: "Calle 123"}, token)
+	stockBefore, _ := sv.Store.GetFlavorByID(ctx, "ddl")
 
-	w := requestWithCookie("POST", uri, "NOT A PAYMENT STRUCT", "Authorization", tokenUser)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidJsonFormat), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
+	concurrently(30, func(int) {
+		requestToAddATubToAnOrder(types.IceCreamTub{Weight: 250, Flavors: []string{"ddl"}}, order.ID, token)
+	})
+
+	actualOrder, _ := sv.Store.GetOrderByID(ctx, order.ID)
+	stockAfter, _ := sv.Store.GetFlavorByID(ctx, "ddl")
+	tubIDs := map[uint]bool{}
+	for _, tub := range actualOrder.IceCreamTubs {
+		tubIDs[tub.ID] = true
+	}
+	assert.Len(t, tubIDs, 30)
+	assert.Equal(t, types.NewMoney(30*priceOf(250).Amount, "ARS"), actualOrder.TotalCost)
+	assert.Equal(t, stockBefore.Stock-30*250, stockAfter.Stock)
+}
+
+func TestConcurrentCancellationsRefundAPaidOrderOnce(t *testing.T) {
+	setup()
+	defer clearAndCloseConnection(t, sv.Store)
+	adminToken := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	userToken := tokens.GenerateTokenFromUserEmail(genericUser.Email)
+	order := requestToPlaceAnOrder(userToken)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/pay", order.ID), validCreditCardPaymentRequest, "Authorization", userToken)
+	codes := make([]int, 20)
+
+	concurrently(20, func(i int) {
+		if i%2 == 0 {
+			codes[i] = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/cancel", order.ID), nil, "Authorization", userToken).Code
+		} else {
+			codes[i] = requestWithCookie("POST", fmt.Sprintf("/orders/%v/cancel", order.ID), map[string]string{"reason": "Duplicated"}, "Authorization", adminToken).Code
+		}
+	})
+
+	actualOrder, _ := sv.Store.GetOrderByID(ctx, order.ID)
+	events, _ := sv.Store.GetOrderHistory(ctx, order.ID)
+	responses := map[int]int{}
+	for _, code := range codes {
+		responses[code]++
+	}
+	kinds := map[string]int{}
+	for _, kind := range kindsOf(events) {
+		kinds[kind]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusConflict: 19}, responses, "only one cancellation succeeds")
+	assert.Equal(t, 1, kinds[types.OrderCancelledEvent])
+	assert.Equal(t, 1, kinds[types.OrderRefundedEvent], "the order is refunded once")
+	assert.Equal(t, types.PaymentRefunded, actualOrder.PaymentState)
+}
+
+func TestFlavorsCanBeReadWhileTheirStockIsUpdated(t *testing.T) {
+	setup()
+	defer clearAndCloseConnection(t, sv.Store)
+	token := tokens.GenerateTokenFromUserEmail(adminUser.Email)
+	codes := make([]int, 40)
+
+	concurrently(40, func(i int) {
+		var w *httptest.ResponseRecorder
+		if i%2 == 0 {
+			w = requestWithCookie("PUT", "/flavors/ddl/stock", map[string]uint{"stock": uint(i)}, "Authorization", token)
+		} else {
+			w = requestWithCookie("GET", "/flavors", nil, "", "")
+		}
+		codes[i] = w.Code
+	})
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
 }
 
-func TestAnUserCannotPayWithInvalidPaymentData(t *testing.T) {
+func TestObtainedFlavorsCannotChangeTheStoredOnes(t *testing.T) {
 	setup()
-	tokenUser := auth.GenerateTokenFromUserEmail(genericUser.Email)
-	order := requestToMakeAnOrder(newValidOrder, tokenUser)
-	uri := fmt.Sprintf("/my-orders/%v/pay", order.ID)
-	w := requestWithCookie("POST", uri, invalidPaymentRequest, "Authorization", tokenUser)
+	defer clearAndCloseConnection(t, sv.Store)
+	_ = sv.Store.AddFlavor(ctx, flavorWithNuts)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Equal(t, utils.CreateJsonSingletonString("error", messageErrors.InvalidPaymentData), w.Body.String())
-	clearAndCloseConnection(t, sv.Store)
+	obtained, _ := sv.Store.GetFlavorByID(ctx, flavorWithNuts.ID)
+	obtained.Allergens[0] = "soy"
+	stored, _ := sv.Store.GetFlavorByID(ctx, flavorWithNuts.ID)
+
+	assert.Equal(t, flavorWithNuts.Allergens, stored.Allergens)
 }
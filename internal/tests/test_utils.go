@@ -1,19 +1,58 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"icecreamshop/internal/auth"
+	"icecreamshop/internal/config"
 	"icecreamshop/internal/services/payment"
 	"icecreamshop/internal/types"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 )
 
-var flavorDDL types.Flavor = types.Flavor{ID: "ddl", Name: "Dulce de leche", Type: "Dulce de leches"}
-var flavorMRC types.Flavor = types.Flavor{ID: "mrc", Name: "Chocolate marroc", Type: "Chocolates"}
-var flavorTRM types.Flavor = types.Flavor{ID: "trm", Name: "Tramontana", Type: "Cremas"}
-var flavorFRT types.Flavor = types.Flavor{ID: "frt", Name: "Frutilla al agua", Type: "Al agua"}
+// ctx is the context of the storage calls made directly by tests.
+var ctx = context.Background()
+
+var categories = []types.FlavorCategory{
+	{ID: "dulce-de-leches", Name: "Dulce de leches", SortOrder: 1},
+	{ID: "chocolates", Name: "Chocolates", SortOrder: 2},
+	{ID: "cremas", Name: "Cremas", SortOrder: 3},
+	{ID: "al-agua", Name: "Al agua", SortOrder: 4},
+}
+
+var flavorDDL types.Flavor = types.Flavor{ID: "ddl", Name: "Dulce de leche", CategoryID: "dulce-de-leches", Stock: 10000}
+var flavorMRC types.Flavor = types.Flavor{ID: "mrc", Name: "Chocolate marroc", CategoryID: "chocolates", Stock: 10000}
+var flavorTRM types.Flavor = types.Flavor{ID: "trm", Name: "Tramontana", CategoryID: "cremas", Stock: 10000}
+var flavorFRT types.Flavor = types.Flavor{ID: "frt", Name: "Frutilla al agua", CategoryID: "al-agua", Stock: 10000}
+
+// flavorOutOfSeason stopped being available yesterday.
+var flavorOutOfSeason = types.Flavor{
+	ID:             "mng",
+	Name:           "Mango",
+	CategoryID:     "al-agua",
+	Stock:          10000,
+	AvailableUntil: time.Now().AddDate(0, 0, -1).Format(types.DateLayout),
+}
+
+var flavorWithNuts = types.Flavor{
+	ID:         "alm",
+	Name:       "Almendrado",
+	CategoryID: "cremas",
+	Stock:      10000,
+	Allergens:  []string{"lactose", "nuts"},
+}
+
+var flavorVegan = types.Flavor{
+	ID:         "lim",
+	Name:       "Limon al agua",
+	CategoryID: "al-agua",
+	Stock:      10000,
+	Diets:      []string{"vegan", "lactose-free"},
+}
 
 var flavors = []types.Flavor{
 	flavorDDL, flavorMRC, flavorTRM, flavorFRT,
@@ -69,10 +108,38 @@ var invalidDeliveryDriver = types.DeliveryDriver{
 
 var users = []types.User{adminUser, genericUser}
 
-var prices = map[uint]uint{
-	250:  3,
-	500:  5,
-	1000: 10,
+var prices = []types.IceCreamTubPrice{
+	{Weight: 250, Price: types.NewMoney(300, "ARS"), MaxFlavors: 3},
+	{Weight: 500, Price: types.NewMoney(500, "ARS"), MaxFlavors: 3},
+	{Weight: 1000, Price: types.NewMoney(1000, "ARS"), MaxFlavors: 4},
+}
+
+// priceOf returns the price of a tub size from the prices fixture.
+func priceOf(weight uint) types.Money {
+	for _, price := range prices {
+		if price.Weight == weight {
+			return price.Price
+		}
+	}
+	return types.NewMoney(0, "ARS")
+}
+
+var percentagePromoCode = types.PromoCode{
+	Code:       "TENOFF",
+	Kind:       types.PercentageDiscount,
+	Percentage: 10,
+}
+
+var fixedAmountPromoCode = types.PromoCode{
+	Code:   "MINUS200",
+	Kind:   types.FixedAmountDiscount,
+	Amount: types.NewMoney(200, "ARS"),
+}
+
+var freeTubPromoCode = types.PromoCode{
+	Code:          "FREEHALF",
+	Kind:          types.FreeTubDiscount,
+	FreeTubWeight: 500,
 }
 
 var newValidOrder types.Order = types.Order{
@@ -139,6 +206,10 @@ var invalidPaymentRequest = payment.PaymentRequest{
 	CreditCard:  &payment.CreditCard{},
 }
 
+// testConfig is loaded from the .env file by TestMain, and tokens signs the session tokens with its secret, like the server.
+var testConfig config.Config
+var tokens auth.Tokens
+
 // requestWithCookie receives the necessary data to make a request with a cookie value
 func requestWithCookie(method, path string, structBody any, cookieName, cookieValue string) *httptest.ResponseRecorder {
 	jsonBody, err := json.Marshal(structBody)
@@ -205,3 +276,49 @@ func requestToAssignDeliveryDriverToOrder(deliveryDriverID, orderID uint, author
 	uri := fmt.Sprintf("/orders/%v/delivery-driver", orderID)
 	_ = requestWithCookie("PUT", uri, idStruct, "Authorization", authorizationToken)
 }
+
+// requestToPlaceAnOrder builds a request to make an order with a tub and place it.
+// Receives the token from the user making it.
+func requestToPlaceAnOrder(userToken string) types.Order {
+	order := requestToMakeAnOrder(newValidOrder, userToken)
+	_ = requestToAddATubToAnOrder(newValidIceCreamTub, order.ID, userToken)
+	_ = requestWithCookie("POST", fmt.Sprintf("/my-orders/%v/place", order.ID), nil, "Authorization", userToken)
+	return order
+}
+
+// requestToUpdateOrderStatus builds a request to move an order to a new status through the endpoint of a role.
+func requestToUpdateOrderStatus(uri string, status string, token string) *httptest.ResponseRecorder {
+	return requestWithCookie("PUT", uri, map[string]string{"status": status}, "Authorization", token)
+}
+
+// kindsOf lists the kinds of a history of events, keeping their order.
+func kindsOf(events []types.OrderEvent) []string {
+	kinds := []string{}
+	for _, event := range events {
+		kinds = append(kinds, event.Kind)
+	}
+	return kinds
+}
+
+// scheduledOrder builds an order to deliver some days from today, between two times of the day like 14:00.
+func scheduledOrder(days int, from string, to string) types.Order {
+	day := time.Now().AddDate(0, 0, days)
+	return types.Order{
+		Address:        "Calle 123",
+		DeliveryWindow: &types.DeliveryWindow{Start: atClock(day, from), End: atClock(day, to)},
+	}
+}
+
+func atClock(day time.Time, clock string) time.Time {
+	parsed, _ := time.Parse(types.ClockLayout, clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+}
+
+// idsOf lists the ids of some orders, keeping their order.
+func idsOf(orders []types.Order) []uint {
+	ids := []uint{}
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
@@ -12,7 +12,7 @@ type DeliveryDriver struct {
 	Cuil        string   `json:"cuil" gorm:"not null;unique"`
 	Age         uint     `json:"age" gorm:"not null; check: Age>17"` //check
 	Vehicles    []string `json:"vehicles" gorm:"-"`
-	RawVehicles string   `json:"-" gorm:"column:vehicles; type:jsonb; not null"`
+	RawVehicles JSONText `json:"-" gorm:"column:vehicles; not null"`
 }
 
 func (d *DeliveryDriver) Validate() error {
@@ -62,7 +62,7 @@ func (d *DeliveryDriver) BeforeSave(tx *gorm.DB) (err error) {
 		if err != nil {
 			return err
 		}
-		d.RawVehicles = string(raw)
+		d.RawVehicles = JSONText(raw)
 	}
 	return nil
 }
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"errors"
+	"icecreamshop/internal/messageErrors"
+	"strings"
+	"time"
+)
+
+// ClockLayout is the format used for times of the day, like HH:MM.
+const ClockLayout = "15:04"
+
+// Fallbacks used when the store is not configured with a schedule.
+const (
+	fallbackOpeningTime  = "11:00"
+	fallbackClosingTime  = "23:00"
+	fallbackLeadTime     = 2 * time.Hour
+	fallbackDriverNotice = time.Hour
+)
+
+// DeliveryWindow is the period in which a customer wants to receive a scheduled order.
+// Both ends must be on the same day and within the store opening hours.
+type DeliveryWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// OpeningHours returns the time of the day the store opens and closes, in minutes since midnight.
+func (s StoreSettings) OpeningHours() (opening int, closing int) {
+	return minutesOfClock(s.OpeningTime, fallbackOpeningTime),
+		minutesOfClock(s.ClosingTime, fallbackClosingTime)
+}
+
+// IsValidClock checks if a time of the day has the format HH:MM.
+func IsValidClock(clock string) bool {
+	_, err := time.Parse(ClockLayout, strings.TrimSpace(clock))
+	return err == nil
+}
+
+// CheckAt checks if the window can be requested from a store at a given moment:
+// it must end after it starts, start after the lead time and be within the opening hours of a single day.
+func (w DeliveryWindow) CheckAt(moment time.Time, store StoreSettings) error {
+	if !w.End.After(w.Start) {
+		return errors.New(messageErrors.InvalidDeliveryWindow)
+	}
+	if w.Start.Before(moment.Add(store.SchedulingLeadTime)) {
+		return errors.New(messageErrors.DeliveryWindowIsTooSoon)
+	}
+	start, end := w.Start.Local(), w.End.Local()
+	if start.Format(DateLayout) != end.Format(DateLayout) {
+		return errors.New(messageErrors.DeliveryWindowOutsideOpeningHours)
+	}
+	opening, closing := store.OpeningHours()
+	if minutesOfDay(start) < opening || minutesOfDay(end) > closing {
+		return errors.New(messageErrors.DeliveryWindowOutsideOpeningHours)
+	}
+	return nil
+}
+
+// StartsOn checks if the window starts on a given date, with the format YYYY-MM-DD.
+func (w DeliveryWindow) StartsOn(date string) bool {
+	return w.Start.Local().Format(DateLayout) == date
+}
+
+// IsNoticedAt checks if delivery drivers can already see the window at a given moment,
+// when they see windows a notice before they start.
+func (w DeliveryWindow) IsNoticedAt(moment time.Time, notice time.Duration) bool {
+	return !moment.Before(w.Start.Add(-notice))
+}
+
+// SameDeliveryWindow checks if two optional windows are equal. Orders without window have a nil one.
+func SameDeliveryWindow(a *DeliveryWindow, b *DeliveryWindow) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Start.Equal(b.Start) && a.End.Equal(b.End)
+}
+
+func minutesOfDay(moment time.Time) int {
+	return moment.Hour()*60 + moment.Minute()
+}
+
+func minutesOfClock(clock string, fallback string) int {
+	parsed, err := time.Parse(ClockLayout, strings.TrimSpace(clock))
+	if err != nil {
+		parsed, _ = time.Parse(ClockLayout, fallback)
+	}
+	return minutesOfDay(parsed)
+}
@@ -1,20 +1,40 @@
 package types
 
 import (
+	"encoding/json"
 	"errors"
+	"gorm.io/gorm"
 	"icecreamshop/internal/messageErrors"
+	"slices"
+	"strings"
+	"time"
 )
 
-const (
-	dulceDeLeches string = "Dulce de leches"
-	chocolates    string = "Chocolates"
-	cremas        string = "Cremas"
-)
+// DateLayout is the format used for dates without time, like YYYY-MM-DD.
+const DateLayout = "2006-01-02"
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// Allergens that a flavor may contain.
+var Allergens = []string{"nuts", "peanuts", "gluten", "lactose", "eggs", "soy"}
+
+// Diets that a flavor may be suitable for.
+var Diets = []string{"vegan", "sugar-free", "gluten-free", "lactose-free"}
 
 type Flavor struct {
-	ID   string `json:"id" gorm:"primaryKey"`
-	Name string `json:"name" gorm:"not null"`
-	Type string `json:"type" gorm:"not null"`
+	ID                   string   `json:"id" gorm:"primaryKey"`
+	Name                 string   `json:"name" gorm:"not null"`
+	CategoryID           string   `json:"categoryID" gorm:"not null; index"`
+	Stock                uint     `json:"stock" gorm:"not null; default:0"` // measured in grams
+	Retired              bool     `json:"retired" gorm:"not null; default:false"`
+	AvailableFrom        string   `json:"availableFrom,omitempty"`  // YYYY-MM-DD, empty means no start date
+	AvailableUntil       string   `json:"availableUntil,omitempty"` // YYYY-MM-DD, empty means no end date
+	AvailableWeekdays    []string `json:"availableWeekdays,omitempty" gorm:"-"`
+	RawAvailableWeekdays JSONText `json:"-" gorm:"column:available_weekdays; default:'[]'"`
+	Allergens            []string `json:"allergens,omitempty" gorm:"-"`
+	RawAllergens         JSONText `json:"-" gorm:"column:allergens; default:'[]'"`
+	Diets                []string `json:"diets,omitempty" gorm:"-"`
+	RawDiets             JSONText `json:"-" gorm:"column:diets; default:'[]'"`
 }
 
 func (f *Flavor) Validate() error {
@@ -25,12 +45,90 @@ func (f *Flavor) Validate() error {
 	if f.Name == "" {
 		return errors.New(messageErrors.FlavorNameIsRequired)
 	}
-	if f.Type == "" {
-		return errors.New(messageErrors.FlavorTypeIsRequired)
+	if f.CategoryID == "" {
+		return errors.New(messageErrors.FlavorCategoryIsRequired)
+	}
+	if err := f.validateAvailability(); err != nil {
+		return err
+	}
+	for i, allergen := range f.Allergens {
+		f.Allergens[i] = strings.ToLower(allergen)
+		if !slices.Contains(Allergens, f.Allergens[i]) {
+			return errors.New(messageErrors.InvalidAllergen)
+		}
+	}
+	for i, diet := range f.Diets {
+		f.Diets[i] = strings.ToLower(diet)
+		if !slices.Contains(Diets, f.Diets[i]) {
+			return errors.New(messageErrors.InvalidDiet)
+		}
 	}
 	return nil
 }
 
+func (f *Flavor) validateAvailability() error {
+	var from, until time.Time
+	var err error
+	if f.AvailableFrom != "" {
+		from, err = time.Parse(DateLayout, f.AvailableFrom)
+		if err != nil {
+			return errors.New(messageErrors.InvalidAvailabilityDate)
+		}
+	}
+	if f.AvailableUntil != "" {
+		until, err = time.Parse(DateLayout, f.AvailableUntil)
+		if err != nil {
+			return errors.New(messageErrors.InvalidAvailabilityDate)
+		}
+	}
+	if f.AvailableFrom != "" && f.AvailableUntil != "" && until.Before(from) {
+		return errors.New(messageErrors.InvalidAvailabilityWindow)
+	}
+	for i, weekday := range f.AvailableWeekdays {
+		f.AvailableWeekdays[i] = strings.ToLower(weekday)
+		if !slices.Contains(weekdays, f.AvailableWeekdays[i]) {
+			return errors.New(messageErrors.InvalidWeekday)
+		}
+	}
+	return nil
+}
+
+// IsAvailableAt checks if the flavor can be sold at a given moment.
+// Empty dates or weekdays mean the flavor has no restriction.
+func (f Flavor) IsAvailableAt(moment time.Time) bool {
+	date := moment.Format(DateLayout)
+	if f.AvailableFrom != "" && date < f.AvailableFrom {
+		return false
+	}
+	if f.AvailableUntil != "" && date > f.AvailableUntil {
+		return false
+	}
+	if len(f.AvailableWeekdays) > 0 && !slices.Contains(f.AvailableWeekdays, weekdays[moment.Weekday()]) {
+		return false
+	}
+	return true
+}
+
+// ContainsAnyAllergen checks if the flavor contains at least one of the allergens.
+func (f Flavor) ContainsAnyAllergen(allergens []string) bool {
+	for _, allergen := range allergens {
+		if slices.Contains(f.Allergens, allergen) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuitableForDiets checks if the flavor is suitable for all the diets.
+func (f Flavor) IsSuitableForDiets(diets []string) bool {
+	for _, diet := range diets {
+		if !slices.Contains(f.Diets, diet) {
+			return false
+		}
+	}
+	return true
+}
+
 func (f Flavor) IsEqualTo(flavor Flavor) bool {
 	if f.ID != flavor.ID {
 		return false
@@ -38,8 +136,92 @@ func (f Flavor) IsEqualTo(flavor Flavor) bool {
 	if f.Name != flavor.Name {
 		return false
 	}
-	if f.Type != flavor.Type {
+	if f.CategoryID != flavor.CategoryID {
+		return false
+	}
+	if f.Stock != flavor.Stock {
+		return false
+	}
+	if f.Retired != flavor.Retired {
+		return false
+	}
+	if f.AvailableFrom != flavor.AvailableFrom {
+		return false
+	}
+	if f.AvailableUntil != flavor.AvailableUntil {
+		return false
+	}
+	if !slices.Equal(f.AvailableWeekdays, flavor.AvailableWeekdays) {
+		return false
+	}
+	if !slices.Equal(f.Allergens, flavor.Allergens) {
+		return false
+	}
+	if !slices.Equal(f.Diets, flavor.Diets) {
 		return false
 	}
 	return true
 }
+
+// BeforeSave is executed when Gorm is about to save new data in the database.
+func (f *Flavor) BeforeSave(tx *gorm.DB) (err error) {
+	// Serializes AvailableWeekdays, Allergens and Diets from slices to JSON
+	if err := encodeList(f.AvailableWeekdays, &f.RawAvailableWeekdays); err != nil {
+		return err
+	}
+	if err := encodeList(f.Allergens, &f.RawAllergens); err != nil {
+		return err
+	}
+	if err := encodeList(f.Diets, &f.RawDiets); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AfterFind is executed just after Gorm finds data from the database.
+func (f *Flavor) AfterFind(tx *gorm.DB) (err error) {
+	// Deserializes RawAvailableWeekdays, RawAllergens and RawDiets from JSON to slices.
+	if err := decodeList(&f.RawAvailableWeekdays, &f.AvailableWeekdays); err != nil {
+		return err
+	}
+	if err := decodeList(&f.RawAllergens, &f.Allergens); err != nil {
+		return err
+	}
+	if err := decodeList(&f.RawDiets, &f.Diets); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (f *Flavor) AfterCreate(tx *gorm.DB) (err error) {
+	f.RawAvailableWeekdays = ""
+	f.RawAllergens = ""
+	f.RawDiets = ""
+	return nil
+}
+
+// encodeList serializes a list to JSON, only if the list is not nil.
+func encodeList(list []string, raw *JSONText) error {
+	if list == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	*raw = JSONText(encoded)
+	return nil
+}
+
+// decodeList deserializes a JSON list and clears the raw value.
+// An empty list is kept as nil.
+func decodeList(raw *JSONText, list *[]string) error {
+	if *raw != "" && *raw != "[]" {
+		err := json.Unmarshal([]byte(*raw), list)
+		if err != nil {
+			return err
+		}
+	}
+	*raw = ""
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"errors"
+	"icecreamshop/internal/messageErrors"
+)
+
+type FlavorCategory struct {
+	ID        string `json:"id" gorm:"primaryKey"`
+	Name      string `json:"name" gorm:"not null"`
+	SortOrder uint   `json:"sortOrder" gorm:"not null; default:0"`
+}
+
+func (fc *FlavorCategory) Validate() error {
+	if fc.ID == "" {
+		return errors.New(messageErrors.FlavorCategoryIdIsRequired)
+	}
+	if fc.Name == "" {
+		return errors.New(messageErrors.FlavorCategoryNameIsRequired)
+	}
+	return nil
+}
+
+func (fc FlavorCategory) IsEqualTo(category FlavorCategory) bool {
+	if fc.ID != category.ID {
+		return false
+	}
+	if fc.Name != category.Name {
+		return false
+	}
+	if fc.SortOrder != category.SortOrder {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSONText is a JSON document stored in a single column.
+// The column is jsonb in Postgres and text in databases without a JSON type, like SQLite.
+type JSONText string
+
+// GormDBDataType tells Gorm the type of the column in the database it migrates.
+func (JSONText) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "text"
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"icecreamshop/internal/messageErrors"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// fallbackCurrency is used when the store is not configured with a currency.
+const fallbackCurrency = "ARS"
+
+// zeroDecimalCurrencies are ISO 4217 currencies without minor units.
+var zeroDecimalCurrencies = []string{"CLP", "JPY", "KRW", "PYG", "VND"}
+
+var currencyFormat = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Money is an amount of money measured in the minor unit of its currency, like cents for ARS.
+// It is stored as two columns: the amount as an integer and the ISO 4217 currency code.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null; default:0"`
+	Currency string `json:"currency" gorm:"size:3; not null; default:''"`
+}
+
+// NewMoney creates an amount of money in minor units of a currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// DefaultCurrency is the currency the store prices its products in.
+func (s StoreSettings) DefaultCurrency() string {
+	currency := strings.TrimSpace(s.Currency)
+	if currency == "" {
+		return fallbackCurrency
+	}
+	return strings.ToUpper(currency)
+}
+
+// IsValidCurrency checks if a currency is a three letters ISO 4217 code, like ARS.
+func IsValidCurrency(currency string) bool {
+	return currencyFormat.MatchString(currency)
+}
+
+// Validate fills an empty currency with the default one of the store and checks the currency code.
+func (m *Money) Validate(defaultCurrency string) error {
+	if m.Currency == "" {
+		m.Currency = defaultCurrency
+	}
+	m.Currency = strings.ToUpper(m.Currency)
+	if !IsValidCurrency(m.Currency) {
+		return errors.New(messageErrors.InvalidCurrency)
+	}
+	return nil
+}
+
+// IsPositive checks if the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Add sums two amounts of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, errors.New(messageErrors.CurrencyMismatch)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// MinorUnitDigits returns how many decimals the currency has.
+func (m Money) MinorUnitDigits() int {
+	if slices.Contains(zeroDecimalCurrencies, m.Currency) {
+		return 0
+	}
+	return 2
+}
+
+// MajorUnits returns the amount in the main unit of the currency, like pesos instead of cents.
+// It is meant for external services that expect decimal amounts.
+func (m Money) MajorUnits() float64 {
+	return float64(m.Amount) / math.Pow10(m.MinorUnitDigits())
+}
+
+// String formats the amount with its currency, like "ARS 10.50".
+func (m Money) String() string {
+	return fmt.Sprintf("%s %.*f", m.Currency, m.MinorUnitDigits(), m.MajorUnits())
+}
@@ -5,44 +5,214 @@ import (
 	"errors"
 	"gorm.io/gorm"
 	"icecreamshop/internal/messageErrors"
+	"slices"
+	"time"
 )
 
+// Payment states of an order. They are set by the server only, never by the client.
+// Orders are processing while their customer is charged, and cancelled paid orders wait in refund pending until their refund is issued.
+const (
+	PaymentPending       = "pending"
+	PaymentProcessing    = "processing"
+	PaymentPaid          = "paid"
+	PaymentRefundPending = "refund_pending"
+	PaymentRefunded      = "refunded"
+)
+
+// IceCreamTubPrice is a tub size on sale. Weight is measured in grams and identifies the size.
 type IceCreamTubPrice struct {
-	Weight uint `json:"weight" gorm:"not null"`
-	Price  uint `json:"price" gorm:"not null"`
+	Weight     uint  `json:"weight" gorm:"primaryKey; autoIncrement:false"`
+	Price      Money `json:"price" gorm:"embedded; embeddedPrefix:price_"`
+	MaxFlavors uint  `json:"maxFlavors" gorm:"not null; default:4"`
 }
+
 type IceCreamTub struct {
 	ID         uint     `json:"id" gorm:"primaryKey; autoIncrement"`
 	Weight     uint     `json:"weight" gorm:"not null"`
 	Flavors    []string `json:"flavor" gorm:"-"`
-	RawFlavors string   `json:"-" gorm:"column:flavor; type:jsonb"`
+	RawFlavors JSONText `json:"-" gorm:"column:flavor"`
 	OrderID    uint     `json:"order_id" gorm:"not null"`
+	UnitPrice  Money    `json:"unitPrice" gorm:"embedded; embeddedPrefix:unit_price_"` // price of its weight when it was added
+	Allergens  []string `json:"allergens,omitempty" gorm:"-"`                          // combined allergens of its flavors, not stored
 }
 
 type Order struct {
-	ID               uint          `json:"id" gorm:"primaryKey; autoIncrement"`
-	Address          string        `json:"address" gorm:"not null"`
-	IceCreamTubs     []IceCreamTub `json:"iceCreamTubs" gorm:"foreignKey:OrderID"`
-	UserID           uint          `json:"userID" gorm:"not null"`
-	DeliveryDriverID uint          `json:"deliveryDriverID"`
-	PaymentState     string        `json:"state" gorm:"not null"`
-	TotalCost        uint          `json:"totalCost" gorm:"not null"`
+	ID                 uint            `json:"id" gorm:"primaryKey; autoIncrement"`
+	Address            string          `json:"address" gorm:"not null"`
+	IceCreamTubs       []IceCreamTub   `json:"iceCreamTubs" gorm:"foreignKey:OrderID"`
+	UserID             uint            `json:"userID" gorm:"not null"`
+	DeliveryDriverID   uint            `json:"deliveryDriverID"`
+	PaymentState       string          `json:"state" gorm:"not null"`
+	PaymentReference   string          `json:"-"`                                              // id given by the payment provider, needed to refund the order (a preference id for MercadoPago)
+	Status             string          `json:"status" gorm:"not null; default:'draft'; index"` // fulfillment status, see orderStatus.go
+	PromoCode          string          `json:"promoCode,omitempty" gorm:"index"`
+	Subtotal           Money           `json:"subtotal" gorm:"embedded; embeddedPrefix:subtotal_"`
+	Discount           Money           `json:"discount" gorm:"embedded; embeddedPrefix:discount_"`
+	TotalCost          Money           `json:"totalCost" gorm:"embedded; embeddedPrefix:total_cost_"` // subtotal minus discount
+	CancelledBy        uint            `json:"cancelledBy,omitempty"`                                 // id of the user who cancelled the order
+	CancellationReason string          `json:"cancellationReason,omitempty"`
+	DeliveryWindow     *DeliveryWindow `json:"deliveryWindow,omitempty" gorm:"-"` // only for scheduled orders
+	DeliveryStart      *time.Time      `json:"-" gorm:"column:delivery_window_start; index"`
+	DeliveryEnd        *time.Time      `json:"-" gorm:"column:delivery_window_end"`
+}
+
+// TubError is the reason a tub of a new order was rejected. Tubs are identified by their position in the order.
+type TubError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// InvalidTubsError is returned when one or more tubs of a new order are rejected. In that case, neither the order nor its tubs are saved.
+type InvalidTubsError struct {
+	Tubs []TubError
+}
+
+func (e *InvalidTubsError) Error() string {
+	return messageErrors.InvalidIceCreamTubs
 }
 
 func (p *IceCreamTub) Validate() error {
 	if p.Weight == 0 {
 		return errors.New(messageErrors.WeightCannotBeZero)
 	}
-	if len(p.Flavors) == 0 || len(p.Flavors) > 4 {
+	if len(p.Flavors) == 0 {
 		return errors.New(messageErrors.InvalidAmountOfFlavors)
 	}
 	return nil
 }
 
-func (p *Order) Validate() error {
+func (p *IceCreamTubPrice) Validate(defaultCurrency string) error {
+	if p.Weight == 0 {
+		return errors.New(messageErrors.WeightCannotBeZero)
+	}
+	if !p.Price.IsPositive() {
+		return errors.New(messageErrors.PriceCannotBeZero)
+	}
+	if err := p.Price.Validate(defaultCurrency); err != nil {
+		return err
+	}
+	if p.MaxFlavors == 0 {
+		return errors.New(messageErrors.MaxFlavorsCannotBeZero)
+	}
+	return nil
+}
+
+// GramsPerFlavor splits the tub weight evenly between its flavors.
+// Remaining grams from the division are assigned to the first flavors, so the sum always equals the tub weight.
+func (p *IceCreamTub) GramsPerFlavor() map[string]uint {
+	grams := make(map[string]uint)
+	if len(p.Flavors) == 0 {
+		return grams
+	}
+	share := p.Weight / uint(len(p.Flavors))
+	remainder := p.Weight % uint(len(p.Flavors))
+	for i, flavorID := range p.Flavors {
+		grams[flavorID] += share
+		if uint(i) < remainder {
+			grams[flavorID]++
+		}
+	}
+	return grams
+}
+
+// ComputeSubtotal sums the prices the order tubs were charged at, in the currency of the order.
+func (p *Order) ComputeSubtotal() Money {
+	subtotal := NewMoney(0, p.TotalCost.Currency)
+	for _, tub := range p.IceCreamTubs {
+		subtotal.Amount += tub.UnitPrice.Amount
+	}
+	return subtotal
+}
+
+// ComputeTotals updates the subtotal, discount and total cost of the order from its tubs.
+// The promo code must be the one applied to the order, or nil if it has none.
+func (p *Order) ComputeTotals(promo *PromoCode) {
+	p.Subtotal = p.ComputeSubtotal()
+	p.Discount = NewMoney(0, p.Subtotal.Currency)
+	if promo != nil {
+		p.Discount = promo.DiscountFor(*p)
+	}
+	p.TotalCost = NewMoney(p.Subtotal.Amount-p.Discount.Amount, p.Subtotal.Currency)
+}
+
+// IsPaid checks if the order has already been paid. Paid orders cannot change their tubs.
+func (p Order) IsPaid() bool {
+	return p.PaymentState == PaymentPaid
+}
+
+// CheckUnpaid checks that the payment of the order has not started, so its tubs and promo code can still change.
+func (p Order) CheckUnpaid() error {
+	if p.IsPaid() {
+		return errors.New(messageErrors.OrderIsAlreadyPaid)
+	}
+	if p.PaymentState == PaymentProcessing {
+		return errors.New(messageErrors.OrderIsBeingPaid)
+	}
+	return nil
+}
+
+// IsRepriceable checks if the totals of the order still follow its promo code when it changes:
+// only orders waiting to be paid are repriced, while paid, refunded and cancelled orders keep what they were charged.
+func (p Order) IsRepriceable() bool {
+	return p.PaymentState == PaymentPending && p.Status != OrderCancelled
+}
+
+// CheckPayable checks if the payment of the order can start: it must not be paid or being paid, and it must have tubs and a total greater than zero.
+func (p Order) CheckPayable() error {
+	if err := p.CheckUnpaid(); err != nil {
+		return err
+	}
+	if p.Status == OrderCancelled {
+		return errors.New(messageErrors.OrderIsAlreadyCancelled)
+	}
+	if len(p.IceCreamTubs) == 0 {
+		return errors.New(messageErrors.OrderHasNoIceCreamTubs)
+	}
+	if !p.TotalCost.IsPositive() {
+		return errors.New(messageErrors.OrderTotalIsZero)
+	}
+	return nil
+}
+
+// CheckPaidWith checks if the order can be marked as paid once its customer was charged the amount:
+// its payment must have started, and the amount must be its total.
+func (p Order) CheckPaidWith(amount Money) error {
+	if p.Status == OrderCancelled {
+		return errors.New(messageErrors.OrderIsAlreadyCancelled)
+	}
+	if p.IsPaid() {
+		return errors.New(messageErrors.OrderIsAlreadyPaid)
+	}
+	if p.PaymentState != PaymentProcessing {
+		return errors.New(messageErrors.OrderPaymentNotStarted)
+	}
+	if amount != p.TotalCost {
+		return errors.New(messageErrors.PaidAmountMismatch)
+	}
+	return nil
+}
+
+// IsScheduled checks if the customer asked for the order to be delivered in a window.
+func (p Order) IsScheduled() bool {
+	return p.DeliveryWindow != nil
+}
+
+// IsAssignableAt checks if a delivery driver can take the order at a given moment: it must be in the shop and have no driver yet.
+// Scheduled orders only become assignable the notice of the drivers before their window.
+func (p Order) IsAssignableAt(moment time.Time, notice time.Duration) bool {
+	if p.DeliveryDriverID != 0 || !slices.Contains(AssignableOrderStatuses, p.Status) {
+		return false
+	}
+	return !p.IsScheduled() || p.DeliveryWindow.IsNoticedAt(moment, notice)
+}
+
+func (p *Order) Validate(store StoreSettings) error {
 	if p.Address == "" {
 		return errors.New(messageErrors.AddressIsRequired)
 	}
+	if p.IsScheduled() {
+		return p.DeliveryWindow.CheckAt(time.Now(), store)
+	}
 	return nil
 }
 
@@ -57,6 +227,9 @@ func (p IceCreamTub) IsEqualTo(pote IceCreamTub) bool {
 	if p.OrderID != pote.OrderID {
 		return false
 	}
+	if p.UnitPrice != pote.UnitPrice {
+		return false
+	}
 	if len(p.Flavors) != len(pote.Flavors) {
 		return false
 	}
@@ -86,9 +259,24 @@ func (p Order) IsEqualTo(pedido Order) bool {
 	if p.PaymentState != pedido.PaymentState {
 		return false
 	}
+	if p.Status != pedido.Status {
+		return false
+	}
+	if p.PromoCode != pedido.PromoCode {
+		return false
+	}
+	if p.Subtotal != pedido.Subtotal {
+		return false
+	}
+	if p.Discount != pedido.Discount {
+		return false
+	}
 	if p.TotalCost != pedido.TotalCost {
 		return false
 	}
+	if !SameDeliveryWindow(p.DeliveryWindow, pedido.DeliveryWindow) {
+		return false
+	}
 
 	if len(p.IceCreamTubs) != len(pedido.IceCreamTubs) {
 		return false
@@ -110,8 +298,7 @@ func (p *IceCreamTub) BeforeSave(tx *gorm.DB) (err error) {
 		if err != nil {
 			return err
 		}
-		p.RawFlavors = string(raw)
-		print(p.RawFlavors)
+		p.RawFlavors = JSONText(raw)
 	}
 	return nil
 }
@@ -143,9 +330,23 @@ func (p *Order) AfterCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// BeforeSave is executed when Gorm is about to save new data in the database.
+func (p *Order) BeforeSave(tx *gorm.DB) (err error) {
+	// Splits DeliveryWindow into its columns
+	p.DeliveryStart, p.DeliveryEnd = nil, nil
+	if p.DeliveryWindow != nil {
+		p.DeliveryStart, p.DeliveryEnd = &p.DeliveryWindow.Start, &p.DeliveryWindow.End
+	}
+	return nil
+}
+
 func (p *Order) AfterFind(tx *gorm.DB) (err error) {
 	if p.IceCreamTubs == nil {
 		p.IceCreamTubs = []IceCreamTub{}
 	}
+	// Joins the delivery window columns
+	if p.DeliveryStart != nil && p.DeliveryEnd != nil {
+		p.DeliveryWindow = &DeliveryWindow{Start: *p.DeliveryStart, End: *p.DeliveryEnd}
+	}
 	return nil
 }
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+// Kinds of events recorded in the history of an order.
+const (
+	OrderCreatedEvent     = "created"
+	TubAddedEvent         = "tub_added"
+	TubRemovedEvent       = "tub_removed"
+	PromoCodeAppliedEvent = "promo_applied"
+	AddressChangedEvent   = "address_changed"
+	WindowChangedEvent    = "delivery_window_changed"
+	OrderPaidEvent        = "paid"
+	StatusChangedEvent    = "status_changed"
+	DriverAssignedEvent   = "driver_assigned"
+	DriverUnassignedEvent = "driver_unassigned"
+	OrderCancelledEvent   = "cancelled"
+	OrderRefundedEvent    = "refunded"
+)
+
+// internalOrderEvents are the kinds of events only admins can see in the history of an order.
+var internalOrderEvents = []string{DriverUnassignedEvent}
+
+// OrderEvent is something that happened to an order. Events are only appended, never updated.
+type OrderEvent struct {
+	ID         uint           `json:"id" gorm:"primaryKey; autoIncrement"`
+	OrderID    uint           `json:"orderID" gorm:"not null; index"`
+	Kind       string         `json:"kind" gorm:"not null"`
+	ActorID    uint           `json:"actorID,omitempty"` // id of the user who made the change, zero when hidden
+	CreatedAt  time.Time      `json:"createdAt" gorm:"not null"`
+	Payload    map[string]any `json:"payload,omitempty" gorm:"-"` // details of the change, depending on its kind
+	RawPayload JSONText       `json:"-" gorm:"column:payload; default:'{}'"`
+}
+
+// NewOrderEvent creates an event that happens now.
+func NewOrderEvent(orderID uint, kind string, actorID uint, payload map[string]any) OrderEvent {
+	return OrderEvent{
+		OrderID:   orderID,
+		Kind:      kind,
+		ActorID:   actorID,
+		CreatedAt: time.Now(),
+		Payload:   payload,
+	}
+}
+
+// TubEventPayload describes a tub added to or removed from an order.
+func TubEventPayload(tub IceCreamTub) map[string]any {
+	return map[string]any{
+		"tubID":     tub.ID,
+		"weight":    tub.Weight,
+		"flavors":   tub.Flavors,
+		"unitPrice": tub.UnitPrice,
+	}
+}
+
+// CreatedEventPayload describes a new order.
+func CreatedEventPayload(order Order) map[string]any {
+	payload := map[string]any{"address": order.Address}
+	if order.IsScheduled() {
+		payload["deliveryWindow"] = *order.DeliveryWindow
+	}
+	return payload
+}
+
+// VisibleToOwner filters the history of an order for its owner.
+// Internal events are removed, and the actor is hidden when it is not the owner.
+func VisibleToOwner(events []OrderEvent, ownerID uint) []OrderEvent {
+	visible := []OrderEvent{}
+	for _, event := range events {
+		if slices.Contains(internalOrderEvents, event.Kind) {
+			continue
+		}
+		if event.ActorID != ownerID {
+			event.ActorID = 0
+		}
+		visible = append(visible, event)
+	}
+	return visible
+}
+
+// BeforeSave is executed when Gorm is about to save new data in the database.
+func (e *OrderEvent) BeforeSave(tx *gorm.DB) (err error) {
+	// Serializes Payload from map to JSON
+	if e.Payload != nil {
+		raw, err := json.Marshal(e.Payload)
+		if err != nil {
+			return err
+		}
+		e.RawPayload = JSONText(raw)
+	}
+	return nil
+}
+
+// AfterFind is executed just after Gorm finds data from the database.
+func (e *OrderEvent) AfterFind(tx *gorm.DB) (err error) {
+	// Deserializes RawPayload from JSON to map
+	if e.RawPayload != "" {
+		err := json.Unmarshal([]byte(e.RawPayload), &e.Payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
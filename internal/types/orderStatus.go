@@ -0,0 +1,132 @@
+package types
+
+import (
+	"errors"
+	"icecreamshop/internal/messageErrors"
+	"slices"
+	"strings"
+)
+
+// Fulfillment statuses of an order. They are independent of the payment state.
+const (
+	OrderDraft          = "draft"
+	OrderPlaced         = "placed"
+	OrderPreparing      = "preparing"
+	OrderReady          = "ready"
+	OrderOutForDelivery = "out_for_delivery"
+	OrderDelivered      = "delivered"
+	OrderCancelled      = "cancelled"
+)
+
+// orderTransitions lists the statuses an order can move to from each status.
+// Delivered and cancelled orders are final. Cancellation is not listed, since it has its own rules, see CheckCancellable.
+var orderTransitions = map[string][]string{
+	OrderDraft:          {OrderPlaced},
+	OrderPlaced:         {OrderPreparing},
+	OrderPreparing:      {OrderReady},
+	OrderReady:          {OrderOutForDelivery},
+	OrderOutForDelivery: {OrderDelivered},
+	OrderDelivered:      {},
+	OrderCancelled:      {},
+}
+
+// customerCancellableStatuses are the statuses in which the customer can still cancel their order.
+var customerCancellableStatuses = []string{OrderDraft, OrderPlaced}
+
+// OrderCancellation holds who cancels an order and why.
+type OrderCancellation struct {
+	CancelledBy uint   // id of the user who cancels the order
+	ByAdmin     bool   // admins can cancel orders at any time, customers only before preparation starts
+	Reason      string // required for admins
+}
+
+// AssignableOrderStatuses are the statuses in which an order can get a delivery driver.
+var AssignableOrderStatuses = []string{OrderPlaced, OrderPreparing, OrderReady}
+
+// closedDeliveryStatuses are the statuses in which the delivery of an order cannot change anymore,
+// since the order already left the shop or was cancelled: its driver, address and delivery window are final.
+var closedDeliveryStatuses = []string{OrderOutForDelivery, OrderDelivered, OrderCancelled}
+
+// StaffOrderStatuses are the statuses the shop staff can move an order to.
+var StaffOrderStatuses = []string{OrderPreparing, OrderReady}
+
+// DeliveryOrderStatuses are the statuses a delivery driver can move an order to.
+var DeliveryOrderStatuses = []string{OrderOutForDelivery, OrderDelivered}
+
+// IsValidOrderStatus checks if a status is one of the fulfillment statuses.
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CheckStatusTransition checks if the order can move from its current status to a new one.
+func (p Order) CheckStatusTransition(status string) error {
+	if !IsValidOrderStatus(status) {
+		return errors.New(messageErrors.InvalidOrderStatus)
+	}
+	if status == OrderCancelled {
+		return errors.New(messageErrors.CancelOrderInstead)
+	}
+	if !slices.Contains(orderTransitions[p.Status], status) {
+		return errors.New(messageErrors.IllegalOrderStatusTransition)
+	}
+	return nil
+}
+
+// CheckCancellable checks if the order can be cancelled.
+// Customers can cancel their orders only before preparation starts, while admins can cancel them at any time but need a reason.
+func (p Order) CheckCancellable(cancellation OrderCancellation) error {
+	if p.Status == OrderCancelled {
+		return errors.New(messageErrors.OrderIsAlreadyCancelled)
+	}
+	if !cancellation.ByAdmin && !slices.Contains(customerCancellableStatuses, p.Status) {
+		return errors.New(messageErrors.OrderCannotBeCancelled)
+	}
+	if cancellation.ByAdmin && strings.TrimSpace(cancellation.Reason) == "" {
+		return errors.New(messageErrors.CancellationReasonIsRequired)
+	}
+	return nil
+}
+
+// Cancel moves the order to cancelled, recording who cancelled it and why, and releases its delivery driver.
+// If the order was paid, its payment state becomes refund pending until the refund is issued.
+// If it was being paid, it goes back to pending, so the payment in progress cannot be recorded and is given back instead.
+func (p *Order) Cancel(cancellation OrderCancellation) {
+	p.Status = OrderCancelled
+	p.CancelledBy = cancellation.CancelledBy
+	p.CancellationReason = strings.TrimSpace(cancellation.Reason)
+	p.DeliveryDriverID = 0
+	switch p.PaymentState {
+	case PaymentPaid:
+		p.PaymentState = PaymentRefundPending
+	case PaymentProcessing:
+		p.PaymentState = PaymentPending
+	}
+}
+
+// CheckDriverAssignable checks if a delivery driver can be assigned to the order by an admin.
+// Unlike IsAssignableAt, admins can assign drivers before the order is placed or its window is near.
+func (p Order) CheckDriverAssignable() error {
+	if slices.Contains(closedDeliveryStatuses, p.Status) {
+		return errors.New(messageErrors.OrderCannotGetADriver)
+	}
+	return nil
+}
+
+// CheckDeliveryEditable checks if the customer can still change the address and delivery window of the order.
+func (p Order) CheckDeliveryEditable() error {
+	if slices.Contains(closedDeliveryStatuses, p.Status) {
+		return errors.New(messageErrors.OrderDeliveryCannotBeChanged)
+	}
+	return nil
+}
+
+// ReleasesStock checks if cancelling the order gives its flavors back to the stock, which happens when its preparation has not started.
+func (p Order) ReleasesStock() bool {
+	return slices.Contains(customerCancellableStatuses, p.Status)
+}
+
+// IsDraft checks if the order has not been placed yet, so its tubs can still be changed.
+func (p Order) IsDraft() bool {
+	return p.Status == OrderDraft
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"errors"
+	"icecreamshop/internal/messageErrors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of discount a promo code can give.
+const (
+	PercentageDiscount  = "percentage"
+	FixedAmountDiscount = "fixedAmount"
+	FreeTubDiscount     = "freeTub"
+)
+
+var promoCodeKinds = []string{PercentageDiscount, FixedAmountDiscount, FreeTubDiscount}
+
+type PromoCode struct {
+	Code           string `json:"code" gorm:"primaryKey"`
+	Kind           string `json:"kind" gorm:"not null"`
+	Percentage     uint   `json:"percentage,omitempty"`                          // only for percentage codes, from 1 to 100
+	Amount         Money  `json:"amount" gorm:"embedded; embeddedPrefix:fixed_"` // only for fixed amount codes
+	FreeTubWeight  uint   `json:"freeTubWeight,omitempty"`                       // only for free tub codes
+	ValidFrom      string `json:"validFrom,omitempty"`                           // YYYY-MM-DD, empty means no start date
+	ValidUntil     string `json:"validUntil,omitempty"`                          // YYYY-MM-DD, empty means no end date
+	MaxUses        uint   `json:"maxUses"`                                       // 0 means unlimited
+	MaxUsesPerUser uint   `json:"maxUsesPerUser"`                                // 0 means unlimited
+	MinOrderTotal  Money  `json:"minOrderTotal" gorm:"embedded; embeddedPrefix:min_order_total_"`
+}
+
+// Validate checks the promo code data, filling empty currencies with the default one of the store. Codes are saved in uppercase.
+func (p *PromoCode) Validate(defaultCurrency string) error {
+	p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+	if p.Code == "" {
+		return errors.New(messageErrors.PromoCodeIsRequired)
+	}
+	if !slices.Contains(promoCodeKinds, p.Kind) {
+		return errors.New(messageErrors.InvalidPromoCodeKind)
+	}
+	if p.Kind == PercentageDiscount && (p.Percentage == 0 || p.Percentage > 100) {
+		return errors.New(messageErrors.InvalidPromoCodePercentage)
+	}
+	if p.Kind == FixedAmountDiscount && !p.Amount.IsPositive() {
+		return errors.New(messageErrors.PromoCodeAmountCannotBeZero)
+	}
+	if p.Kind == FreeTubDiscount && p.FreeTubWeight == 0 {
+		return errors.New(messageErrors.PromoCodeWeightIsRequired)
+	}
+	if err := p.Amount.Validate(defaultCurrency); err != nil {
+		return err
+	}
+	if err := p.MinOrderTotal.Validate(defaultCurrency); err != nil {
+		return err
+	}
+	return p.validateValidity()
+}
+
+func (p *PromoCode) validateValidity() error {
+	var from, until time.Time
+	var err error
+	if p.ValidFrom != "" {
+		from, err = time.Parse(DateLayout, p.ValidFrom)
+		if err != nil {
+			return errors.New(messageErrors.InvalidPromoCodeDate)
+		}
+	}
+	if p.ValidUntil != "" {
+		until, err = time.Parse(DateLayout, p.ValidUntil)
+		if err != nil {
+			return errors.New(messageErrors.InvalidPromoCodeDate)
+		}
+	}
+	if p.ValidFrom != "" && p.ValidUntil != "" && until.Before(from) {
+		return errors.New(messageErrors.InvalidPromoCodeValidity)
+	}
+	return nil
+}
+
+// IsValidAt checks if the promo code can be used at a given moment.
+func (p PromoCode) IsValidAt(moment time.Time) bool {
+	date := moment.Format(DateLayout)
+	if p.ValidFrom != "" && date < p.ValidFrom {
+		return false
+	}
+	if p.ValidUntil != "" && date > p.ValidUntil {
+		return false
+	}
+	return true
+}
+
+// CheckApplicableTo checks if the promo code can be applied to an order at a given moment.
+// Usage limits are checked apart with CheckUsageLimits, since they depend on other orders.
+func (p PromoCode) CheckApplicableTo(order Order, moment time.Time) error {
+	if !p.IsValidAt(moment) {
+		return errors.New(messageErrors.PromoCodeIsNotValidNow)
+	}
+	subtotal := order.ComputeSubtotal()
+	if !p.isMetBy(subtotal) {
+		return errors.New(messageErrors.OrderTotalBelowPromoMinimum)
+	}
+	if p.Kind == FixedAmountDiscount && p.Amount.Currency != subtotal.Currency {
+		return errors.New(messageErrors.CurrencyMismatch)
+	}
+	if !p.DiscountFor(order).IsPositive() {
+		return errors.New(messageErrors.PromoCodeNotApplicable)
+	}
+	return nil
+}
+
+// CheckUsageLimits checks if the promo code can be used once more,
+// given how many orders already use it in total and for the same user.
+func (p PromoCode) CheckUsageLimits(totalUses uint, userUses uint) error {
+	if p.MaxUses > 0 && totalUses >= p.MaxUses {
+		return errors.New(messageErrors.PromoCodeUsageLimitReached)
+	}
+	if p.MaxUsesPerUser > 0 && userUses >= p.MaxUsesPerUser {
+		return errors.New(messageErrors.PromoCodeUserLimitReached)
+	}
+	return nil
+}
+
+// DiscountFor calculates the discount the promo code gives to an order, based on the prices of its tubs.
+// The discount is never greater than the order subtotal, and it is zero if the order no longer meets the minimum total.
+func (p PromoCode) DiscountFor(order Order) Money {
+	subtotal := order.ComputeSubtotal()
+	discount := NewMoney(0, subtotal.Currency)
+	if !p.isMetBy(subtotal) {
+		return discount
+	}
+	switch p.Kind {
+	case PercentageDiscount:
+		discount.Amount = subtotal.Amount * int64(p.Percentage) / 100
+	case FixedAmountDiscount:
+		if p.Amount.Currency == subtotal.Currency {
+			discount.Amount = min(p.Amount.Amount, subtotal.Amount)
+		}
+	case FreeTubDiscount:
+		for _, tub := range order.IceCreamTubs {
+			if tub.Weight == p.FreeTubWeight {
+				discount.Amount = max(discount.Amount, tub.UnitPrice.Amount)
+			}
+		}
+	}
+	return discount
+}
+
+// isMetBy checks if an order subtotal reaches the minimum total of the promo code.
+func (p PromoCode) isMetBy(subtotal Money) bool {
+	if p.MinOrderTotal.Amount == 0 {
+		return true
+	}
+	return p.MinOrderTotal.Currency == subtotal.Currency && subtotal.Amount >= p.MinOrderTotal.Amount
+}
+
+func (p PromoCode) IsEqualTo(promo PromoCode) bool {
+	return p == promo
+}
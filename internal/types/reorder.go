@@ -0,0 +1,14 @@
+package types
+
+// Reorder is a new order cloned from a previous one, with the warnings found while cloning it.
+type Reorder struct {
+	Order    Order            `json:"order"`
+	Warnings []ReorderWarning `json:"warnings"`
+}
+
+// ReorderWarning explains why a flavor or a whole tub of the previous order was left out.
+type ReorderWarning struct {
+	TubID    uint   `json:"tubID"`              // id of the tub in the previous order
+	FlavorID string `json:"flavorID,omitempty"` // empty when the whole tub was left out
+	Message  string `json:"message"`
+}
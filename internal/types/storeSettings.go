@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// StoreSettings are the settings of the store used by the orders: the currency of its prices and its delivery schedule.
+type StoreSettings struct {
+	// Currency is the ISO 4217 code of the currency the store prices its products in.
+	Currency string
+	// OpeningTime and ClosingTime are the times of the day the store opens and closes, like 11:00.
+	OpeningTime string
+	ClosingTime string
+	// SchedulingLeadTime is how long in advance a delivery window must start.
+	SchedulingLeadTime time.Duration
+	// DriverNotice is how long before its delivery window a scheduled order becomes assignable to delivery drivers.
+	DriverNotice time.Duration
+}
+
+// DefaultStoreSettings are the settings of a store that is not configured.
+func DefaultStoreSettings() StoreSettings {
+	return StoreSettings{
+		Currency:           fallbackCurrency,
+		OpeningTime:        fallbackOpeningTime,
+		ClosingTime:        fallbackClosingTime,
+		SchedulingLeadTime: fallbackLeadTime,
+		DriverNotice:       fallbackDriverNotice,
+	}
+}
@@ -15,7 +15,7 @@ type User struct {
 	Password       string   `json:"-" gorm:"not null"`
 	Orders         []Order  `json:"order" gorm:"foreignKey:UserID"`
 	Permissions    []string `json:"permissions" gorm:"-"`
-	RawPermissions string   `json:"-" gorm:"column:permissions; type:jsonb; default:'[]'"`
+	RawPermissions JSONText `json:"-" gorm:"column:permissions; default:'[]'"`
 }
 
 type SignUpInput struct {
@@ -43,6 +43,15 @@ func (u *User) IsAdmin() bool {
 	return false
 }
 
+func (u *User) IsStaff() bool {
+	for _, rol := range u.Permissions {
+		if rol == "staff" {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *User) Validate() error {
 	if err := u.ValidateUserDataWithoutPassword(); err != nil {
 		return err
@@ -109,7 +118,7 @@ func (u *User) BeforeSave(tx *gorm.DB) (err error) {
 		if err != nil {
 			return err
 		}
-		u.RawPermissions = string(raw)
+		u.RawPermissions = JSONText(raw)
 	}
 	return nil
 }